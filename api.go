@@ -2,16 +2,15 @@ package zero
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 
-	"github.com/cubevlmu/CZeroBot/log"
 	"github.com/tidwall/gjson"
 
 	"github.com/cubevlmu/CZeroBot/message"
@@ -51,46 +50,29 @@ func formatMessage(msg interface{}) string {
 
 // CallAction 调用 cqhttp API
 func (ctx *Ctx) CallAction(action string, params Params) APIResponse {
-	req := APIRequest{
-		Action: action,
-		Params: params,
-	}
-	rsp, err := ctx.caller.CallAPI(req)
-	if err != nil {
-		log.Errorf("[api] calling action failed, action type : %s error : %v", action, err)
-	}
-	if err == nil && rsp.RetCode != 0 {
-		log.Errorf("[api] calling action failed, action type : %s return value : %v message : %s information : %s", action, rsp.RetCode, rsp.Message, rsp.Wording)
-	}
+	return ctx.CallActionContext(ctx.Context(), action, params)
+}
+
+// CallActionContext 使用 c 调用 cqhttp API, c 取消时立即返回
+//
+// 失败时仅记录日志, 如需获取错误请使用 Ctx.API
+func (ctx *Ctx) CallActionContext(c context.Context, action string, params Params) APIResponse {
+	rsp, _ := ctx.API().WithContext(c).Call(action, params)
 	return rsp
 }
 
 // SendGroupMessage 发送群消息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#send_group_msg-%E5%8F%91%E9%80%81%E7%BE%A4%E6%B6%88%E6%81%AF
 func (ctx *Ctx) SendGroupMessage(groupID int64, message interface{}) int64 {
-	rsp := ctx.CallAction("send_group_msg", Params{ // 调用并保存返回值
-		"group_id": groupID,
-		"message":  message,
-	}).Data.Get("message_id")
-	if rsp.Exists() {
-		log.Infof("[api] sending group message (%v): %v (id=%v)", groupID, formatMessage(message), rsp.Int())
-		return rsp.Int()
-	}
-	return 0 // 无法获取返回值
+	rsp, _ := ctx.API().SendGroupMessage(groupID, message)
+	return rsp
 }
 
 // SendPrivateMessage 发送私聊消息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#send_private_msg-%E5%8F%91%E9%80%81%E7%A7%81%E8%81%8A%E6%B6%88%E6%81%AF
 func (ctx *Ctx) SendPrivateMessage(userID int64, message interface{}) int64 {
-	rsp := ctx.CallAction("send_private_msg", Params{
-		"user_id": userID,
-		"message": message,
-	}).Data.Get("message_id")
-	if rsp.Exists() {
-		log.Infof("[api] sending DM message (%v): %v (id=%v)", userID, formatMessage(message), rsp.Int())
-		return rsp.Int()
-	}
-	return 0 // 无法获取返回值
+	rsp, _ := ctx.API().SendPrivateMessage(userID, message)
+	return rsp
 }
 
 // DeleteMessage 撤回消息
@@ -98,9 +80,7 @@ func (ctx *Ctx) SendPrivateMessage(userID int64, message interface{}) int64 {
 //
 //nolint:interfacer
 func (ctx *Ctx) DeleteMessage(messageID interface{}) {
-	ctx.CallAction("delete_msg", Params{
-		"message_id": messageID,
-	})
+	_ = ctx.API().DeleteMessage(messageID)
 }
 
 // GetMessage 获取消息
@@ -108,52 +88,27 @@ func (ctx *Ctx) DeleteMessage(messageID interface{}) {
 //
 //nolint:interfacer
 func (ctx *Ctx) GetMessage(messageID interface{}, nologreply ...bool) Message {
-	params := Params{
-		"message_id": messageID,
-	}
-	if len(nologreply) > 0 && nologreply[0] {
-		params["__zerobot_no_log_mseeage_id__"] = true
-	}
-	rsp := ctx.CallAction("get_msg", params).Data
-	m := Message{
-		Elements:    message.ParseMessage(helper.StringToBytes(rsp.Get("message").Raw)),
-		MessageID:   message.NewMessageIDFromInteger(rsp.Get("message_id").Int()),
-		MessageType: rsp.Get("message_type").String(),
-		Sender:      &User{},
-	}
-	err := json.Unmarshal(helper.StringToBytes(rsp.Get("sender").Raw), m.Sender)
-	if err != nil {
-		return Message{}
-	}
-	return m
+	rsp, _ := ctx.API().GetMessage(messageID, nologreply...)
+	return rsp
 }
 
 // GetForwardMessage 获取合并转发消息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_forward_msg-%E8%8E%B7%E5%8F%96%E5%90%88%E5%B9%B6%E8%BD%AC%E5%8F%91%E6%B6%88%E6%81%AF
 func (ctx *Ctx) GetForwardMessage(id string) gjson.Result {
-	rsp := ctx.CallAction("get_forward_msg", Params{
-		"id": id,
-	}).Data
+	rsp, _ := ctx.API().GetForwardMessage(id)
 	return rsp
 }
 
 // SendLike 发送好友赞
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#send_like-%E5%8F%91%E9%80%81%E5%A5%BD%E5%8F%8B%E8%B5%9E
 func (ctx *Ctx) SendLike(userID int64, times int) {
-	ctx.CallAction("send_like", Params{
-		"user_id": userID,
-		"times":   times,
-	})
+	_ = ctx.API().SendLike(userID, times)
 }
 
 // SetGroupKick 群组踢人
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_kick-%E7%BE%A4%E7%BB%84%E8%B8%A2%E4%BA%BA
 func (ctx *Ctx) SetGroupKick(groupID, userID int64, rejectAddRequest bool) {
-	ctx.CallAction("set_group_kick", Params{
-		"group_id":           groupID,
-		"user_id":            userID,
-		"reject_add_request": rejectAddRequest,
-	})
+	_ = ctx.API().SetGroupKick(groupID, userID, rejectAddRequest)
 }
 
 // SetThisGroupKick 本群组踢人
@@ -165,11 +120,7 @@ func (ctx *Ctx) SetThisGroupKick(userID int64, rejectAddRequest bool) {
 // SetGroupBan 群组单人禁言
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_ban-%E7%BE%A4%E7%BB%84%E5%8D%95%E4%BA%BA%E7%A6%81%E8%A8%80
 func (ctx *Ctx) SetGroupBan(groupID, userID, duration int64) {
-	ctx.CallAction("set_group_ban", Params{
-		"group_id": groupID,
-		"user_id":  userID,
-		"duration": duration,
-	})
+	_ = ctx.API().SetGroupBan(groupID, userID, duration)
 }
 
 // SetThisGroupBan 本群组单人禁言
@@ -181,10 +132,7 @@ func (ctx *Ctx) SetThisGroupBan(userID, duration int64) {
 // SetGroupWholeBan 群组全员禁言
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_whole_ban-%E7%BE%A4%E7%BB%84%E5%85%A8%E5%91%98%E7%A6%81%E8%A8%80
 func (ctx *Ctx) SetGroupWholeBan(groupID int64, enable bool) {
-	ctx.CallAction("set_group_whole_ban", Params{
-		"group_id": groupID,
-		"enable":   enable,
-	})
+	_ = ctx.API().SetGroupWholeBan(groupID, enable)
 }
 
 // SetThisGroupWholeBan 本群组全员禁言
@@ -196,11 +144,7 @@ func (ctx *Ctx) SetThisGroupWholeBan(enable bool) {
 // SetGroupAdmin 群组设置管理员
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_whole_ban-%E7%BE%A4%E7%BB%84%E5%85%A8%E5%91%98%E7%A6%81%E8%A8%80
 func (ctx *Ctx) SetGroupAdmin(groupID, userID int64, enable bool) {
-	ctx.CallAction("set_group_admin", Params{
-		"group_id": groupID,
-		"user_id":  userID,
-		"enable":   enable,
-	})
+	_ = ctx.API().SetGroupAdmin(groupID, userID, enable)
 }
 
 // SetThisGroupAdmin 本群组设置管理员
@@ -212,10 +156,7 @@ func (ctx *Ctx) SetThisGroupAdmin(userID int64, enable bool) {
 // SetGroupAnonymous 群组匿名
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_anonymous-%E7%BE%A4%E7%BB%84%E5%8C%BF%E5%90%8D
 func (ctx *Ctx) SetGroupAnonymous(groupID int64, enable bool) {
-	ctx.CallAction("set_group_anonymous", Params{
-		"group_id": groupID,
-		"enable":   enable,
-	})
+	_ = ctx.API().SetGroupAnonymous(groupID, enable)
 }
 
 // SetThisGroupAnonymous 群组匿名
@@ -227,11 +168,7 @@ func (ctx *Ctx) SetThisGroupAnonymous(enable bool) {
 // SetGroupCard 设置群名片（群备注）
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_card-%E8%AE%BE%E7%BD%AE%E7%BE%A4%E5%90%8D%E7%89%87%E7%BE%A4%E5%A4%87%E6%B3%A8
 func (ctx *Ctx) SetGroupCard(groupID, userID int64, card string) {
-	ctx.CallAction("set_group_card", Params{
-		"group_id": groupID,
-		"user_id":  userID,
-		"card":     card,
-	})
+	_ = ctx.API().SetGroupCard(groupID, userID, card)
 }
 
 // SetThisGroupCard 设置本群名片（群备注）
@@ -243,10 +180,7 @@ func (ctx *Ctx) SetThisGroupCard(userID int64, card string) {
 // SetGroupName 设置群名
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_name-%E8%AE%BE%E7%BD%AE%E7%BE%A4%E5%90%8D
 func (ctx *Ctx) SetGroupName(groupID int64, groupName string) {
-	ctx.CallAction("set_group_name", Params{
-		"group_id":   groupID,
-		"group_name": groupName,
-	})
+	_ = ctx.API().SetGroupName(groupID, groupName)
 }
 
 // SetThisGroupName 设置本群名
@@ -258,10 +192,7 @@ func (ctx *Ctx) SetThisGroupName(groupName string) {
 // SetGroupLeave 退出群组
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_leave-%E9%80%80%E5%87%BA%E7%BE%A4%E7%BB%84
 func (ctx *Ctx) SetGroupLeave(groupID int64, isDismiss bool) {
-	ctx.CallAction("set_group_leave", Params{
-		"group_id":   groupID,
-		"is_dismiss": isDismiss,
-	})
+	_ = ctx.API().SetGroupLeave(groupID, isDismiss)
 }
 
 // SetThisGroupLeave 退出本群组
@@ -273,11 +204,7 @@ func (ctx *Ctx) SetThisGroupLeave(isDismiss bool) {
 // SetGroupSpecialTitle 设置群组专属头衔
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_special_title-%E8%AE%BE%E7%BD%AE%E7%BE%A4%E7%BB%84%E4%B8%93%E5%B1%9E%E5%A4%B4%E8%A1%94
 func (ctx *Ctx) SetGroupSpecialTitle(groupID, userID int64, specialTitle string) {
-	ctx.CallAction("set_group_special_title", Params{
-		"group_id":      groupID,
-		"user_id":       userID,
-		"special_title": specialTitle,
-	})
+	_ = ctx.API().SetGroupSpecialTitle(groupID, userID, specialTitle)
 }
 
 // SetThisGroupSpecialTitle 设置本群组专属头衔
@@ -289,32 +216,27 @@ func (ctx *Ctx) SetThisGroupSpecialTitle(userID int64, specialTitle string) {
 // SetFriendAddRequest 处理加好友请求
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_friend_add_request-%E5%A4%84%E7%90%86%E5%8A%A0%E5%A5%BD%E5%8F%8B%E8%AF%B7%E6%B1%82
 func (ctx *Ctx) SetFriendAddRequest(flag string, approve bool, remark string) {
-	ctx.CallAction("set_friend_add_request", Params{
-		"flag":    flag,
-		"approve": approve,
-		"remark":  remark,
-	})
+	_ = ctx.API().SetFriendAddRequest(flag, approve, remark)
 }
 
 // SetGroupAddRequest 处理加群请求／邀请
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#set_group_add_request-%E5%A4%84%E7%90%86%E5%8A%A0%E7%BE%A4%E8%AF%B7%E6%B1%82%E9%82%80%E8%AF%B7
 func (ctx *Ctx) SetGroupAddRequest(flag string, subType string, approve bool, reason string) {
-	ctx.CallAction("set_group_add_request", Params{
-		"flag":     flag,
-		"sub_type": subType,
-		"approve":  approve,
-		"reason":   reason,
-	})
+	_ = ctx.API().SetGroupAddRequest(flag, subType, approve, reason)
 }
 
 // GetLoginInfo 获取登录号信息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_login_info-%E8%8E%B7%E5%8F%96%E7%99%BB%E5%BD%95%E5%8F%B7%E4%BF%A1%E6%81%AF
+//
+// 类型化的结果与错误见 API.GetLoginInfo
 func (ctx *Ctx) GetLoginInfo() gjson.Result {
 	return ctx.CallAction("get_login_info", Params{}).Data
 }
 
 // GetStrangerInfo 获取陌生人信息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_stranger_info-%E8%8E%B7%E5%8F%96%E9%99%8C%E7%94%9F%E4%BA%BA%E4%BF%A1%E6%81%AF
+//
+// 类型化的结果与错误见 API.GetStrangerInfo
 func (ctx *Ctx) GetStrangerInfo(userID int64, noCache bool) gjson.Result {
 	return ctx.CallAction("get_stranger_info", Params{
 		"user_id":  userID,
@@ -324,6 +246,8 @@ func (ctx *Ctx) GetStrangerInfo(userID int64, noCache bool) gjson.Result {
 
 // GetFriendList 获取好友列表
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_friend_list-%E8%8E%B7%E5%8F%96%E5%A5%BD%E5%8F%8B%E5%88%97%E8%A1%A8
+//
+// 类型化的结果与错误见 API.GetFriendList
 func (ctx *Ctx) GetFriendList() gjson.Result {
 	return ctx.CallAction("get_friend_list", Params{}).Data
 }
@@ -331,13 +255,8 @@ func (ctx *Ctx) GetFriendList() gjson.Result {
 // GetGroupInfo 获取群信息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_info-%E8%8E%B7%E5%8F%96%E7%BE%A4%E4%BF%A1%E6%81%AF
 func (ctx *Ctx) GetGroupInfo(groupID int64, noCache bool) Group {
-	rsp := ctx.CallAction("get_group_info", Params{
-		"group_id": groupID,
-		"no_cache": noCache,
-	}).Data
-	group := Group{}
-	_ = json.Unmarshal([]byte(rsp.Raw), &group)
-	return group
+	rsp, _ := ctx.API().GetGroupInfo(groupID, noCache)
+	return rsp
 }
 
 // GetThisGroupInfo 获取本群信息
@@ -348,12 +267,16 @@ func (ctx *Ctx) GetThisGroupInfo(noCache bool) Group {
 
 // GetGroupList 获取群列表
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_list-%E8%8E%B7%E5%8F%96%E7%BE%A4%E5%88%97%E8%A1%A8
+//
+// 类型化的结果与错误见 API.GetGroupList
 func (ctx *Ctx) GetGroupList() gjson.Result {
 	return ctx.CallAction("get_group_list", Params{}).Data
 }
 
 // GetGroupMemberInfo 获取群成员信息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_member_info-%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%88%90%E5%91%98%E4%BF%A1%E6%81%AF
+//
+// 类型化的结果与错误见 API.GetGroupMemberInfo
 func (ctx *Ctx) GetGroupMemberInfo(groupID int64, userID int64, noCache bool) gjson.Result {
 	return ctx.CallAction("get_group_member_info", Params{
 		"group_id": groupID,
@@ -364,12 +287,16 @@ func (ctx *Ctx) GetGroupMemberInfo(groupID int64, userID int64, noCache bool) gj
 
 // GetThisGroupMemberInfo 获取本群成员信息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_member_info-%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%88%90%E5%91%98%E4%BF%A1%E6%81%AF
+//
+// 类型化的结果与错误见 API.GetThisGroupMemberInfo
 func (ctx *Ctx) GetThisGroupMemberInfo(userID int64, noCache bool) gjson.Result {
 	return ctx.GetGroupMemberInfo(ctx.Event.GroupID, userID, noCache)
 }
 
 // GetGroupMemberList 获取群成员列表
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_member_list-%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%88%90%E5%91%98%E5%88%97%E8%A1%A8
+//
+// 类型化的结果与错误见 API.GetGroupMemberList
 func (ctx *Ctx) GetGroupMemberList(groupID int64) gjson.Result {
 	return ctx.CallAction("get_group_member_list", Params{
 		"group_id": groupID,
@@ -377,12 +304,16 @@ func (ctx *Ctx) GetGroupMemberList(groupID int64) gjson.Result {
 }
 
 // GetThisGroupMemberList 获取本群成员列表
+//
+// 类型化的结果与错误见 API.GetThisGroupMemberList
 func (ctx *Ctx) GetThisGroupMemberList() gjson.Result {
 	return ctx.GetGroupMemberList(ctx.Event.GroupID)
 }
 
 // GetGroupMemberListNoCache 无缓存获取群员列表
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_member_list-%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%88%90%E5%91%98%E5%88%97%E8%A1%A8
+//
+// 类型化的结果与错误见 API.GetGroupMemberListNoCache
 func (ctx *Ctx) GetGroupMemberListNoCache(groupID int64) gjson.Result {
 	return ctx.CallAction("get_group_member_list", Params{
 		"group_id": groupID,
@@ -391,12 +322,16 @@ func (ctx *Ctx) GetGroupMemberListNoCache(groupID int64) gjson.Result {
 }
 
 // GetThisGroupMemberListNoCache 无缓存获取本群员列表
+//
+// 类型化的结果与错误见 API.GetThisGroupMemberListNoCache
 func (ctx *Ctx) GetThisGroupMemberListNoCache() gjson.Result {
 	return ctx.GetGroupMemberListNoCache(ctx.Event.GroupID)
 }
 
 // GetGroupHonorInfo 获取群荣誉信息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_honor_info-%E8%8E%B7%E5%8F%96%E7%BE%A4%E8%8D%A3%E8%AA%89%E4%BF%A1%E6%81%AF
+//
+// 类型化的结果与错误见 API.GetGroupHonorInfo
 func (ctx *Ctx) GetGroupHonorInfo(groupID int64, hType string) gjson.Result {
 	return ctx.CallAction("get_group_honor_info", Params{
 		"group_id": groupID,
@@ -406,12 +341,16 @@ func (ctx *Ctx) GetGroupHonorInfo(groupID int64, hType string) gjson.Result {
 
 // GetThisGroupHonorInfo 获取本群荣誉信息
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_honor_info-%E8%8E%B7%E5%8F%96%E7%BE%A4%E8%8D%A3%E8%AA%89%E4%BF%A1%E6%81%AF
+//
+// 类型化的结果与错误见 API.GetThisGroupHonorInfo
 func (ctx *Ctx) GetThisGroupHonorInfo(hType string) gjson.Result {
 	return ctx.GetGroupHonorInfo(ctx.Event.GroupID, hType)
 }
 
 // GetRecord 获取语音
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_record-%E8%8E%B7%E5%8F%96%E8%AF%AD%E9%9F%B3
+//
+// 类型化的结果与错误见 API.GetRecord
 func (ctx *Ctx) GetRecord(file string, outFormat string) gjson.Result {
 	return ctx.CallAction("get_record", Params{
 		"file":       file,
@@ -421,6 +360,8 @@ func (ctx *Ctx) GetRecord(file string, outFormat string) gjson.Result {
 
 // GetImage 获取图片
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_image-%E8%8E%B7%E5%8F%96%E5%9B%BE%E7%89%87
+//
+// 类型化的结果与错误见 API.GetImage
 func (ctx *Ctx) GetImage(file string) gjson.Result {
 	return ctx.CallAction("get_image", Params{
 		"file": file,
@@ -429,6 +370,8 @@ func (ctx *Ctx) GetImage(file string) gjson.Result {
 
 // GetVersionInfo 获取运行状态
 // https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_status-%E8%8E%B7%E5%8F%96%E8%BF%90%E8%A1%8C%E7%8A%B6%E6%80%81
+//
+// 类型化的结果与错误见 API.GetVersionInfo
 func (ctx *Ctx) GetVersionInfo() gjson.Result {
 	return ctx.CallAction("get_version_info", Params{}).Data
 }
@@ -438,10 +381,7 @@ func (ctx *Ctx) GetVersionInfo() gjson.Result {
 // SetGroupPortrait 设置群头像
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%AE%BE%E7%BD%AE%E7%BE%A4%E5%A4%B4%E5%83%8F
 func (ctx *Ctx) SetGroupPortrait(groupID int64, file string) {
-	ctx.CallAction("set_group_portrait", Params{
-		"group_id": groupID,
-		"file":     file,
-	})
+	_ = ctx.API().SetGroupPortrait(groupID, file)
 }
 
 // SetThisGroupPortrait 设置本群头像
@@ -453,81 +393,72 @@ func (ctx *Ctx) SetThisGroupPortrait(file string) {
 // OCRImage 图片OCR
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E5%9B%BE%E7%89%87ocr
 func (ctx *Ctx) OCRImage(file string) gjson.Result {
-	return ctx.CallAction("ocr_image", Params{
-		"image": file,
-	}).Data
+	rsp, _ := ctx.API().OCRImage(file)
+	return rsp
 }
 
 // SendGroupForwardMessage 发送合并转发(群)
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E5%9B%BE%E7%89%87ocr
 func (ctx *Ctx) SendGroupForwardMessage(groupID int64, message message.Message) gjson.Result {
-	return ctx.CallAction("send_group_forward_msg", Params{
-		"group_id": groupID,
-		"messages": message,
-	}).Data
+	rsp, _ := ctx.API().SendGroupForwardMessage(groupID, message)
+	return rsp
 }
 
 // SendPrivateForwardMessage 发送合并转发(私聊)
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E5%9B%BE%E7%89%87ocr
 func (ctx *Ctx) SendPrivateForwardMessage(userID int64, message message.Message) gjson.Result {
-	return ctx.CallAction("send_private_forward_msg", Params{
-		"user_id":  userID,
-		"messages": message,
-	}).Data
+	rsp, _ := ctx.API().SendPrivateForwardMessage(userID, message)
+	return rsp
 }
 
 // ForwardFriendSingleMessage 转发单条消息到好友
 //
 // https://llonebot.github.io/zh-CN/develop/extends_api
 func (ctx *Ctx) ForwardFriendSingleMessage(userID int64, messageID interface{}) APIResponse {
-	return ctx.CallAction("forward_friend_single_msg", Params{
-		"user_id":    userID,
-		"message_id": messageID,
-	})
+	rsp, _ := ctx.API().ForwardFriendSingleMessage(userID, messageID)
+	return rsp
 }
 
 // ForwardGroupSingleMessage 转发单条消息到群
 //
 // https://llonebot.github.io/zh-CN/develop/extends_api
 func (ctx *Ctx) ForwardGroupSingleMessage(groupID int64, messageID interface{}) APIResponse {
-	return ctx.CallAction("forward_group_single_msg", Params{
-		"group_id":   groupID,
-		"message_id": messageID,
-	})
+	rsp, _ := ctx.API().ForwardGroupSingleMessage(groupID, messageID)
+	return rsp
 }
 
 // GetGroupSystemMessage 获取群系统消息
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E7%B3%BB%E7%BB%9F%E6%B6%88%E6%81%AF
 func (ctx *Ctx) GetGroupSystemMessage() gjson.Result {
-	return ctx.CallAction("get_group_system_msg", Params{}).Data
+	rsp, _ := ctx.API().GetGroupSystemMessage()
+	return rsp
 }
 
 // MarkMessageAsRead 标记消息已读
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E6%A0%87%E8%AE%B0%E6%B6%88%E6%81%AF%E5%B7%B2%E8%AF%BB
 func (ctx *Ctx) MarkMessageAsRead(messageID int64) APIResponse {
-	return ctx.CallAction("mark_msg_as_read", Params{
-		"message_id": messageID,
-	})
+	rsp, _ := ctx.API().MarkMessageAsRead(messageID)
+	return rsp
 }
 
 // MarkThisMessageAsRead 标记本消息已读
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E6%A0%87%E8%AE%B0%E6%B6%88%E6%81%AF%E5%B7%B2%E8%AF%BB
 func (ctx *Ctx) MarkThisMessageAsRead() APIResponse {
-	return ctx.CallAction("mark_msg_as_read", Params{
-		"message_id": ctx.Event.MessageID,
-	})
+	rsp, _ := ctx.API().MarkThisMessageAsRead()
+	return rsp
 }
 
 // GetOnlineClients 获取当前账号在线客户端列表
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E5%BD%93%E5%89%8D%E8%B4%A6%E5%8F%B7%E5%9C%A8%E7%BA%BF%E5%AE%A2%E6%88%B7%E7%AB%AF%E5%88%97%E8%A1%A8
 func (ctx *Ctx) GetOnlineClients(noCache bool) gjson.Result {
-	return ctx.CallAction("get_online_clients", Params{
-		"no_cache": noCache,
-	}).Data
+	rsp, _ := ctx.API().GetOnlineClients(noCache)
+	return rsp
 }
 
 // GetGroupAtAllRemain 获取群@全体成员剩余次数
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E5%85%A8%E4%BD%93%E6%88%90%E5%91%98%E5%89%A9%E4%BD%99%E6%AC%A1%E6%95%B0
+//
+// 类型化的结果与错误见 API.GetGroupAtAllRemain
 func (ctx *Ctx) GetGroupAtAllRemain(groupID int64) gjson.Result {
 	return ctx.CallAction("get_group_at_all_remain", Params{
 		"group_id": groupID,
@@ -536,6 +467,8 @@ func (ctx *Ctx) GetGroupAtAllRemain(groupID int64) gjson.Result {
 
 // GetThisGroupAtAllRemain 获取本群@全体成员剩余次数
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E5%85%A8%E4%BD%93%E6%88%90%E5%91%98%E5%89%A9%E4%BD%99%E6%AC%A1%E6%95%B0
+//
+// 类型化的结果与错误见 API.GetThisGroupAtAllRemain
 func (ctx *Ctx) GetThisGroupAtAllRemain() gjson.Result {
 	return ctx.GetGroupAtAllRemain(ctx.Event.GroupID)
 }
@@ -545,18 +478,14 @@ func (ctx *Ctx) GetThisGroupAtAllRemain() gjson.Result {
 //
 //	messageID: 起始消息序号, 可通过 get_msg 获得
 func (ctx *Ctx) GetGroupMessageHistory(groupID, messageID int64) gjson.Result {
-	return ctx.CallAction("get_group_msg_history", Params{
-		"group_id":    groupID,
-		"message_seq": messageID, // 兼容旧版本
-		"message_id":  messageID,
-	}).Data
+	rsp, _ := ctx.API().GetGroupMessageHistory(groupID, messageID)
+	return rsp
 }
 
 // GettLatestGroupMessageHistory 获取最新群消息历史记录
 func (ctx *Ctx) GetLatestGroupMessageHistory(groupID int64) gjson.Result {
-	return ctx.CallAction("get_group_msg_history", Params{
-		"group_id": groupID,
-	}).Data
+	rsp, _ := ctx.API().GetLatestGroupMessageHistory(groupID)
+	return rsp
 }
 
 // GetThisGroupMessageHistory 获取本群消息历史记录
@@ -573,6 +502,8 @@ func (ctx *Ctx) GetLatestThisGroupMessageHistory() gjson.Result {
 
 // GetGroupEssenceMessageList 获取群精华消息列表
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%B2%BE%E5%8D%8E%E6%B6%88%E6%81%AF%E5%88%97%E8%A1%A8
+//
+// 类型化的结果与错误见 API.GetGroupEssenceMessageList
 func (ctx *Ctx) GetGroupEssenceMessageList(groupID int64) gjson.Result {
 	return ctx.CallAction("get_essence_msg_list", Params{
 		"group_id": groupID,
@@ -580,6 +511,8 @@ func (ctx *Ctx) GetGroupEssenceMessageList(groupID int64) gjson.Result {
 }
 
 // GetThisGroupEssenceMessageList 获取本群精华消息列表
+//
+// 类型化的结果与错误见 API.GetThisGroupEssenceMessageList
 func (ctx *Ctx) GetThisGroupEssenceMessageList() gjson.Result {
 	return ctx.GetGroupEssenceMessageList(ctx.Event.GroupID)
 }
@@ -587,39 +520,28 @@ func (ctx *Ctx) GetThisGroupEssenceMessageList() gjson.Result {
 // SetGroupEssenceMessage 设置群精华消息
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%AE%BE%E7%BD%AE%E7%B2%BE%E5%8D%8E%E6%B6%88%E6%81%AF
 func (ctx *Ctx) SetGroupEssenceMessage(messageID int64) APIResponse {
-	return ctx.CallAction("set_essence_msg", Params{
-		"message_id": messageID,
-	})
+	rsp, _ := ctx.API().SetGroupEssenceMessage(messageID)
+	return rsp
 }
 
 // DeleteGroupEssenceMessage 移出群精华消息
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E7%A7%BB%E5%87%BA%E7%B2%BE%E5%8D%8E%E6%B6%88%E6%81%AF
 func (ctx *Ctx) DeleteGroupEssenceMessage(messageID int64) APIResponse {
-	return ctx.CallAction("delete_essence_msg", Params{
-		"message_id": messageID,
-	})
+	rsp, _ := ctx.API().DeleteGroupEssenceMessage(messageID)
+	return rsp
 }
 
 // GetWordSlices 获取中文分词
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E4%B8%AD%E6%96%87%E5%88%86%E8%AF%8D
 func (ctx *Ctx) GetWordSlices(content string) gjson.Result {
-	return ctx.CallAction(".get_word_slices", Params{
-		"content": content,
-	}).Data
+	rsp, _ := ctx.API().GetWordSlices(content)
+	return rsp
 }
 
 // SendGuildChannelMessage 发送频道消息
 func (ctx *Ctx) SendGuildChannelMessage(guildID, channelID string, message interface{}) string {
-	rsp := ctx.CallAction("send_guild_channel_msg", Params{
-		"guild_id":   guildID,
-		"channel_id": channelID,
-		"message":    message,
-	}).Data.Get("message_id")
-	if rsp.Exists() {
-		log.Infof("[api] sending QQ Channal's message (%v-%v): %v (id=%v)", guildID, channelID, formatMessage(message), rsp.Int())
-		return rsp.String()
-	}
-	return "0" // 无法获取返回值
+	rsp, _ := ctx.API().SendGuildChannelMessage(guildID, channelID, message)
+	return rsp
 }
 
 // NickName 从 args/at 获取昵称，如果都没有则获取发送者的昵称
@@ -645,6 +567,8 @@ func (ctx *Ctx) CardOrNickName(uid int64) (name string) {
 
 // GetGroupFilesystemInfo 获取群文件系统信息
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%96%87%E4%BB%B6%E7%B3%BB%E7%BB%9F%E4%BF%A1%E6%81%AF
+//
+// 类型化的结果与错误见 API.GetGroupFilesystemInfo
 func (ctx *Ctx) GetGroupFilesystemInfo(groupID int64) gjson.Result {
 	return ctx.CallAction("get_group_file_system_info", Params{
 		"group_id": groupID,
@@ -653,12 +577,16 @@ func (ctx *Ctx) GetGroupFilesystemInfo(groupID int64) gjson.Result {
 
 // GetThisGroupFilesystemInfo 获取本群文件系统信息
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%96%87%E4%BB%B6%E7%B3%BB%E7%BB%9F%E4%BF%A1%E6%81%AF
+//
+// 类型化的结果与错误见 API.GetThisGroupFilesystemInfo
 func (ctx *Ctx) GetThisGroupFilesystemInfo() gjson.Result {
 	return ctx.GetGroupFilesystemInfo(ctx.Event.GroupID)
 }
 
 // GetGroupRootFiles 获取群根目录文件列表
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%A0%B9%E7%9B%AE%E5%BD%95%E6%96%87%E4%BB%B6%E5%88%97%E8%A1%A8
+//
+// 类型化的结果与错误见 API.GetGroupRootFiles
 func (ctx *Ctx) GetGroupRootFiles(groupID int64) gjson.Result {
 	return ctx.CallAction("get_group_root_files", Params{
 		"group_id": groupID,
@@ -667,12 +595,16 @@ func (ctx *Ctx) GetGroupRootFiles(groupID int64) gjson.Result {
 
 // GetThisGroupRootFiles 获取本群根目录文件列表
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%A0%B9%E7%9B%AE%E5%BD%95%E6%96%87%E4%BB%B6%E5%88%97%E8%A1%A8
+//
+// 类型化的结果与错误见 API.GetThisGroupRootFiles
 func (ctx *Ctx) GetThisGroupRootFiles() gjson.Result {
 	return ctx.GetGroupRootFiles(ctx.Event.GroupID)
 }
 
 // GetGroupFilesByFolder 获取群子目录文件列表
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E5%AD%90%E7%9B%AE%E5%BD%95%E6%96%87%E4%BB%B6%E5%88%97%E8%A1%A8
+//
+// 类型化的结果与错误见 API.GetGroupFilesByFolder
 func (ctx *Ctx) GetGroupFilesByFolder(groupID int64, folderID string) gjson.Result {
 	return ctx.CallAction("get_group_files_by_folder", Params{
 		"group_id":  groupID,
@@ -682,6 +614,8 @@ func (ctx *Ctx) GetGroupFilesByFolder(groupID int64, folderID string) gjson.Resu
 
 // GetThisGroupFilesByFolder 获取本群子目录文件列表
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E5%AD%90%E7%9B%AE%E5%BD%95%E6%96%87%E4%BB%B6%E5%88%97%E8%A1%A8
+//
+// 类型化的结果与错误见 API.GetThisGroupFilesByFolder
 func (ctx *Ctx) GetThisGroupFilesByFolder(folderID string) gjson.Result {
 	return ctx.GetGroupFilesByFolder(ctx.Event.GroupID, folderID)
 }
@@ -689,11 +623,8 @@ func (ctx *Ctx) GetThisGroupFilesByFolder(folderID string) gjson.Result {
 // GetGroupFileURL 获取群文件资源链接
 // https://github.com/Mrs4s/go-cqhttp/blob/master/docs/cqhttp.md#%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%96%87%E4%BB%B6%E8%B5%84%E6%BA%90%E9%93%BE%E6%8E%A5
 func (ctx *Ctx) GetGroupFileURL(groupID, busid int64, fileID string) string {
-	return ctx.CallAction("get_group_file_url", Params{
-		"group_id": groupID,
-		"file_id":  fileID,
-		"busid":    busid,
-	}).Data.Get("url").Str
+	rsp, _ := ctx.API().GetGroupFileURL(groupID, busid, fileID)
+	return rsp
 }
 
 // GetThisGroupFileURL 获取本群文件资源链接
@@ -707,12 +638,8 @@ func (ctx *Ctx) GetThisGroupFileURL(busid int64, fileID string) string {
 //
 //	msg: FILE_NOT_FOUND FILE_SYSTEM_UPLOAD_API_ERROR ...
 func (ctx *Ctx) UploadGroupFile(groupID int64, file, name, folder string) APIResponse {
-	return ctx.CallAction("upload_group_file", Params{
-		"group_id": groupID,
-		"file":     file,
-		"name":     name,
-		"folder":   folder,
-	})
+	rsp, _ := ctx.API().UploadGroupFile(groupID, file, name, folder)
+	return rsp
 }
 
 // UploadThisGroupFile 上传本群文件
@@ -727,14 +654,15 @@ func (ctx *Ctx) UploadThisGroupFile(file, name, folder string) APIResponse {
 //
 // https://llonebot.github.io/zh-CN/develop/extends_api
 func (ctx *Ctx) SetMyAvatar(file string) APIResponse {
-	return ctx.CallAction("set_qq_avatar", Params{
-		"file": file,
-	})
+	rsp, _ := ctx.API().SetMyAvatar(file)
+	return rsp
 }
 
 // GetFile 下载收到的群文件或私聊文件
 //
 // https://llonebot.github.io/zh-CN/develop/extends_api
+//
+// 类型化的结果与错误见 API.GetFile
 func (ctx *Ctx) GetFile(fileID string) gjson.Result {
 	return ctx.CallAction("get_file", Params{
 		"file_id": fileID,
@@ -747,73 +675,51 @@ func (ctx *Ctx) GetFile(fileID string) gjson.Result {
 //
 // emoji_id 参考 https://bot.q.qq.com/wiki/develop/api-v2/openapi/emoji/model.html#EmojiType
 func (ctx *Ctx) SetMessageEmojiLike(messageID interface{}, emojiID rune) error {
-	ret := ctx.CallAction("set_msg_emoji_like", Params{
-		"message_id": messageID,
-		"emoji_id":   strconv.Itoa(int(emojiID)),
-	}).Data.Get("errMsg").Str
-	if ret != "" {
-		return errors.New(ret)
-	}
-	return nil
+	return ctx.API().SetMessageEmojiLike(messageID, emojiID)
 }
 
 // SetGroupSign 群签到
 //
 // https://napneko.github.io/develop/api/doc#set-group-sign-%E7%BE%A4%E7%AD%BE%E5%88%B0
 func (ctx *Ctx) SetGroupSign(groupID int64) {
-	ctx.CallAction("set_group_sign", Params{
-		"group_id": groupID,
-	})
+	_ = ctx.API().SetGroupSign(groupID)
 }
 
 // GroupPoke 群聊戳一戳
 //
 // https://napneko.github.io/develop/api/doc#group-poke-%E7%BE%A4%E8%81%8A%E6%88%B3%E4%B8%80%E6%88%B3
 func (ctx *Ctx) GroupPoke(groupID, userID int64) {
-	ctx.CallAction("group_poke", Params{
-		"group_id": groupID,
-		"user_id":  userID,
-	})
+	_ = ctx.API().GroupPoke(groupID, userID)
 }
 
 // FriendPoke 私聊戳一戳
 //
 // https://napneko.github.io/develop/api/doc#friend-poke-%E7%A7%81%E8%81%8A%E6%88%B3%E4%B8%80%E6%88%B3
 func (ctx *Ctx) FriendPoke(userID int64) {
-	ctx.CallAction("friend_poke", Params{
-		"user_id": userID,
-	})
+	_ = ctx.API().FriendPoke(userID)
 }
 
 // ArkSharePeer 获取推荐好友/群聊卡片
 //
 // c
 func (ctx *Ctx) ArkSharePeer(userID, groupID string) string {
-	return ctx.CallAction("ArkSharePeer", Params{
-		"user_id":  userID,
-		"group_id": groupID,
-	}).Data.Get("arkJson").String()
+	rsp, _ := ctx.API().ArkSharePeer(userID, groupID)
+	return rsp
 }
 
 // ArkShareGroup 获取推荐群聊卡片
 //
 // https://napneko.github.io/develop/api/doc#arksharegroup-%E8%8E%B7%E5%8F%96%E6%8E%A8%E8%8D%90%E7%BE%A4%E8%81%8A%E5%8D%A1%E7%89%87
 func (ctx *Ctx) ArkShareGroup(groupID string) string {
-	return ctx.CallAction("ArkShareGroup", Params{
-		"group_id": groupID,
-	}).Data.String()
+	rsp, _ := ctx.API().ArkShareGroup(groupID)
+	return rsp
 }
 
 // GetRobotUinRange 获取机器人账号范围
 //
 // https://napneko.github.io/develop/api/doc#get-robot-uin-range-%E8%8E%B7%E5%8F%96%E6%9C%BA%E5%99%A8%E4%BA%BA%E8%B4%A6%E5%8F%B7%E8%8C%83%E5%9B%B4
 func (ctx *Ctx) GetRobotUinRange() (start, end int64) {
-	arr := ctx.CallAction("get_robot_uin_range", Params{}).Data.Array()
-	if len(arr) != 2 {
-		return
-	}
-	start = arr[0].Int()
-	end = arr[1].Int()
+	start, end, _ = ctx.API().GetRobotUinRange()
 	return
 }
 
@@ -821,170 +727,137 @@ func (ctx *Ctx) GetRobotUinRange() (start, end int64) {
 //
 // https://napneko.github.io/develop/api/doc#set-online-status-%E8%AE%BE%E7%BD%AE%E5%9C%A8%E7%BA%BF%E7%8A%B6%E6%80%81
 func (ctx *Ctx) SetOnlineStatus(status, extStatus, batteryStatus int) {
-	ctx.CallAction("set_online_status", Params{
-		"status":         status,
-		"ext_status":     extStatus,
-		"battery_status": batteryStatus,
-	})
+	_ = ctx.API().SetOnlineStatus(status, extStatus, batteryStatus)
 }
 
 // GetFriendsWithCategory 获取分类的好友列表
 //
 // https://napneko.github.io/develop/api/doc#get-friends-with-category-%E8%8E%B7%E5%8F%96%E5%88%86%E7%B1%BB%E7%9A%84%E5%A5%BD%E5%8F%8B%E5%88%97%E8%A1%A8
 func (ctx *Ctx) GetFriendsWithCategory() gjson.Result {
-	return ctx.CallAction("get_friends_with_category", Params{}).Data
+	rsp, _ := ctx.API().GetFriendsWithCategory()
+	return rsp
 }
 
 // TranslateEn2Zh 英译中
 //
 // https://napneko.github.io/develop/api/doc#translate-en2zh-%E8%8B%B1%E8%AF%91%E4%B8%AD
 func (ctx *Ctx) TranslateEn2Zh(words []string) []string {
-	arr := ctx.CallAction("translate_en2zh", Params{
-		"words": words,
-	}).Data.Array()
-	result := make([]string, len(arr))
-	for i, v := range arr {
-		result[i] = v.String()
-	}
-	return result
+	rsp, _ := ctx.API().TranslateEn2Zh(words)
+	return rsp
 }
 
 // SendForwardMessage 发送合并转发
 //
 // https://napneko.github.io/develop/api/doc#send-forward-msg-%E5%8F%91%E9%80%81%E5%90%88%E5%B9%B6%E8%BD%AC%E5%8F%91
 func (ctx *Ctx) SendForwardMessage(messageType string, userID, groupID int64, messages message.Message) (messageID int64, resID string) {
-	data := ctx.CallAction("send_forward_msg", Params{
-		"message_type": messageType,
-		"user_id":      userID,
-		"group_id":     groupID,
-		"messages":     messages,
-	}).Data
-	return data.Get("message_id").Int(), data.Get("res_id").String()
+	messageID, resID, _ = ctx.API().SendForwardMessage(messageType, userID, groupID, messages)
+	return
 }
 
 // MarkPrivateMessageAsRead 设置私聊已读
 //
 // https://napneko.github.io/develop/api/doc#mark-private-msg-as-read-%E8%AE%BE%E7%BD%AE%E7%A7%81%E8%81%8A%E5%B7%B2%E8%AF%BB
 func (ctx *Ctx) MarkPrivateMessageAsRead(userID int64) {
-	ctx.CallAction("mark_private_msg_as_read", Params{
-		"user_id": userID,
-	})
+	_ = ctx.API().MarkPrivateMessageAsRead(userID)
 }
 
 // MarkGroupMessageAsRead 设置群聊已读
 //
 // https://napneko.github.io/develop/api/doc#mark-group-msg-as-read-%E8%AE%BE%E7%BD%AE%E7%BE%A4%E8%81%8A%E5%B7%B2%E8%AF%BB
 func (ctx *Ctx) MarkGroupMessageAsRead(groupID int64) {
-	ctx.CallAction("mark_group_msg_as_read", Params{
-		"group_id": groupID,
-	})
+	_ = ctx.API().MarkGroupMessageAsRead(groupID)
 }
 
 // GetFriendMessageHistory 获取私聊历史记录
 //
 // https://napneko.github.io/develop/api/doc#get-friend-msg-history-%E8%8E%B7%E5%8F%96%E7%A7%81%E8%81%8A%E5%8E%86%E5%8F%B2%E8%AE%B0%E5%BD%95
 func (ctx *Ctx) GetFriendMessageHistory(userID, messageSeq string, count int, reverseOrder bool) gjson.Result {
-	return ctx.CallAction("get_friend_msg_history", Params{
-		"user_id":      userID,
-		"message_seq":  messageSeq,
-		"count":        count,
-		"reverseOrder": reverseOrder,
-	}).Data
+	rsp, _ := ctx.API().GetFriendMessageHistory(userID, messageSeq, count, reverseOrder)
+	return rsp
 }
 
 // CreateCollection 创建收藏
 //
 // https://napneko.github.io/develop/api/doc#create-collection-%E5%88%9B%E5%BB%BA%E6%94%B6%E8%97%8F
 func (ctx *Ctx) CreateCollection() gjson.Result {
-	return ctx.CallAction("create_collection", Params{}).Data
+	rsp, _ := ctx.API().CreateCollection()
+	return rsp
 }
 
 // GetCollectionList 获取收藏
 //
 // https://napneko.github.io/develop/api/doc#get-collection-list-%E8%8E%B7%E5%8F%96%E6%94%B6%E8%97%8F
 func (ctx *Ctx) GetCollectionList() gjson.Result {
-	return ctx.CallAction("get_collection_list", Params{}).Data
+	rsp, _ := ctx.API().GetCollectionList()
+	return rsp
 }
 
 // SetSelfLongNick 设置签名
 //
 // https://napneko.github.io/develop/api/doc#set-self-longnick-%E8%AE%BE%E7%BD%AE%E7%AD%BE%E5%90%8D
 func (ctx *Ctx) SetSelfLongNick(longNick string) gjson.Result {
-	return ctx.CallAction("set_self_longnick", Params{
-		"longNick": longNick,
-	}).Data
+	rsp, _ := ctx.API().SetSelfLongNick(longNick)
+	return rsp
 }
 
 // GetRecentContact 获取私聊历史记录
 //
 // https://napneko.github.io/develop/api/doc#get-recent-contact-%E8%8E%B7%E5%8F%96%E7%A7%81%E8%81%8A%E5%8E%86%E5%8F%B2%E8%AE%B0%E5%BD%95
 func (ctx *Ctx) GetRecentContact(count int) gjson.Result {
-	return ctx.CallAction("get_recent_contact", Params{
-		"count": count,
-	}).Data
+	rsp, _ := ctx.API().GetRecentContact(count)
+	return rsp
 }
 
 // MarkAllAsRead 标记所有已读
 //
 // https://napneko.github.io/develop/api/doc#_mark-all-as-read-%E6%A0%87%E8%AE%B0%E6%89%80%E6%9C%89%E5%B7%B2%E8%AF%BB
 func (ctx *Ctx) MarkAllAsRead() {
-	ctx.CallAction("_mark_all_as_read", Params{})
+	_ = ctx.API().MarkAllAsRead()
 }
 
 // GetProfileLike 获取自身点赞列表
 //
 // https://napneko.github.io/develop/api/doc#get-profile-like-%E8%8E%B7%E5%8F%96%E8%87%AA%E8%BA%AB%E7%82%B9%E8%B5%9E%E5%88%97%E8%A1%A8
 func (ctx *Ctx) GetProfileLike() gjson.Result {
-	return ctx.CallAction("get_profile_like", Params{}).Data
+	rsp, _ := ctx.API().GetProfileLike()
+	return rsp
 }
 
 // FetchCustomFace 获取自定义表情
 //
 // https://napneko.github.io/develop/api/doc#fetch-custom-face-%E8%8E%B7%E5%8F%96%E8%87%AA%E5%AE%9A%E4%B9%89%E8%A1%A8%E6%83%85
 func (ctx *Ctx) FetchCustomFace(count int) gjson.Result {
-	return ctx.CallAction("fetch_custom_face", Params{
-		"count": count,
-	}).Data
+	rsp, _ := ctx.API().FetchCustomFace(count)
+	return rsp
 }
 
 // GetAIRecord AI文字转语音
 //
 // https://napneko.github.io/develop/api/doc#get-ai-record-ai%E6%96%87%E5%AD%97%E8%BD%AC%E8%AF%AD%E9%9F%B3
 func (ctx *Ctx) GetAIRecord(character string, groupID int64, text string) string {
-	return ctx.CallAction("get_ai_record", Params{
-		"character": character,
-		"group_id":  groupID,
-		"text":      text,
-	}).Data.String()
+	rsp, _ := ctx.API().GetAIRecord(character, groupID, text)
+	return rsp
 }
 
 // GetAICharacters 获取AI语音角色列表
 //
 // https://napneko.github.io/develop/api/doc#get-ai-characters-%E8%8E%B7%E5%8F%96ai%E8%AF%AD%E9%9F%B3%E8%A7%92%E8%89%B2%E5%88%97%E8%A1%A8
 func (ctx *Ctx) GetAICharacters(groupID int64, chatType int) gjson.Result {
-	return ctx.CallAction("get_ai_characters", Params{
-		"group_id":  groupID,
-		"chat_type": chatType,
-	}).Data
+	rsp, _ := ctx.API().GetAICharacters(groupID, chatType)
+	return rsp
 }
 
 // SendGroupAIRecord 群聊发送AI语音
 //
 // https://napneko.github.io/develop/api/doc#send-group-ai-record-%E7%BE%A4%E8%81%8A%E5%8F%91%E9%80%81ai%E8%AF%AD%E9%9F%B3
 func (ctx *Ctx) SendGroupAIRecord(character string, groupID int64, text string) string {
-	return ctx.CallAction("send_group_ai_record", Params{
-		"character": character,
-		"group_id":  groupID,
-		"text":      text,
-	}).Data.Get("message_id").String()
+	rsp, _ := ctx.API().SendGroupAIRecord(character, groupID, text)
+	return rsp
 }
 
 // SendPoke 群聊/私聊戳一戳
 //
 // https://napneko.github.io/develop/api/doc#send-poke-%E7%BE%A4%E8%81%8A-%E7%A7%81%E8%81%8A%E6%88%B3%E4%B8%80%E6%88%B3
 func (ctx *Ctx) SendPoke(groupID, userID int64) {
-	ctx.CallAction("send_poke", Params{
-		"group_id": groupID,
-		"user_id":  userID,
-	})
+	_ = ctx.API().SendPoke(groupID, userID)
 }
@@ -0,0 +1,111 @@
+package zero
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// 常见 OneBot retcode 对应的错误, 可用 errors.Is 判断 *APIError
+//
+// https://github.com/botuniverse/onebot-11/blob/master/api/README.md#%E5%93%8D%E5%BA%94
+var (
+	// ErrBadParams 参数缺失或参数无效 (100, 1400)
+	ErrBadParams = errors.New("bad params")
+	// ErrPermissionDenied 权限不足或鉴权失败 (102, 1401, 1403)
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrOperationFailed 操作失败 (103)
+	ErrOperationFailed = errors.New("operation failed")
+	// ErrCredentialExpired 凭证失效 (104)
+	ErrCredentialExpired = errors.New("credential expired")
+	// ErrActionNotFound API 不存在 (1404)
+	ErrActionNotFound = errors.New("action not found")
+	// ErrRateLimited 调用过于频繁 (1429)
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// retcodeErrors retcode 到 sentinel 错误的映射
+var retcodeErrors = map[int64]error{
+	100:  ErrBadParams,
+	1400: ErrBadParams,
+	102:  ErrPermissionDenied,
+	1401: ErrPermissionDenied,
+	1403: ErrPermissionDenied,
+	103:  ErrOperationFailed,
+	104:  ErrCredentialExpired,
+	1404: ErrActionNotFound,
+	1429: ErrRateLimited,
+}
+
+// APIError 调用 API 时 OneBot 实现返回的错误 (retcode 非 0)
+type APIError struct {
+	Action  string
+	RetCode int64
+	Status  string
+	Message string
+	Wording string
+	// Err 同时返回的传输错误, 如 HTTP 状态码非 200 时 retcode 为 1000+状态码
+	Err error
+}
+
+// Error impls error
+func (e *APIError) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString("api: calling action ")
+	sb.WriteString(e.Action)
+	sb.WriteString(" failed, retcode ")
+	sb.WriteString(strconv.FormatInt(e.RetCode, 10))
+	if e.Status != "" {
+		sb.WriteString(", status ")
+		sb.WriteString(e.Status)
+	}
+	if e.Message != "" {
+		sb.WriteString(", message: ")
+		sb.WriteString(e.Message)
+	}
+	if e.Wording != "" && e.Wording != e.Message {
+		sb.WriteString(", wording: ")
+		sb.WriteString(e.Wording)
+	}
+	if e.Err != nil {
+		sb.WriteString(": ")
+		sb.WriteString(e.Err.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap 返回传输错误
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使 errors.Is(err, ErrXXX) 可判断 retcode 类别
+func (e *APIError) Is(target error) bool {
+	sentinel, ok := retcodeErrors[e.RetCode]
+	return ok && sentinel == target
+}
+
+// isSuccess retcode 0 为成功, 1 为已提交异步处理
+func (rsp *APIResponse) isSuccess() bool {
+	return rsp.RetCode == 0 || (rsp.RetCode == 1 && rsp.Status == "async")
+}
+
+// newAPIError 根据 rsp 与传输错误 err 生成错误, 成功时返回 nil
+//
+// 没有 retcode 的传输错误原样返回
+func newAPIError(action string, rsp *APIResponse, err error) error {
+	if err != nil && rsp.RetCode == 0 {
+		return err
+	}
+	if rsp.isSuccess() {
+		return nil
+	}
+	return &APIError{
+		Action:  action,
+		RetCode: rsp.RetCode,
+		Status:  rsp.Status,
+		Message: rsp.Message,
+		Wording: rsp.Wording,
+		Err:     err,
+	}
+}
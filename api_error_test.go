@@ -0,0 +1,111 @@
+package zero_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{
+		zero.ErrBadParams, zero.ErrPermissionDenied, zero.ErrOperationFailed,
+		zero.ErrCredentialExpired, zero.ErrActionNotFound, zero.ErrRateLimited,
+	}
+	for _, c := range []struct {
+		retcode int64
+		want    error
+	}{
+		{100, zero.ErrBadParams},
+		{1400, zero.ErrBadParams},
+		{102, zero.ErrPermissionDenied},
+		{1401, zero.ErrPermissionDenied},
+		{1403, zero.ErrPermissionDenied},
+		{103, zero.ErrOperationFailed},
+		{104, zero.ErrCredentialExpired},
+		{1404, zero.ErrActionNotFound},
+		{1429, zero.ErrRateLimited},
+		{201, nil},
+	} {
+		err := error(&zero.APIError{Action: "x", RetCode: c.retcode})
+		for _, s := range sentinels {
+			if got := errors.Is(err, s); got != (s == c.want) {
+				t.Errorf("errors.Is(retcode %d, %v) = %v", c.retcode, s, got)
+			}
+		}
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	for _, c := range []struct {
+		err  zero.APIError
+		want string
+	}{
+		{zero.APIError{Action: "a", RetCode: 1}, "api: calling action a failed, retcode 1"},
+		{
+			zero.APIError{Action: "a", RetCode: 102, Status: "failed", Message: "m", Wording: "m"},
+			"api: calling action a failed, retcode 102, status failed, message: m",
+		},
+		{
+			zero.APIError{Action: "a", RetCode: 102, Message: "m", Wording: "w"},
+			"api: calling action a failed, retcode 102, message: m, wording: w",
+		},
+		{
+			zero.APIError{Action: "a", RetCode: 1429, Err: io.ErrUnexpectedEOF},
+			"api: calling action a failed, retcode 1429: unexpected EOF",
+		},
+	} {
+		if got := c.err.Error(); got != c.want {
+			t.Errorf("Error() = %q, want %q", got, c.want)
+		}
+	}
+}
+
+// errCaller 总是返回 err 的 APICaller
+type errCaller struct{ err error }
+
+func (c errCaller) CallAPI(zero.APIRequest) (zero.APIResponse, error) {
+	return zero.APIResponse{}, c.err
+}
+
+func TestAPIFacadeErrors(t *testing.T) {
+	e := zerotest.Engine(t)
+	var (
+		banErr, sendErr, asyncErr error
+		id                        int64
+	)
+	e.OnCommand("call").Handle(func(ctx *zero.Ctx) {
+		banErr = ctx.API().SetGroupBan(ctx.Event.GroupID, 3, 60)
+		id, sendErr = ctx.API().SendGroupMessage(ctx.Event.GroupID, "hi")
+		_, asyncErr = ctx.API().Call("send_group_msg_async", nil)
+	})
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+	bot.Fail("set_group_ban", 102, "no permission")
+	bot.Handle("send_group_msg_async", func(zero.APIRequest) zero.APIResponse {
+		return zero.APIResponse{Status: "async", RetCode: 1}
+	})
+
+	bot.GroupMessage(1, 2, "/call")
+	var apiErr *zero.APIError
+	if !errors.As(banErr, &apiErr) || apiErr.Action != "set_group_ban" || apiErr.Message != "no permission" {
+		t.Fatalf("set_group_ban err %v, want *APIError", banErr)
+	}
+	if !errors.Is(banErr, zero.ErrPermissionDenied) {
+		t.Fatalf("set_group_ban err %v is not ErrPermissionDenied", banErr)
+	}
+	if sendErr != nil || id == 0 {
+		t.Fatalf("send_group_msg returned %d, %v", id, sendErr)
+	}
+	if asyncErr != nil {
+		t.Fatalf("async call err %v", asyncErr)
+	}
+
+	// 传输错误原样返回
+	zero.APICallers.Store(456, errCaller{err: io.ErrClosedPipe})
+	defer zero.APICallers.Delete(456)
+	if _, err := zero.GetBot(456).API().GetLoginInfo(); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("transport err %v, want %v", err, io.ErrClosedPipe)
+	}
+}
@@ -0,0 +1,884 @@
+package zero
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/cubevlmu/CZeroBot/log"
+	"github.com/cubevlmu/CZeroBot/message"
+	"github.com/cubevlmu/CZeroBot/utils/helper"
+)
+
+// API 返回 error 的 API 调用集合, 通过 Ctx.API 获取
+//
+// 各方法与 Ctx 上的同名方法一致, 但会返回传输错误或 *APIError,
+// 可用 errors.Is(err, ErrPermissionDenied) 等判断 retcode 类别
+type API struct {
+	ctx *Ctx
+	c   context.Context
+}
+
+// API 获取返回 error 的 API 调用集合
+func (ctx *Ctx) API() API {
+	return API{ctx: ctx, c: ctx.Context()}
+}
+
+// WithContext 使用 c 进行之后的调用
+func (api API) WithContext(c context.Context) API {
+	api.c = c
+	return api
+}
+
+// Call 调用 cqhttp API
+//
+// retcode 非 0 时返回 *APIError, 同时有传输错误时可由 errors.Unwrap 得到,
+// 没有 retcode 的调用失败返回传输错误
+func (api API) Call(action string, params Params) (APIResponse, error) {
+	req := APIRequest{
+		Action: action,
+		Params: params,
+	}
+	start := time.Now()
+	rsp, err := CallAPIContext(api.c, api.ctx.caller, req)
+	err = newAPIError(action, &rsp, err)
+	observeAPI(action, time.Since(start), err)
+	if _, ok := err.(*APIError); ok {
+		eventLog(api.ctx, nil).With(log.Action(action)).Errorf("[api] calling action failed, action type : %s return value : %v message : %s information : %s", action, rsp.RetCode, rsp.Message, rsp.Wording)
+	} else if err != nil {
+		eventLog(api.ctx, nil).With(log.Action(action)).Errorf("[api] calling action failed, action type : %s error : %v", action, err)
+	}
+	return rsp, err
+}
+
+// data 调用并返回 Data
+func (api API) data(action string, params Params) (gjson.Result, error) {
+	rsp, err := api.Call(action, params)
+	return rsp.Data, err
+}
+
+// do 调用并忽略返回值
+func (api API) do(action string, params Params) error {
+	_, err := api.Call(action, params)
+	return err
+}
+
+// SendGroupMessage 发送群消息
+func (api API) SendGroupMessage(groupID int64, message interface{}) (int64, error) {
+	rsp, err := api.data("send_group_msg", Params{ // 调用并保存返回值
+		"group_id": groupID,
+		"message":  message,
+	})
+	if err != nil {
+		return 0, err
+	}
+	id := rsp.Get("message_id")
+	if id.Exists() {
+		log.Infof("[api] sending group message (%v): %v (id=%v)", groupID, formatMessage(message), id.Int())
+	}
+	return id.Int(), nil
+}
+
+// SendPrivateMessage 发送私聊消息
+func (api API) SendPrivateMessage(userID int64, message interface{}) (int64, error) {
+	rsp, err := api.data("send_private_msg", Params{
+		"user_id": userID,
+		"message": message,
+	})
+	if err != nil {
+		return 0, err
+	}
+	id := rsp.Get("message_id")
+	if id.Exists() {
+		log.Infof("[api] sending DM message (%v): %v (id=%v)", userID, formatMessage(message), id.Int())
+	}
+	return id.Int(), nil
+}
+
+// DeleteMessage 撤回消息
+//
+//nolint:interfacer
+func (api API) DeleteMessage(messageID interface{}) error {
+	return api.do("delete_msg", Params{
+		"message_id": messageID,
+	})
+}
+
+// GetMessage 获取消息
+//
+//nolint:interfacer
+func (api API) GetMessage(messageID interface{}, nologreply ...bool) (Message, error) {
+	params := Params{
+		"message_id": messageID,
+	}
+	if len(nologreply) > 0 && nologreply[0] {
+		params["__zerobot_no_log_mseeage_id__"] = true
+	}
+	rsp, err := api.data("get_msg", params)
+	if err != nil {
+		return Message{}, err
+	}
+	m := Message{
+		Elements:    message.ParseMessage(helper.StringToBytes(rsp.Get("message").Raw)),
+		MessageID:   message.NewMessageIDFromString(rsp.Get("message_id").String()), // OneBot 12 可能为字符串
+		MessageType: rsp.Get("message_type").String(),
+		Sender:      &User{},
+	}
+	err = json.Unmarshal(helper.StringToBytes(rsp.Get("sender").Raw), m.Sender)
+	if err != nil {
+		return Message{}, err
+	}
+	return m, nil
+}
+
+// GetForwardMessage 获取合并转发消息
+func (api API) GetForwardMessage(id string) (gjson.Result, error) {
+	return api.data("get_forward_msg", Params{
+		"id": id,
+	})
+}
+
+// SendLike 发送好友赞
+func (api API) SendLike(userID int64, times int) error {
+	return api.do("send_like", Params{
+		"user_id": userID,
+		"times":   times,
+	})
+}
+
+// SetGroupKick 群组踢人
+func (api API) SetGroupKick(groupID, userID int64, rejectAddRequest bool) error {
+	return api.do("set_group_kick", Params{
+		"group_id":           groupID,
+		"user_id":            userID,
+		"reject_add_request": rejectAddRequest,
+	})
+}
+
+// SetThisGroupKick 本群组踢人
+func (api API) SetThisGroupKick(userID int64, rejectAddRequest bool) error {
+	return api.SetGroupKick(api.ctx.Event.GroupID, userID, rejectAddRequest)
+}
+
+// SetGroupBan 群组单人禁言
+func (api API) SetGroupBan(groupID, userID, duration int64) error {
+	return api.do("set_group_ban", Params{
+		"group_id": groupID,
+		"user_id":  userID,
+		"duration": duration,
+	})
+}
+
+// SetThisGroupBan 本群组单人禁言
+func (api API) SetThisGroupBan(userID, duration int64) error {
+	return api.SetGroupBan(api.ctx.Event.GroupID, userID, duration)
+}
+
+// SetGroupWholeBan 群组全员禁言
+func (api API) SetGroupWholeBan(groupID int64, enable bool) error {
+	return api.do("set_group_whole_ban", Params{
+		"group_id": groupID,
+		"enable":   enable,
+	})
+}
+
+// SetThisGroupWholeBan 本群组全员禁言
+func (api API) SetThisGroupWholeBan(enable bool) error {
+	return api.SetGroupWholeBan(api.ctx.Event.GroupID, enable)
+}
+
+// SetGroupAdmin 群组设置管理员
+func (api API) SetGroupAdmin(groupID, userID int64, enable bool) error {
+	return api.do("set_group_admin", Params{
+		"group_id": groupID,
+		"user_id":  userID,
+		"enable":   enable,
+	})
+}
+
+// SetThisGroupAdmin 本群组设置管理员
+func (api API) SetThisGroupAdmin(userID int64, enable bool) error {
+	return api.SetGroupAdmin(api.ctx.Event.GroupID, userID, enable)
+}
+
+// SetGroupAnonymous 群组匿名
+func (api API) SetGroupAnonymous(groupID int64, enable bool) error {
+	return api.do("set_group_anonymous", Params{
+		"group_id": groupID,
+		"enable":   enable,
+	})
+}
+
+// SetThisGroupAnonymous 本群组匿名
+func (api API) SetThisGroupAnonymous(enable bool) error {
+	return api.SetGroupAnonymous(api.ctx.Event.GroupID, enable)
+}
+
+// SetGroupCard 设置群名片（群备注）
+func (api API) SetGroupCard(groupID, userID int64, card string) error {
+	return api.do("set_group_card", Params{
+		"group_id": groupID,
+		"user_id":  userID,
+		"card":     card,
+	})
+}
+
+// SetThisGroupCard 设置本群名片（群备注）
+func (api API) SetThisGroupCard(userID int64, card string) error {
+	return api.SetGroupCard(api.ctx.Event.GroupID, userID, card)
+}
+
+// SetGroupName 设置群名
+func (api API) SetGroupName(groupID int64, groupName string) error {
+	return api.do("set_group_name", Params{
+		"group_id":   groupID,
+		"group_name": groupName,
+	})
+}
+
+// SetThisGroupName 设置本群名
+func (api API) SetThisGroupName(groupName string) error {
+	return api.SetGroupName(api.ctx.Event.GroupID, groupName)
+}
+
+// SetGroupLeave 退出群组
+func (api API) SetGroupLeave(groupID int64, isDismiss bool) error {
+	return api.do("set_group_leave", Params{
+		"group_id":   groupID,
+		"is_dismiss": isDismiss,
+	})
+}
+
+// SetThisGroupLeave 退出本群组
+func (api API) SetThisGroupLeave(isDismiss bool) error {
+	return api.SetGroupLeave(api.ctx.Event.GroupID, isDismiss)
+}
+
+// SetGroupSpecialTitle 设置群组专属头衔
+func (api API) SetGroupSpecialTitle(groupID, userID int64, specialTitle string) error {
+	return api.do("set_group_special_title", Params{
+		"group_id":      groupID,
+		"user_id":       userID,
+		"special_title": specialTitle,
+	})
+}
+
+// SetThisGroupSpecialTitle 设置本群组专属头衔
+func (api API) SetThisGroupSpecialTitle(userID int64, specialTitle string) error {
+	return api.SetGroupSpecialTitle(api.ctx.Event.GroupID, userID, specialTitle)
+}
+
+// SetFriendAddRequest 处理加好友请求
+func (api API) SetFriendAddRequest(flag string, approve bool, remark string) error {
+	return api.do("set_friend_add_request", Params{
+		"flag":    flag,
+		"approve": approve,
+		"remark":  remark,
+	})
+}
+
+// SetGroupAddRequest 处理加群请求／邀请
+func (api API) SetGroupAddRequest(flag string, subType string, approve bool, reason string) error {
+	return api.do("set_group_add_request", Params{
+		"flag":     flag,
+		"sub_type": subType,
+		"approve":  approve,
+		"reason":   reason,
+	})
+}
+
+// GetLoginInfo 获取登录号信息
+func (api API) GetLoginInfo() (LoginInfo, error) {
+	return decodeData[LoginInfo](api.data("get_login_info", Params{}))
+}
+
+// GetStrangerInfo 获取陌生人信息
+func (api API) GetStrangerInfo(userID int64, noCache bool) (Stranger, error) {
+	return decodeData[Stranger](api.data("get_stranger_info", Params{
+		"user_id":  userID,
+		"no_cache": noCache,
+	}))
+}
+
+// GetFriendList 获取好友列表
+func (api API) GetFriendList() ([]Friend, error) {
+	return decodeList[Friend](api.friendList())
+}
+
+func (api API) friendList() (gjson.Result, error) {
+	return api.data("get_friend_list", Params{})
+}
+
+// GetGroupInfo 获取群信息
+func (api API) GetGroupInfo(groupID int64, noCache bool) (Group, error) {
+	return decodeData[Group](api.data("get_group_info", Params{
+		"group_id": groupID,
+		"no_cache": noCache,
+	}))
+}
+
+// GetThisGroupInfo 获取本群信息
+func (api API) GetThisGroupInfo(noCache bool) (Group, error) {
+	return api.GetGroupInfo(api.ctx.Event.GroupID, noCache)
+}
+
+// GetGroupList 获取群列表
+func (api API) GetGroupList() ([]Group, error) {
+	return decodeList[Group](api.groupList())
+}
+
+func (api API) groupList() (gjson.Result, error) {
+	return api.data("get_group_list", Params{})
+}
+
+// GetGroupMemberInfo 获取群成员信息
+func (api API) GetGroupMemberInfo(groupID int64, userID int64, noCache bool) (GroupMember, error) {
+	return decodeData[GroupMember](api.data("get_group_member_info", Params{
+		"group_id": groupID,
+		"user_id":  userID,
+		"no_cache": noCache,
+	}))
+}
+
+// GetThisGroupMemberInfo 获取本群成员信息
+func (api API) GetThisGroupMemberInfo(userID int64, noCache bool) (GroupMember, error) {
+	return api.GetGroupMemberInfo(api.ctx.Event.GroupID, userID, noCache)
+}
+
+// GetGroupMemberList 获取群成员列表
+func (api API) GetGroupMemberList(groupID int64) ([]GroupMember, error) {
+	return decodeList[GroupMember](api.groupMemberList(groupID, false))
+}
+
+// GetThisGroupMemberList 获取本群成员列表
+func (api API) GetThisGroupMemberList() ([]GroupMember, error) {
+	return api.GetGroupMemberList(api.ctx.Event.GroupID)
+}
+
+// GetGroupMemberListNoCache 无缓存获取群员列表
+func (api API) GetGroupMemberListNoCache(groupID int64) ([]GroupMember, error) {
+	return decodeList[GroupMember](api.groupMemberList(groupID, true))
+}
+
+// GetThisGroupMemberListNoCache 无缓存获取本群员列表
+func (api API) GetThisGroupMemberListNoCache() ([]GroupMember, error) {
+	return api.GetGroupMemberListNoCache(api.ctx.Event.GroupID)
+}
+
+func (api API) groupMemberList(groupID int64, noCache bool) (gjson.Result, error) {
+	params := Params{
+		"group_id": groupID,
+	}
+	if noCache {
+		params["no_cache"] = true
+	}
+	return api.data("get_group_member_list", params)
+}
+
+// GetGroupHonorInfo 获取群荣誉信息
+func (api API) GetGroupHonorInfo(groupID int64, hType string) (GroupHonorInfo, error) {
+	return decodeData[GroupHonorInfo](api.data("get_group_honor_info", Params{
+		"group_id": groupID,
+		"type":     hType,
+	}))
+}
+
+// GetThisGroupHonorInfo 获取本群荣誉信息
+func (api API) GetThisGroupHonorInfo(hType string) (GroupHonorInfo, error) {
+	return api.GetGroupHonorInfo(api.ctx.Event.GroupID, hType)
+}
+
+// GetRecord 获取语音
+func (api API) GetRecord(file string, outFormat string) (RecordInfo, error) {
+	return decodeData[RecordInfo](api.data("get_record", Params{
+		"file":       file,
+		"out_format": outFormat,
+	}))
+}
+
+// GetImage 获取图片
+func (api API) GetImage(file string) (ImageInfo, error) {
+	return decodeData[ImageInfo](api.data("get_image", Params{
+		"file": file,
+	}))
+}
+
+// GetVersionInfo 获取版本信息
+func (api API) GetVersionInfo() (VersionInfo, error) {
+	return decodeData[VersionInfo](api.data("get_version_info", Params{}))
+}
+
+// SetGroupPortrait 设置群头像
+func (api API) SetGroupPortrait(groupID int64, file string) error {
+	return api.do("set_group_portrait", Params{
+		"group_id": groupID,
+		"file":     file,
+	})
+}
+
+// SetThisGroupPortrait 设置本群头像
+func (api API) SetThisGroupPortrait(file string) error {
+	return api.SetGroupPortrait(api.ctx.Event.GroupID, file)
+}
+
+// OCRImage 图片OCR
+func (api API) OCRImage(file string) (gjson.Result, error) {
+	return api.data("ocr_image", Params{
+		"image": file,
+	})
+}
+
+// SendGroupForwardMessage 发送合并转发(群)
+func (api API) SendGroupForwardMessage(groupID int64, message message.Message) (gjson.Result, error) {
+	return api.data("send_group_forward_msg", Params{
+		"group_id": groupID,
+		"messages": message,
+	})
+}
+
+// SendPrivateForwardMessage 发送合并转发(私聊)
+func (api API) SendPrivateForwardMessage(userID int64, message message.Message) (gjson.Result, error) {
+	return api.data("send_private_forward_msg", Params{
+		"user_id":  userID,
+		"messages": message,
+	})
+}
+
+// ForwardFriendSingleMessage 转发单条消息到好友
+func (api API) ForwardFriendSingleMessage(userID int64, messageID interface{}) (APIResponse, error) {
+	return api.Call("forward_friend_single_msg", Params{
+		"user_id":    userID,
+		"message_id": messageID,
+	})
+}
+
+// ForwardGroupSingleMessage 转发单条消息到群
+func (api API) ForwardGroupSingleMessage(groupID int64, messageID interface{}) (APIResponse, error) {
+	return api.Call("forward_group_single_msg", Params{
+		"group_id":   groupID,
+		"message_id": messageID,
+	})
+}
+
+// GetGroupSystemMessage 获取群系统消息
+func (api API) GetGroupSystemMessage() (gjson.Result, error) {
+	return api.data("get_group_system_msg", Params{})
+}
+
+// MarkMessageAsRead 标记消息已读
+func (api API) MarkMessageAsRead(messageID int64) (APIResponse, error) {
+	return api.Call("mark_msg_as_read", Params{
+		"message_id": messageID,
+	})
+}
+
+// MarkThisMessageAsRead 标记本消息已读
+func (api API) MarkThisMessageAsRead() (APIResponse, error) {
+	return api.Call("mark_msg_as_read", Params{
+		"message_id": api.ctx.Event.MessageID,
+	})
+}
+
+// GetOnlineClients 获取当前账号在线客户端列表
+func (api API) GetOnlineClients(noCache bool) (gjson.Result, error) {
+	return api.data("get_online_clients", Params{
+		"no_cache": noCache,
+	})
+}
+
+// GetGroupAtAllRemain 获取群@全体成员剩余次数
+func (api API) GetGroupAtAllRemain(groupID int64) (AtAllRemain, error) {
+	return decodeData[AtAllRemain](api.data("get_group_at_all_remain", Params{
+		"group_id": groupID,
+	}))
+}
+
+// GetThisGroupAtAllRemain 获取本群@全体成员剩余次数
+func (api API) GetThisGroupAtAllRemain() (AtAllRemain, error) {
+	return api.GetGroupAtAllRemain(api.ctx.Event.GroupID)
+}
+
+// GetGroupMessageHistory 获取群消息历史记录
+//
+//	messageID: 起始消息序号, 可通过 get_msg 获得
+func (api API) GetGroupMessageHistory(groupID, messageID int64) (gjson.Result, error) {
+	return api.data("get_group_msg_history", Params{
+		"group_id":    groupID,
+		"message_seq": messageID, // 兼容旧版本
+		"message_id":  messageID,
+	})
+}
+
+// GetLatestGroupMessageHistory 获取最新群消息历史记录
+func (api API) GetLatestGroupMessageHistory(groupID int64) (gjson.Result, error) {
+	return api.data("get_group_msg_history", Params{
+		"group_id": groupID,
+	})
+}
+
+// GetThisGroupMessageHistory 获取本群消息历史记录
+//
+//	messageID: 起始消息序号, 可通过 get_msg 获得
+func (api API) GetThisGroupMessageHistory(messageID int64) (gjson.Result, error) {
+	return api.GetGroupMessageHistory(api.ctx.Event.GroupID, messageID)
+}
+
+// GetLatestThisGroupMessageHistory 获取最新本群消息历史记录
+func (api API) GetLatestThisGroupMessageHistory() (gjson.Result, error) {
+	return api.GetLatestGroupMessageHistory(api.ctx.Event.GroupID)
+}
+
+// GetGroupEssenceMessageList 获取群精华消息列表
+func (api API) GetGroupEssenceMessageList(groupID int64) ([]EssenceMessage, error) {
+	return decodeList[EssenceMessage](api.essenceMessageList(groupID))
+}
+
+// GetThisGroupEssenceMessageList 获取本群精华消息列表
+func (api API) GetThisGroupEssenceMessageList() ([]EssenceMessage, error) {
+	return api.GetGroupEssenceMessageList(api.ctx.Event.GroupID)
+}
+
+func (api API) essenceMessageList(groupID int64) (gjson.Result, error) {
+	return api.data("get_essence_msg_list", Params{
+		"group_id": groupID,
+	})
+}
+
+// SetGroupEssenceMessage 设置群精华消息
+func (api API) SetGroupEssenceMessage(messageID int64) (APIResponse, error) {
+	return api.Call("set_essence_msg", Params{
+		"message_id": messageID,
+	})
+}
+
+// DeleteGroupEssenceMessage 移出群精华消息
+func (api API) DeleteGroupEssenceMessage(messageID int64) (APIResponse, error) {
+	return api.Call("delete_essence_msg", Params{
+		"message_id": messageID,
+	})
+}
+
+// GetWordSlices 获取中文分词
+func (api API) GetWordSlices(content string) (gjson.Result, error) {
+	return api.data(".get_word_slices", Params{
+		"content": content,
+	})
+}
+
+// SendGuildChannelMessage 发送频道消息
+func (api API) SendGuildChannelMessage(guildID, channelID string, message interface{}) (string, error) {
+	rsp, err := api.data("send_guild_channel_msg", Params{
+		"guild_id":   guildID,
+		"channel_id": channelID,
+		"message":    message,
+	})
+	if err != nil {
+		return "0", err
+	}
+	id := rsp.Get("message_id")
+	if !id.Exists() {
+		return "0", nil // 无法获取返回值
+	}
+	log.Infof("[api] sending QQ Channal's message (%v-%v): %v (id=%v)", guildID, channelID, formatMessage(message), id.Int())
+	return id.String(), nil
+}
+
+// GetGroupFilesystemInfo 获取群文件系统信息
+func (api API) GetGroupFilesystemInfo(groupID int64) (GroupFilesystemInfo, error) {
+	return decodeData[GroupFilesystemInfo](api.data("get_group_file_system_info", Params{
+		"group_id": groupID,
+	}))
+}
+
+// GetThisGroupFilesystemInfo 获取本群文件系统信息
+func (api API) GetThisGroupFilesystemInfo() (GroupFilesystemInfo, error) {
+	return api.GetGroupFilesystemInfo(api.ctx.Event.GroupID)
+}
+
+// GetGroupRootFiles 获取群根目录文件列表
+func (api API) GetGroupRootFiles(groupID int64) (GroupFiles, error) {
+	return decodeData[GroupFiles](api.data("get_group_root_files", Params{
+		"group_id": groupID,
+	}))
+}
+
+// GetThisGroupRootFiles 获取本群根目录文件列表
+func (api API) GetThisGroupRootFiles() (GroupFiles, error) {
+	return api.GetGroupRootFiles(api.ctx.Event.GroupID)
+}
+
+// GetGroupFilesByFolder 获取群子目录文件列表
+func (api API) GetGroupFilesByFolder(groupID int64, folderID string) (GroupFiles, error) {
+	return decodeData[GroupFiles](api.data("get_group_files_by_folder", Params{
+		"group_id":  groupID,
+		"folder_id": folderID,
+	}))
+}
+
+// GetThisGroupFilesByFolder 获取本群子目录文件列表
+func (api API) GetThisGroupFilesByFolder(folderID string) (GroupFiles, error) {
+	return api.GetGroupFilesByFolder(api.ctx.Event.GroupID, folderID)
+}
+
+// GetGroupFileURL 获取群文件资源链接
+func (api API) GetGroupFileURL(groupID, busid int64, fileID string) (string, error) {
+	rsp, err := api.data("get_group_file_url", Params{
+		"group_id": groupID,
+		"file_id":  fileID,
+		"busid":    busid,
+	})
+	return rsp.Get("url").Str, err
+}
+
+// GetThisGroupFileURL 获取本群文件资源链接
+func (api API) GetThisGroupFileURL(busid int64, fileID string) (string, error) {
+	return api.GetGroupFileURL(api.ctx.Event.GroupID, busid, fileID)
+}
+
+// UploadGroupFile 上传群文件
+//
+//	msg: FILE_NOT_FOUND FILE_SYSTEM_UPLOAD_API_ERROR ...
+func (api API) UploadGroupFile(groupID int64, file, name, folder string) (APIResponse, error) {
+	return api.Call("upload_group_file", Params{
+		"group_id": groupID,
+		"file":     file,
+		"name":     name,
+		"folder":   folder,
+	})
+}
+
+// UploadThisGroupFile 上传本群文件
+//
+//	msg: FILE_NOT_FOUND FILE_SYSTEM_UPLOAD_API_ERROR ...
+func (api API) UploadThisGroupFile(file, name, folder string) (APIResponse, error) {
+	return api.UploadGroupFile(api.ctx.Event.GroupID, file, name, folder)
+}
+
+// SetMyAvatar 设置我的头像
+func (api API) SetMyAvatar(file string) (APIResponse, error) {
+	return api.Call("set_qq_avatar", Params{
+		"file": file,
+	})
+}
+
+// GetFile 下载收到的群文件或私聊文件
+func (api API) GetFile(fileID string) (FileInfo, error) {
+	return decodeData[FileInfo](api.data("get_file", Params{
+		"file_id": fileID,
+	}))
+}
+
+// SetMessageEmojiLike 发送表情回应
+//
+// emoji_id 参考 https://bot.q.qq.com/wiki/develop/api-v2/openapi/emoji/model.html#EmojiType
+func (api API) SetMessageEmojiLike(messageID interface{}, emojiID rune) error {
+	rsp, err := api.data("set_msg_emoji_like", Params{
+		"message_id": messageID,
+		"emoji_id":   strconv.Itoa(int(emojiID)),
+	})
+	if err != nil {
+		return err
+	}
+	if msg := rsp.Get("errMsg").Str; msg != "" {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// SetGroupSign 群签到
+func (api API) SetGroupSign(groupID int64) error {
+	return api.do("set_group_sign", Params{
+		"group_id": groupID,
+	})
+}
+
+// GroupPoke 群聊戳一戳
+func (api API) GroupPoke(groupID, userID int64) error {
+	return api.do("group_poke", Params{
+		"group_id": groupID,
+		"user_id":  userID,
+	})
+}
+
+// FriendPoke 私聊戳一戳
+func (api API) FriendPoke(userID int64) error {
+	return api.do("friend_poke", Params{
+		"user_id": userID,
+	})
+}
+
+// ArkSharePeer 获取推荐好友/群聊卡片
+func (api API) ArkSharePeer(userID, groupID string) (string, error) {
+	rsp, err := api.data("ArkSharePeer", Params{
+		"user_id":  userID,
+		"group_id": groupID,
+	})
+	return rsp.Get("arkJson").String(), err
+}
+
+// ArkShareGroup 获取推荐群聊卡片
+func (api API) ArkShareGroup(groupID string) (string, error) {
+	rsp, err := api.data("ArkShareGroup", Params{
+		"group_id": groupID,
+	})
+	return rsp.String(), err
+}
+
+// GetRobotUinRange 获取机器人账号范围
+func (api API) GetRobotUinRange() (start, end int64, err error) {
+	rsp, err := api.data("get_robot_uin_range", Params{})
+	if err != nil {
+		return
+	}
+	arr := rsp.Array()
+	if len(arr) != 2 {
+		return
+	}
+	return arr[0].Int(), arr[1].Int(), nil
+}
+
+// SetOnlineStatus 设置在线状态
+func (api API) SetOnlineStatus(status, extStatus, batteryStatus int) error {
+	return api.do("set_online_status", Params{
+		"status":         status,
+		"ext_status":     extStatus,
+		"battery_status": batteryStatus,
+	})
+}
+
+// GetFriendsWithCategory 获取分类的好友列表
+func (api API) GetFriendsWithCategory() (gjson.Result, error) {
+	return api.data("get_friends_with_category", Params{})
+}
+
+// TranslateEn2Zh 英译中
+func (api API) TranslateEn2Zh(words []string) ([]string, error) {
+	rsp, err := api.data("translate_en2zh", Params{
+		"words": words,
+	})
+	if err != nil {
+		return nil, err
+	}
+	arr := rsp.Array()
+	result := make([]string, len(arr))
+	for i, v := range arr {
+		result[i] = v.String()
+	}
+	return result, nil
+}
+
+// SendForwardMessage 发送合并转发
+func (api API) SendForwardMessage(messageType string, userID, groupID int64, messages message.Message) (messageID int64, resID string, err error) {
+	rsp, err := api.data("send_forward_msg", Params{
+		"message_type": messageType,
+		"user_id":      userID,
+		"group_id":     groupID,
+		"messages":     messages,
+	})
+	return rsp.Get("message_id").Int(), rsp.Get("res_id").String(), err
+}
+
+// MarkPrivateMessageAsRead 设置私聊已读
+func (api API) MarkPrivateMessageAsRead(userID int64) error {
+	return api.do("mark_private_msg_as_read", Params{
+		"user_id": userID,
+	})
+}
+
+// MarkGroupMessageAsRead 设置群聊已读
+func (api API) MarkGroupMessageAsRead(groupID int64) error {
+	return api.do("mark_group_msg_as_read", Params{
+		"group_id": groupID,
+	})
+}
+
+// GetFriendMessageHistory 获取私聊历史记录
+func (api API) GetFriendMessageHistory(userID, messageSeq string, count int, reverseOrder bool) (gjson.Result, error) {
+	return api.data("get_friend_msg_history", Params{
+		"user_id":      userID,
+		"message_seq":  messageSeq,
+		"count":        count,
+		"reverseOrder": reverseOrder,
+	})
+}
+
+// CreateCollection 创建收藏
+func (api API) CreateCollection() (gjson.Result, error) {
+	return api.data("create_collection", Params{})
+}
+
+// GetCollectionList 获取收藏
+func (api API) GetCollectionList() (gjson.Result, error) {
+	return api.data("get_collection_list", Params{})
+}
+
+// SetSelfLongNick 设置签名
+func (api API) SetSelfLongNick(longNick string) (gjson.Result, error) {
+	return api.data("set_self_longnick", Params{
+		"longNick": longNick,
+	})
+}
+
+// GetRecentContact 获取最近联系人
+func (api API) GetRecentContact(count int) (gjson.Result, error) {
+	return api.data("get_recent_contact", Params{
+		"count": count,
+	})
+}
+
+// MarkAllAsRead 标记所有已读
+func (api API) MarkAllAsRead() error {
+	return api.do("_mark_all_as_read", Params{})
+}
+
+// GetProfileLike 获取自身点赞列表
+func (api API) GetProfileLike() (gjson.Result, error) {
+	return api.data("get_profile_like", Params{})
+}
+
+// FetchCustomFace 获取自定义表情
+func (api API) FetchCustomFace(count int) (gjson.Result, error) {
+	return api.data("fetch_custom_face", Params{
+		"count": count,
+	})
+}
+
+// GetAIRecord AI文字转语音
+func (api API) GetAIRecord(character string, groupID int64, text string) (string, error) {
+	rsp, err := api.data("get_ai_record", Params{
+		"character": character,
+		"group_id":  groupID,
+		"text":      text,
+	})
+	return rsp.String(), err
+}
+
+// GetAICharacters 获取AI语音角色列表
+func (api API) GetAICharacters(groupID int64, chatType int) (gjson.Result, error) {
+	return api.data("get_ai_characters", Params{
+		"group_id":  groupID,
+		"chat_type": chatType,
+	})
+}
+
+// SendGroupAIRecord 群聊发送AI语音
+func (api API) SendGroupAIRecord(character string, groupID int64, text string) (string, error) {
+	rsp, err := api.data("send_group_ai_record", Params{
+		"character": character,
+		"group_id":  groupID,
+		"text":      text,
+	})
+	return rsp.Get("message_id").String(), err
+}
+
+// SendPoke 群聊/私聊戳一戳
+func (api API) SendPoke(groupID, userID int64) error {
+	return api.do("send_poke", Params{
+		"group_id": groupID,
+		"user_id":  userID,
+	})
+}
@@ -0,0 +1,86 @@
+package zero_test
+
+import (
+	"fmt"
+	"testing"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/message"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestTypedAPI(t *testing.T) {
+	e := zerotest.Engine(t)
+	var (
+		name, extra, legacyRole string
+		members                 []zero.GroupMember
+		honor                   zero.GroupHonorInfo
+		group, again            zero.Group
+	)
+	e.OnCommand("info").Handle(func(ctx *zero.Ctx) {
+		name = ctx.CardOrNickName(ctx.Event.UserID)
+		stranger, _ := ctx.API().GetStrangerInfo(ctx.Event.UserID, false)
+		extra = stranger.Raw.Get("extra").String()
+		members, _ = ctx.API().GetThisGroupMemberList()
+		honor, _ = ctx.API().GetThisGroupHonorInfo("all")
+		// 原有的 Ctx 方法仍返回 gjson.Result
+		legacyRole = ctx.GetThisGroupMemberList().Get("0.role").String()
+		group = ctx.GetThisGroupInfo(false)
+		again, _ = ctx.API().GetThisGroupInfo(false)
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	bot.Respond("get_group_member_info", map[string]interface{}{"user_id": 2, "card": ""})
+	bot.Respond("get_stranger_info", map[string]interface{}{"user_id": 2, "nickname": "alice", "extra": "x"})
+	bot.Respond("get_group_member_list", []map[string]interface{}{
+		{"user_id": 2, "role": "owner"},
+		{"user_id": 3, "role": "member"},
+	})
+	bot.Respond("get_group_honor_info", map[string]interface{}{
+		"group_id":          1,
+		"current_talkative": map[string]interface{}{"user_id": 2, "day_count": 3, "level": 7},
+		"talkative_list":    []map[string]interface{}{{"user_id": 2, "level": 7}},
+	})
+	bot.Respond("get_group_info", map[string]interface{}{"group_id": 1, "group_name": "g", "member_count": "5"})
+	bot.GroupMessage(1, 2, "/info")
+
+	if name != "alice" {
+		t.Fatalf("CardOrNickName is %q, want %q", name, "alice")
+	}
+	if extra != "x" {
+		t.Fatalf("raw extra field is %q, want %q", extra, "x")
+	}
+	if len(members) != 2 || members[0].Role != "owner" || members[1].UserID != 3 {
+		t.Fatalf("group members %+v", members)
+	}
+	if legacyRole != "owner" {
+		t.Fatalf("legacy member list role %q, want %q", legacyRole, "owner")
+	}
+	if honor.CurrentTalkative == nil || honor.CurrentTalkative.DayCount != 3 || honor.CurrentTalkative.Raw.Get("level").Int() != 7 {
+		t.Fatalf("current talkative %+v", honor.CurrentTalkative)
+	}
+	if len(honor.TalkativeList) != 1 || honor.TalkativeList[0].Raw.Get("level").Int() != 7 {
+		t.Fatalf("talkative list %+v", honor.TalkativeList)
+	}
+	// 模型可用 == 比较
+	if want := (zero.Group{ID: 1, Name: "g", MemberCount: 5}); group != want || again != want {
+		t.Fatalf("group %+v and %+v, want %+v", group, again, want)
+	}
+}
+
+func TestGetMessageID(t *testing.T) {
+	bot := zerotest.Start(t, zero.Config{})
+	api := zero.GetBot(bot.SelfID).API()
+	for _, id := range []interface{}{int64(42), "42", "a1b2"} {
+		bot.Respond("get_msg", map[string]interface{}{"message_id": id, "message_type": "group", "sender": map[string]interface{}{}})
+		m, err := api.GetMessage(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := message.NewMessageIDFromString(fmt.Sprint(id))
+		if m.MessageID != want || m.MessageID.ID() == 0 {
+			t.Errorf("message_id %#v parsed as %v (%d), want %v", id, m.MessageID, m.MessageID.ID(), want)
+		}
+	}
+}
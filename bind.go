@@ -0,0 +1,497 @@
+package zero
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cubevlmu/CZeroBot/utils/shell"
+)
+
+// FieldError 单个字段的绑定错误
+type FieldError struct {
+	Field  string // 结构体字段名
+	Name   string // name tag, 默认为字段名
+	Value  string // 原始参数
+	Reason string // 面向用户的原因
+}
+
+// Error impls error
+func (e *FieldError) Error() string {
+	return e.Name + ": " + e.Reason
+}
+
+// BindError Bind 失败时返回的所有字段错误, 可直接发送给用户
+type BindError []*FieldError
+
+// Error impls error
+func (e BindError) Error() string {
+	strs := make([]string, len(e))
+	for i, fe := range e {
+		strs[i] = fe.Error()
+	}
+	return strings.Join(strs, "\n")
+}
+
+// bindSource 字段值的来源
+type bindSource int
+
+const (
+	bindState bindSource = iota // zero:"key"
+	bindArg                     // arg:"0" / arg:"1..."
+	bindRegex                   // regex:"1"
+	bindAt                      // at:"0" / at:"*"
+	bindImage                   // image:"0" / image:"*"
+)
+
+// bindField 带绑定 tag 的字段
+type bindField struct {
+	index    int
+	field    string
+	name     string
+	source   bindSource
+	key      string // bindState 的 State 键
+	pos      int
+	rest     bool // 从 pos 开始的所有值
+	def      string
+	hasDef   bool
+	required bool
+	rules    []bindRule
+}
+
+// bindRule validate tag 中的一项校验
+type bindRule func(v reflect.Value) string
+
+var (
+	bindCache          = sync.Map{} // reflect.Type -> []bindField
+	textUnmarshalerTyp = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Bind 将命令参数绑定到 model 指向的结构体
+//
+// 字段的来源由 tag 指定, 可将字段声明为切片以接收多个值:
+//
+//	arg:"0"      State["args"] 切分后的第 0 个参数, arg:"1..." 为第 1 个及之后的所有参数
+//	regex:"1"    State["regex_matched"] 的第 1 个分组
+//	at:"0"       消息中第 0 个 at 的 QQ 号, at:"*" 为所有
+//	image:"0"    消息中第 0 张图片的 url, image:"*" 为所有
+//	zero:"key"   State["key"] 的值
+//
+// 参数将转换为字段的类型, 支持 string, bool, 整数, 浮点数, time.Duration
+// 与实现了 encoding.TextUnmarshaler 的类型 (如枚举).
+// 可选的 tag:
+//
+//	name:"次数"                        错误提示中的字段名
+//	default:"1"                        参数不存在时的默认值
+//	validate:"required,min=1,max=10"   校验, 另支持 oneof=a b c
+//
+// 转换或校验失败时返回 BindError, 其中包含所有出错的字段
+func (ctx *Ctx) Bind(model interface{}) error {
+	rv := reflect.ValueOf(model)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: can't bind to %T", model)
+	}
+	rv = rv.Elem()
+	fields, err := bindFields(rv.Type())
+	if err != nil {
+		return err
+	}
+	src := bindValues{ctx: ctx}
+	var errs BindError
+	for i := range fields {
+		f := &fields[i]
+		if fe := f.bind(rv.Field(f.index), &src); fe != nil {
+			errs = append(errs, fe)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// bindFields 解析并缓存 t 的绑定字段
+func bindFields(t reflect.Type) ([]bindField, error) {
+	if v, ok := bindCache.Load(t); ok {
+		return v.([]bindField), nil
+	}
+	fields := make([]bindField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		f := bindField{index: i, field: sf.Name, name: sf.Name}
+		if !f.parseSource(sf.Tag) {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, fmt.Errorf("bind: field %s of %v is unexported", sf.Name, t)
+		}
+		if f.source != bindState && !isBindType(sf.Type) {
+			return nil, fmt.Errorf("bind: unsupported type %v of field %s in %v", sf.Type, sf.Name, t)
+		}
+		if name, ok := sf.Tag.Lookup("name"); ok {
+			f.name = name
+		}
+		f.def, f.hasDef = sf.Tag.Lookup("default")
+		if err := f.parseRules(sf.Tag.Get("validate"), sf.Type); err != nil {
+			return nil, fmt.Errorf("bind: field %s of %v: %w", sf.Name, t, err)
+		}
+		fields = append(fields, f)
+	}
+	bindCache.Store(t, fields)
+	return fields, nil
+}
+
+// parseSource 解析来源 tag, 无绑定 tag 时返回 false
+func (f *bindField) parseSource(tag reflect.StructTag) bool {
+	for _, s := range [...]struct {
+		tag    string
+		source bindSource
+	}{{"arg", bindArg}, {"regex", bindRegex}, {"at", bindAt}, {"image", bindImage}} {
+		v, ok := tag.Lookup(s.tag)
+		if !ok {
+			continue
+		}
+		f.source = s.source
+		switch {
+		case v == "*":
+			f.rest = true
+		case strings.HasSuffix(v, "..."):
+			f.rest = true
+			f.pos, _ = strconv.Atoi(strings.TrimSuffix(v, "..."))
+		default:
+			f.pos, _ = strconv.Atoi(v)
+		}
+		return true
+	}
+	if key, ok := tag.Lookup("zero"); ok {
+		f.source = bindState
+		f.key = key
+		return true
+	}
+	return false
+}
+
+func isBindType(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerTyp) || t == durationType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && isBindType(t.Elem())
+	default:
+		return false
+	}
+}
+
+// bindValues 懒加载的参数来源
+type bindValues struct {
+	ctx    *Ctx
+	args   []string
+	argErr error
+	parsed bool
+}
+
+func (s *bindValues) arguments() ([]string, error) {
+	if s.parsed {
+		return s.args, s.argErr
+	}
+	s.parsed = true
+	switch args := s.ctx.State["args"].(type) {
+	case string:
+		s.args, s.argErr = shell.Parse(args)
+	case []string: // ShellRule 剩余的位置参数
+		s.args = args
+	}
+	return s.args, s.argErr
+}
+
+// values 字段的原始值, 不存在时 ok 为 false
+func (s *bindValues) values(f *bindField) (vals []string, ok bool, err error) {
+	var all []string
+	switch f.source {
+	case bindArg:
+		all, err = s.arguments()
+	case bindRegex:
+		all, _ = s.ctx.State["regex_matched"].([]string)
+	case bindAt:
+		for _, seg := range s.ctx.Event.Message {
+			if seg.Type == "at" && seg.Data["qq"] != "all" {
+				all = append(all, seg.Data["qq"])
+			}
+		}
+	case bindImage:
+		if urls, ok := s.ctx.State["image_url"].([]string); ok { // HasPicture / MustProvidePicture
+			all = urls
+			break
+		}
+		for _, seg := range s.ctx.Event.Message {
+			if seg.Type == "image" {
+				if u := seg.Data["url"]; u != "" {
+					all = append(all, u)
+				} else {
+					all = append(all, seg.Data["file"])
+				}
+			}
+		}
+	}
+	if err != nil || f.pos >= len(all) {
+		return nil, false, err
+	}
+	if f.rest {
+		return all[f.pos:], true, nil
+	}
+	return all[f.pos : f.pos+1], true, nil
+}
+
+// bind 为字段 v 赋值并校验
+func (f *bindField) bind(v reflect.Value, src *bindValues) *FieldError {
+	fail := func(value, reason string) *FieldError {
+		return &FieldError{Field: f.field, Name: f.name, Value: value, Reason: reason}
+	}
+	if f.source == bindState {
+		x, ok := src.ctx.State[f.key]
+		if ok && x != nil {
+			xv := reflect.ValueOf(x)
+			switch {
+			case xv.Type().AssignableTo(v.Type()):
+				v.Set(xv)
+			case xv.Kind() == reflect.String && isBindType(v.Type()):
+				if reason := setBindValue(v, []string{xv.String()}); reason != "" {
+					return fail(xv.String(), reason)
+				}
+			default:
+				return fail(fmt.Sprint(x), "类型应为 "+v.Type().String())
+			}
+		} else if f.hasDef {
+			_ = setBindValue(v, []string{f.def})
+		}
+		return f.validate(v, ok && x != nil)
+	}
+	vals, ok, err := src.values(f)
+	if err != nil {
+		return fail("", "参数格式错误: "+err.Error())
+	}
+	if !ok {
+		if f.hasDef {
+			vals = []string{f.def}
+			if v.Kind() == reflect.Slice { // 切片的默认值以逗号分隔
+				vals = strings.Split(f.def, ",")
+			}
+		} else {
+			return f.validate(v, false)
+		}
+	}
+	if reason := setBindValue(v, vals); reason != "" {
+		return fail(strings.Join(vals, " "), reason)
+	}
+	return f.validate(v, true)
+}
+
+// validate 按 validate tag 校验 v
+func (f *bindField) validate(v reflect.Value, present bool) *FieldError {
+	if !present && f.required && !f.hasDef {
+		return &FieldError{Field: f.field, Name: f.name, Reason: "缺少参数"}
+	}
+	if !present && !f.hasDef {
+		return nil
+	}
+	for _, rule := range f.rules {
+		if reason := rule(v); reason != "" {
+			return &FieldError{Field: f.field, Name: f.name, Value: fmt.Sprint(v.Interface()), Reason: reason}
+		}
+	}
+	return nil
+}
+
+// setBindValue 将 vals 转换为 v 的类型并赋值, 失败时返回原因
+func setBindValue(v reflect.Value, vals []string) string {
+	if v.Kind() == reflect.Slice && !reflect.PointerTo(v.Type()).Implements(textUnmarshalerTyp) {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, str := range vals {
+			if reason := setBindScalar(s.Index(i), str); reason != "" {
+				return reason
+			}
+		}
+		v.Set(s)
+		return ""
+	}
+	return setBindScalar(v, strings.Join(vals, " "))
+}
+
+// setBindScalar 将 str 转换为 v 的类型并赋值, 失败时返回原因
+func setBindScalar(v reflect.Value, str string) string {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(str)); err != nil {
+			return "无效的值 " + strconv.Quote(str)
+		}
+		return ""
+	}
+	if v.Type() == durationType {
+		d, err := parseBindDuration(str)
+		if err != nil {
+			return "应为时长, 如 30s, 5m, 1h30m"
+		}
+		v.SetInt(int64(d))
+		return ""
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Bool:
+		b, ok := parseBindBool(str)
+		if !ok {
+			return "应为是或否"
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(str, 10, v.Type().Bits())
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return "超出范围"
+			}
+			return "应为整数"
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(str, 10, v.Type().Bits())
+		if err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return "超出范围"
+			}
+			return "应为非负整数"
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, v.Type().Bits())
+		if err != nil {
+			return "应为数字"
+		}
+		v.SetFloat(f)
+	default:
+		return "不支持的类型 " + v.Type().String()
+	}
+	return ""
+}
+
+// parseBindDuration 解析时长, 纯数字视为秒
+func parseBindDuration(str string) (time.Duration, error) {
+	if n, err := strconv.ParseFloat(str, 64); err == nil {
+		return time.Duration(n * float64(time.Second)), nil
+	}
+	return time.ParseDuration(str)
+}
+
+func parseBindBool(str string) (bool, bool) {
+	switch strings.ToLower(str) {
+	case "1", "t", "true", "y", "yes", "on", "是", "开", "开启":
+		return true, true
+	case "0", "f", "false", "n", "no", "off", "否", "关", "关闭":
+		return false, true
+	}
+	return false, false
+}
+
+// parseRules 解析 validate tag
+func (f *bindField) parseRules(tag string, t reflect.Type) error {
+	if tag == "" {
+		return nil
+	}
+	for _, item := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch name {
+		case "required":
+			f.required = true
+		case "min", "max":
+			rule, err := bindBound(name == "min", param, t)
+			if err != nil {
+				return err
+			}
+			f.rules = append(f.rules, rule)
+		case "oneof":
+			f.rules = append(f.rules, bindOneOf(strings.Fields(param)))
+		default:
+			return fmt.Errorf("unknown validation %q", name)
+		}
+	}
+	return nil
+}
+
+// bindBound min / max 校验: 数字比较数值, 字符串与切片比较长度
+func bindBound(isMin bool, param string, t reflect.Type) (bindRule, error) {
+	word := "大于"
+	if isMin {
+		word = "小于"
+	}
+	cmp := func(x, bound float64) bool {
+		if isMin {
+			return x >= bound
+		}
+		return x <= bound
+	}
+	if t == durationType {
+		d, err := parseBindDuration(param)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) string {
+			if !cmp(float64(v.Int()), float64(d)) {
+				return "不能" + word + " " + d.String()
+			}
+			return ""
+		}, nil
+	}
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return nil, err
+	}
+	return func(v reflect.Value) string {
+		var x float64
+		unit := ""
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			x = float64(v.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			x = float64(v.Uint())
+		case reflect.Float32, reflect.Float64:
+			x = v.Float()
+		case reflect.String:
+			x, unit = float64(len([]rune(v.String()))), "长度"
+		case reflect.Slice:
+			x, unit = float64(v.Len()), "数量"
+		default:
+			return ""
+		}
+		if !cmp(x, bound) {
+			return unit + "不能" + word + " " + param
+		}
+		return ""
+	}, nil
+}
+
+// bindOneOf oneof 校验, 比较值的字符串形式
+func bindOneOf(options []string) bindRule {
+	return func(v reflect.Value) string {
+		s := fmt.Sprint(v.Interface())
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			if b, err := m.MarshalText(); err == nil {
+				s = string(b)
+			}
+		}
+		for _, o := range options {
+			if s == o {
+				return ""
+			}
+		}
+		return "应为 " + strings.Join(options, ", ") + " 之一"
+	}
+}
@@ -0,0 +1,82 @@
+package zero_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+type banArgs struct {
+	Duration time.Duration `arg:"0" name:"时长" validate:"required,max=1h"`
+	Reason   []string      `arg:"1..." name:"理由"`
+	Target   int64         `at:"0" name:"对象" validate:"required"`
+	Count    int           `zero:"count" default:"1"`
+}
+
+func TestBind(t *testing.T) {
+	e := zerotest.Engine(t)
+	var fields []string
+	e.OnCommand("ban").Handle(func(ctx *zero.Ctx) {
+		var args banArgs
+		if err := ctx.Bind(&args); err != nil {
+			var berr zero.BindError
+			if errors.As(err, &berr) {
+				fields = fields[:0]
+				for _, fe := range berr {
+					fields = append(fields, fe.Field)
+				}
+			}
+			ctx.Send(err.Error())
+			return
+		}
+		ctx.Send(fmt.Sprint(args.Target, " ", args.Duration, " ", args.Reason, " ", args.Count))
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	bot.GroupMessage(1, 2, "/ban 10m spam words [CQ:at,qq=5]")
+	bot.GroupMessage(1, 2, `/ban 90 "long reason" [CQ:at,qq=5]`)
+	bot.GroupMessage(1, 2, "/ban 2h [CQ:at,qq=5]")
+	bot.GroupMessage(1, 2, "/ban soon")
+	bot.AssertSent(t,
+		"5 10m0s [spam words] 1",
+		"5 1m30s [long reason] 1",
+		"时长: 不能大于 1h0m0s",
+		"时长: 应为时长, 如 30s, 5m, 1h30m\n对象: 缺少参数",
+	)
+	if len(fields) != 2 || fields[0] != "Duration" || fields[1] != "Target" {
+		t.Fatalf("failed fields %v, want [Duration Target]", fields)
+	}
+}
+
+func TestBindInvalidModel(t *testing.T) {
+	e := zerotest.Engine(t)
+	var errs []error
+	e.OnCommand("bad").Handle(func(ctx *zero.Ctx) {
+		var n int
+		errs = append(errs, ctx.Bind(&n))
+		errs = append(errs, ctx.Bind(&struct {
+			C chan int `arg:"0"`
+		}{}))
+		errs = append(errs, ctx.Bind(&struct {
+			N int `arg:"0" validate:"positive"`
+		}{}))
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	bot.GroupMessage(1, 2, "/bad 1")
+	if len(errs) != 3 {
+		t.Fatalf("handler ran %d binds, want 3", len(errs))
+	}
+	for i, err := range errs {
+		var berr zero.BindError
+		if err == nil || errors.As(err, &berr) {
+			t.Fatalf("bind %d returned %v, want a model error", i, err)
+		}
+	}
+}
@@ -1,9 +1,13 @@
 package zero
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"hash/crc64"
-	"runtime/debug"
+	"io"
+	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,21 +19,25 @@ import (
 	"github.com/tidwall/gjson"
 
 	"github.com/cubevlmu/CZeroBot/message"
+	"github.com/cubevlmu/CZeroBot/record"
 	"github.com/cubevlmu/CZeroBot/utils/helper"
 )
 
 // Config is config of zero bot
 type Config struct {
-	NickName        []string      `json:"nickname"`           // 机器人名称
-	CommandPrefix   string        `json:"command_prefix"`     // 触发命令
-	SuperUsers      []int64       `json:"super_users"`        // 超级用户
-	RingLen         uint          `json:"ring_len"`           // 事件环长度 (默认关闭)
-	Latency         time.Duration `json:"latency"`            // 事件处理延迟 (延迟 latency 再处理事件，在 ring 模式下不可低于 1ms)
-	MaxProcessTime  time.Duration `json:"max_process_time"`   // 事件最大处理时间 (默认4min)
-	MarkMessage     bool          `json:"mark_message"`       // 自动标记消息为已读
-	KeepAtMeMessage bool          `json:"keep_at_me_message"` // 是否保留at me的原始消息
-	AddSpaceAfterAt bool          `json:"at_space"`           // 是否在At消息后没有空格时自动添加空格
-	Driver          []Driver      `json:"-"`                  // 通信驱动
+	NickName        []string       `json:"nickname"`           // 机器人名称
+	CommandPrefix   string         `json:"command_prefix"`     // 触发命令
+	SuperUsers      []int64        `json:"super_users"`        // 超级用户
+	RingLen         uint           `json:"ring_len"`           // 事件环长度 (默认关闭)
+	Latency         time.Duration  `json:"latency"`            // 事件处理延迟 (延迟 latency 再处理事件，在 ring 模式下不可低于 1ms)
+	MaxProcessTime  time.Duration  `json:"max_process_time"`   // Matcher 各阶段默认的最长处理时间 (默认4min), 见 Timeout
+	MarkMessage     bool           `json:"mark_message"`       // 自动标记消息为已读
+	KeepAtMeMessage bool           `json:"keep_at_me_message"` // 是否保留at me的原始消息
+	AddSpaceAfterAt bool           `json:"at_space"`           // 是否在At消息后没有空格时自动添加空格
+	SendLimit       *SendLimit     `json:"send_limit"`         // 发送消息的频率限制 (默认关闭)
+	MetricsAddr     string         `json:"metrics_addr"`       // 在此地址的 /metrics 输出指标, 如 127.0.0.1:9100 (默认关闭)
+	Record          *record.Config `json:"record"`             // 记录收到的事件与 API 调用, 可用 driver.NewReplay 重放 (默认关闭)
+	Driver          []Driver       `json:"-"`                  // 通信驱动
 }
 
 // APICallers 所有的APICaller列表， 通过self-ID映射
@@ -40,7 +48,48 @@ type APICaller interface {
 	CallAPI(request APIRequest) (APIResponse, error)
 }
 
+// ContextAPICaller 支持 context 的 APICaller
+//
+// ctx 取消时应放弃等待响应并返回 ctx.Err()
+type ContextAPICaller interface {
+	APICaller
+	CallAPIContext(ctx context.Context, request APIRequest) (APIResponse, error)
+}
+
+// CallAPIContext 使用 ctx 调用 caller
+//
+// 如果 caller 未实现 ContextAPICaller, 取消时仅放弃等待, 已发出的请求仍会继续
+func CallAPIContext(ctx context.Context, caller APICaller, request APIRequest) (APIResponse, error) {
+	if c, ok := caller.(ContextAPICaller); ok {
+		return c.CallAPIContext(ctx, request)
+	}
+	if ctx.Done() == nil { // 不可取消
+		return caller.CallAPI(request)
+	}
+	if err := ctx.Err(); err != nil {
+		return APIResponse{}, err
+	}
+	type result struct {
+		rsp APIResponse
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		rsp, err := caller.CallAPI(request)
+		ch <- result{rsp: rsp, err: err}
+	}()
+	select {
+	case r := <-ch:
+		return r.rsp, r.err
+	case <-ctx.Done():
+		return APIResponse{}, ctx.Err()
+	}
+}
+
 // Driver 与OneBot通信的驱动，使用driver.DefaultWebSocketDriver
+//
+// 如果 Driver 同时实现了 io.Closer, Stop 时将调用其 Close 关闭连接,
+// 此后 Listen 应当返回. 需要连接状态与事件时请实现 LifecycleDriver
 type Driver interface {
 	Connect()
 	Listen(func([]byte, APICaller))
@@ -50,24 +99,96 @@ type Driver interface {
 var BotConfig Config
 
 var (
-	evring    eventRing // evring 事件环
-	isrunning uintptr
+	evring    atomic.Pointer[eventRing] // evring 事件环, 未启用时为 nil
+	isrunning uintptr                   // 0: 未运行 1: 运行中 2: 停止中
+	running   runState
 )
 
+// runState 运行中 bot 的事件处理状态
+type runState struct {
+	mu       sync.RWMutex // 保证 stopping 后不再有 wg.Add
+	stopping bool
+	wg       sync.WaitGroup // 正在处理的事件
+	draining bool           // Stop 已开始, 不再接收 Driver 的事件
+	linked   sync.WaitGroup // 已从 Driver 收到但尚未登记或放入事件环的事件
+	inflight sync.Map       // 正在处理的 *Ctx
+	drivers  []Driver
+	cancels  []func() // 取消订阅 Driver 连接事件
+}
+
+// reset 开始接收事件
+func (s *runState) reset(drivers []Driver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopping = false
+	s.draining = false
+	s.drivers = drivers
+}
+
+// link 登记一个从 Driver 收到的事件, Stop 开始后返回 false
+func (s *runState) link() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.draining {
+		return false
+	}
+	s.linked.Add(1)
+	return true
+}
+
+// acquire 登记一个待处理事件, 停止中返回 false
+func (s *runState) acquire(ctx *Ctx) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stopping {
+		return false
+	}
+	s.wg.Add(1)
+	s.inflight.Store(ctx, struct{}{})
+	return true
+}
+
+// release 事件处理完成
+func (s *runState) release(ctx *Ctx) {
+	s.inflight.Delete(ctx)
+	s.wg.Done()
+}
+
+// cancelAll 取消所有正在处理的事件仍在进行的 API 调用
+func (s *runState) cancelAll() {
+	s.inflight.Range(func(key, _ interface{}) bool {
+		key.(*Ctx).cancel(nil)
+		return true
+	})
+}
+
 func runinit(op *Config) {
 	if op.MaxProcessTime == 0 {
 		op.MaxProcessTime = time.Minute * 4
 	}
 	BotConfig = *op
+	running.reset(op.Driver)
+	if op.MetricsAddr != "" {
+		serveMetrics(op.MetricsAddr)
+	}
+	if op.Record != nil {
+		startRecord(op.Record)
+	}
 	if op.RingLen == 0 {
+		evring.Store(nil)
 		return
 	}
-	evring = newring(op.RingLen)
-	evring.loop(op.Latency, op.MaxProcessTime, processEventAsync)
+	r := newring(op.RingLen)
+	r.loop(op.Latency, op.MaxProcessTime, processEventAsync)
+	evring.Store(r)
 }
 
 func (op *Config) directlink(b []byte, c APICaller) {
+	if !running.link() {
+		return
+	}
 	go func() {
+		defer running.linked.Done()
 		if op.Latency != 0 {
 			time.Sleep(op.Latency)
 		}
@@ -75,16 +196,27 @@ func (op *Config) directlink(b []byte, c APICaller) {
 	}()
 }
 
+// ringlink 将事件放入事件环, Stop 开始后丢弃
+func ringlink(b []byte, c APICaller) {
+	if !running.link() {
+		return
+	}
+	defer running.linked.Done()
+	evring.Load().processEvent(b, c)
+}
+
 // Run 主函数初始化
 func Run(op *Config) {
 	if !atomic.CompareAndSwapUintptr(&isrunning, 0, 1) {
 		log.Warning("[bot] ignored duplicated Run")
+		return
 	}
 	runinit(op)
 	linkf := op.directlink
 	if op.RingLen != 0 {
-		linkf = evring.processEvent
+		linkf = ringlink
 	}
+	running.cancels = subscribeDrivers(op.Driver, linkf)
 	for _, driver := range op.Driver {
 		driver.Connect()
 		go driver.Listen(linkf)
@@ -94,15 +226,19 @@ func Run(op *Config) {
 // RunAndBlock 主函数初始化并阻塞
 //
 //	preblock 在所有 Driver 连接后，调用最后一个 Driver 的 Listen 阻塞前执行本函数
+//
+// 调用 Stop 关闭最后一个 Driver 后返回
 func RunAndBlock(op *Config, preblock func()) {
 	if !atomic.CompareAndSwapUintptr(&isrunning, 0, 1) {
 		log.Warning("[bot] ignored calling duplicated RunAndBlock")
+		return
 	}
 	runinit(op)
 	linkf := op.directlink
 	if op.RingLen != 0 {
-		linkf = evring.processEvent
+		linkf = ringlink
 	}
+	running.cancels = subscribeDrivers(op.Driver, linkf)
 	switch len(op.Driver) {
 	case 0:
 		return
@@ -126,6 +262,77 @@ func RunAndBlock(op *Config, preblock func()) {
 	}
 }
 
+// Stop 停止由 Run / RunAndBlock 启动的 bot
+//
+// 立即停止接收新事件, 已收到与事件环中的事件仍会处理, 等待正在处理的事件直到 ctx 结束,
+// 随后关闭所有实现了 io.Closer 的 Driver 并清空 APICallers, 之后可再次调用 Run 或 Dispatch.
+// ctx 结束时仍未完成的事件将被取消, 并返回 ctx.Err()
+func Stop(ctx context.Context) error {
+	if !atomic.CompareAndSwapUintptr(&isrunning, 1, 2) {
+		return nil
+	}
+	log.Info("[bot] stopping, waiting for running handlers...")
+	running.mu.Lock()
+	running.draining = true
+	running.mu.Unlock()
+	waitGroup(ctx, &running.linked) // 已收到的事件仍需处理
+	if r := evring.Load(); r != nil {
+		r.stop() // 事件环中的事件同样需要处理
+	}
+	running.mu.Lock()
+	running.stopping = true
+	running.mu.Unlock()
+
+	var errs []error
+	if !waitGroup(ctx, &running.wg) {
+		log.Warning("[bot] timeout occured when waiting for running handlers, cancel them")
+		running.cancelAll()
+		errs = append(errs, ctx.Err())
+	}
+
+	for _, driver := range running.drivers {
+		if c, ok := driver.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				log.Warningf("[bot] failed to close driver: %v", err)
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, cancel := range running.cancels {
+		cancel()
+	}
+	running.cancels = nil
+	stopMetrics()
+	stopRecord()
+	stopSchedulers()
+	APICallers.Range(func(id int64, _ APICaller) bool {
+		APICallers.Delete(id)
+		return true
+	})
+	running.mu.Lock()
+	running.stopping = false // Dispatch 恢复可用, Driver 的事件在再次 Run 前仍被丢弃
+	running.drivers = nil
+	running.mu.Unlock()
+	atomic.StoreUintptr(&isrunning, 0)
+	log.Info("[bot] stopped")
+	return errors.Join(errs...)
+}
+
+// waitGroup 等待 wg 直到 ctx 结束, ctx 先结束时返回 false
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 var (
 	triggeredMessages   = ttl.NewCache[int64, []message.ID](time.Minute * 5)
 	triggeredMessagesMu = sync.Mutex{}
@@ -137,14 +344,19 @@ type messageLogger struct {
 }
 
 // CallAPI 记录被触发的回复消息
-func (m *messageLogger) CallAPI(request APIRequest) (rsp APIResponse, err error) {
+func (m *messageLogger) CallAPI(request APIRequest) (APIResponse, error) {
+	return m.CallAPIContext(context.Background(), request)
+}
+
+// CallAPIContext 记录被触发的回复消息
+func (m *messageLogger) CallAPIContext(ctx context.Context, request APIRequest) (rsp APIResponse, err error) {
 	noLog := false
 	b, ok := request.Params["__zerobot_no_log_mseeage_id__"].(bool)
 	if ok {
 		noLog = b
 		delete(request.Params, "__zerobot_no_log_mseeage_id__")
 	}
-	rsp, err = m.caller.CallAPI(request)
+	rsp, err = CallAPIContext(ctx, m.caller, request)
 	if err != nil {
 		return
 	}
@@ -172,9 +384,48 @@ func GetTriggeredMessages(id message.ID) []message.ID {
 
 // processEventAsync 从池中处理事件, 异步调用匹配 mather
 func processEventAsync(response []byte, caller APICaller, maxwait time.Duration) {
+	ctx, matchers, ok := newEventCtx(response, caller)
+	if !ok {
+		return
+	}
+	go func() {
+		defer running.release(ctx)
+		defer ctx.cancel(errFinished)
+		match(ctx, matchers, maxwait)
+		endEventSpan(ctx)
+	}()
+}
+
+// Dispatch 同步处理事件, 在所有 matcher 处理完成后返回
+//
+// 供测试与自行调度事件的 Driver 使用, 未经 Run 时最大处理时间为默认的 4min
+func Dispatch(response []byte, caller APICaller) {
+	ctx, matchers, ok := newEventCtx(response, caller)
+	if !ok {
+		return
+	}
+	defer running.release(ctx)
+	defer ctx.cancel(errFinished)
+	maxwait := BotConfig.MaxProcessTime
+	if maxwait == 0 {
+		maxwait = time.Minute * 4
+	}
+	match(ctx, matchers, maxwait)
+	endEventSpan(ctx)
+}
+
+// newEventCtx 解析事件并登记为正在处理, 返回 Ctx 与当前的 matcher 列表
+//
+// 停止中返回 false, 否则处理结束后需调用 ctx.cancel(errFinished) 与 running.release
+func newEventCtx(response []byte, caller APICaller) (*Ctx, []*Matcher, bool) {
+	orig := response // 记录原始事件
+	raw := gjson.Parse(helper.BytesToString(response))
+	if isOneBot12Event(raw) { // 转换为 OneBot 11 格式, RawEvent 保留原始事件
+		response = oneBot12Event(raw)
+	}
 	var event Event
 	_ = json.Unmarshal(response, &event)
-	event.RawEvent = gjson.Parse(helper.BytesToString(response))
+	event.RawEvent = raw
 	var msgid message.ID
 	messageID, err := strconv.ParseInt(helper.BytesToString(event.RawMessageID), 10, 64)
 	if err == nil {
@@ -185,26 +436,18 @@ func processEventAsync(response []byte, caller APICaller, maxwait time.Duration)
 		// MessageID 填为 string
 		event.MessageID, _ = strconv.Unquote(helper.BytesToString(event.RawMessageID))
 		// 伪造 GroupID
-		crc := crc64.New(crc64.MakeTable(crc64.ISO))
-		crc.Write(helper.StringToBytes(event.GuildID))
-		crc.Write(helper.StringToBytes(event.ChannelID))
-		r := int64(crc.Sum64() & 0x7fff_ffff_ffff_ffff) // 确保为正数
-		if r <= 0xffff_ffff {
-			r |= 0x1_0000_0000 // 确保不与正常号码重叠
-		}
-		event.GroupID = r
+		event.GroupID = fakeID(event.GuildID, event.ChannelID)
 		// 伪造 UserID
-		crc.Reset()
-		crc.Write(helper.StringToBytes(event.TinyID))
-		r = int64(crc.Sum64() & 0x7fff_ffff_ffff_ffff) // 确保为正数
-		if r <= 0xffff_ffff {
-			r |= 0x1_0000_0000 // 确保不与正常号码重叠
-		}
+		r := fakeID(event.TinyID)
 		event.UserID = r
 		if event.Sender != nil {
 			event.Sender.ID = r
 		}
 		msgid = message.NewMessageIDFromString(event.MessageID.(string))
+	} else if s, err := strconv.Unquote(helper.BytesToString(event.RawMessageID)); err == nil {
+		// OneBot 12 的非数字消息 ID, 以字符串保留
+		event.MessageID = s
+		msgid = message.NewMessageIDFromString(s)
 	}
 
 	switch event.PostType { // process DetailType
@@ -215,23 +458,54 @@ func processEventAsync(response []byte, caller APICaller, maxwait time.Duration)
 		preprocessNoticeEvent(&event)
 	case "request":
 		event.DetailType = event.RequestType
+	case "meta_event":
+		event.DetailType = event.MetaEventType
 	}
 	if event.PostType == "message" {
 		preprocessMessageEvent(&event)
 	}
+	if c, ok := APICallers.Load(event.SelfID); ok { // 已由 StoreCaller 包装
+		caller = c
+	} else { // 未存入的账号, 如直接调用 Dispatch
+		caller = outbound(event.SelfID, caller)
+	}
 	ctx := &Ctx{
 		Event:  &event,
 		State:  State{},
 		caller: &messageLogger{msgid: msgid, caller: caller},
 	}
+	ctx.stdctx, ctx.cancel = context.WithCancelCause(context.Background())
+	if !running.acquire(ctx) {
+		ctx.cancel(errFinished)
+		log.With(log.SelfID(event.SelfID)).Debug("[bot] bot is stopping, drop event")
+		return nil, nil, false
+	}
+	recordEvent(event.SelfID, orig) // 只记录会被处理的事件
+	observeEvent(&event)
+	startEventSpan(ctx)
 	matcherLock.Lock()
-	if hasMatcherListChanged {
-		matcherListForRanging = make([]*Matcher, len(matcherList))
-		copy(matcherListForRanging, matcherList)
+	if hasMatcherListChanged || matcherIndexForRanging == nil {
+		matchers := make([]*Matcher, len(matcherList))
+		copy(matchers, matcherList)
+		matcherIndexForRanging = newDispatchIndex(matchers)
 		hasMatcherListChanged = false
 	}
+	index := matcherIndexForRanging
 	matcherLock.Unlock()
-	go match(ctx, matcherListForRanging, maxwait)
+	return ctx, index.candidates(ctx), true
+}
+
+// fakeID 由字符串伪造 int64 ID
+func fakeID(s ...string) int64 {
+	crc := crc64.New(crc64.MakeTable(crc64.ISO))
+	for _, x := range s {
+		crc.Write(helper.StringToBytes(x))
+	}
+	r := int64(crc.Sum64() & 0x7fff_ffff_ffff_ffff) // 确保为正数
+	if r <= 0xffff_ffff {
+		r |= 0x1_0000_0000 // 确保不与正常号码重叠
+	}
+	return r
 }
 
 // match 匹配规则，处理事件
@@ -239,35 +513,114 @@ func match(ctx *Ctx, matchers []*Matcher, maxwait time.Duration) {
 	if BotConfig.MarkMessage && ctx.Event.MessageID != nil {
 		ctx.MarkThisMessageAsRead()
 	}
-	gorule := func(rule Rule) <-chan bool {
+	// root 事件的 context, 每个 Matcher 在第一次阻塞等待前由其派生出可单独取消的 context
+	root := ctx.stdctx
+	var cancel context.CancelCauseFunc
+	begin := func() {
+		if cancel == nil {
+			ctx.stdctx, cancel = context.WithCancelCause(root)
+		}
+	}
+	// finish 释放处理完成的 Matcher 的 context
+	finish := func() {
+		if cancel != nil {
+			cancel(errFinished)
+			cancel = nil
+		}
+	}
+	defer finish()
+	gorule := func(m *Matcher, stage string, rule Rule) <-chan bool {
+		begin()
+		c := ctx // 超时后 ctx 将被替换
 		ch := make(chan bool, 1)
 		go func() {
 			defer func() {
-				close(ch)
-				if pa := recover(); pa != nil {
-					log.Errorf("[bot] execute rule err: %v\n%v", pa, helper.BytesToString(debug.Stack()))
+				if pa := recover(); pa != nil { // 先调用错误钩子, 再视为处理完成
+					recoverPanic(c, m, stage, pa)
 				}
+				close(ch)
 			}()
-			ch <- rule(ctx)
+			ch <- rule(c)
 		}()
 		return ch
 	}
-	gohandler := func(h Handler) <-chan struct{} {
-		ch := make(chan struct{}, 1)
+	gohandler := func(m *Matcher, stage string, h HandlerE) <-chan bool {
+		begin()
+		c := ctx
+		ch := make(chan bool, 1)
 		go func() {
 			defer func() {
-				close(ch)
 				if pa := recover(); pa != nil {
-					log.Errorf("[bot] execute handler err: %v\n%v", pa, helper.BytesToString(debug.Stack()))
+					recoverPanic(c, m, stage, pa)
 				}
+				close(ch)
 			}()
-			h(ctx)
-			ch <- struct{}{}
+			if err := h(c); err != nil {
+				reportError(c, &HandlerError{Matcher: m, Stage: stage, Err: err})
+			}
+			ch <- true
 		}()
 		return ch
 	}
-	t := time.NewTimer(maxwait)
-	defer t.Stop()
+	// wait 等待 c 的结果, 超过该阶段的最长处理时间返回 timeout
+	wait := func(m *Matcher, stage string, c <-chan bool) (ok, timeout bool) {
+		d := m.stageTimeout(stage, maxwait)
+		if d < 0 || m.NoTimeout { // 不设超时限制
+			return <-c, false
+		}
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case ok = <-c:
+			return ok, false
+		case <-t.C:
+			if m.NoTimeout { // 在处理中调用了 ctx.NoTimeout
+				return <-c, false
+			}
+			eventLog(ctx, m).With(log.Stage(stage), log.Latency(d)).
+				Warningf("[bot] timeout occured when handling %s stage, abandon matcher", stage)
+			observeTimeout(m, stage)
+			cancel(nil) // 取消超时 Matcher 仍在进行的 API 调用
+			return false, true
+		}
+	}
+	// rules 依次判断 rs 直至有未满足的, 每个阶段一个 Span
+	//
+	// 前 inline 个 Rule 不会阻塞, 直接在当前协程中判断
+	rules := func(m *Matcher, stage string, rs []Rule, inline int) (ok, timeout bool) {
+		if len(rs) == 0 {
+			return true, false
+		}
+		span := stageSpan(ctx, m, stage)
+		for i, r := range rs {
+			if i < inline {
+				ok = callRule(ctx, m, stage, r)
+			} else {
+				ok, timeout = wait(m, stage, gorule(m, stage, r))
+			}
+			if !ok || timeout {
+				break
+			}
+		}
+		endStageSpan(span, ok, timeout)
+		return
+	}
+	// handle 执行 h, 超时返回 true
+	handle := func(m *Matcher, stage string, h HandlerE) (timeout bool) {
+		span := stageSpan(ctx, m, stage)
+		start := time.Now()
+		_, timeout = wait(m, stage, gohandler(m, stage, h))
+		if stage == "Handler" {
+			observeHandler(m, time.Since(start))
+		}
+		endStageSpan(span, !timeout, timeout)
+		return
+	}
+	// abandon 放弃超时的 Matcher, 其协程仍持有原 Ctx, 后续 Matcher 使用新的 Ctx
+	abandon := func() {
+		ctx = ctx.fork(root)
+		cancel = nil
+	}
 loop:
 	for _, matcher := range matchers {
 		if !matcher.Type(ctx) {
@@ -278,118 +631,48 @@ loop:
 		}
 		m := matcher.copy()
 		ctx.ma = m
+		finish()
+		ctx.stdctx = root
 
-		// pre handler
-		if m.Engine != nil {
-			for _, handler := range m.Engine.preHandler {
-				c := gorule(handler)
-				for {
-					select {
-					case ok := <-c:
-						if !ok { // 有 pre handler 未满足
-							if m.Break { // 阻断后续
-								break loop
-							}
-							continue loop
-						}
-					case <-t.C:
-						if m.NoTimeout { // 不设超时限制
-							t.Reset(maxwait)
-							continue
-						}
-						log.Warning("[bot] timeout occured when handling preHandler stage, halt")
-						break loop
-					}
-					break
-				}
-			}
+		ok, timeout := true, false
+		if m.Engine != nil { // pre handler
+			ok, timeout = rules(m, "preHandler", m.Engine.preHandler, m.Engine.inlineHandlers())
 		}
-
-		for _, rule := range m.Rules {
-			c := gorule(rule)
-			for {
-				select {
-				case ok := <-c:
-					if !ok { // 有 Rule 的条件未满足
-						if m.Break { // 阻断后续
-							break loop
-						}
-						continue loop
-					}
-				case <-t.C:
-					if m.NoTimeout { // 不设超时限制
-						t.Reset(maxwait)
-						continue
-					}
-					log.Warning("[bot] timeout occured when handling rule stage, halt")
-					break loop
-				}
-				break
-			}
+		if ok && !timeout {
+			ok, timeout = rules(m, "rule", m.Rules, m.inlineRules())
 		}
-
-		// mid handler
-		if m.Engine != nil {
-			for _, handler := range m.Engine.midHandler {
-				c := gorule(handler)
-				for {
-					select {
-					case ok := <-c:
-						if !ok { // 有 mid handler 未满足
-							if m.Break { // 阻断后续
-								break loop
-							}
-							continue loop
-						}
-					case <-t.C:
-						if m.NoTimeout { // 不设超时限制
-							t.Reset(maxwait)
-							continue
-						}
-						log.Warning("[bot] timeout occured when handling midHandler stage, halt")
-						break loop
-					}
-					break
-				}
+		if ok && !timeout && m.Engine != nil { // mid handler
+			ok, timeout = rules(m, "midHandler", m.Engine.midHandler, m.Engine.inlineHandlers())
+		}
+		if timeout {
+			abandon()
+			continue loop
+		}
+		if !ok { // 有 pre handler, Rule 或 mid handler 的条件未满足
+			if m.Break { // 阻断后续
+				break loop
 			}
+			continue loop
 		}
 
-		if m.Handler != nil {
-			c := gohandler(m.Handler)
-			for {
-				select {
-				case <-c: // 处理事件
-				case <-t.C:
-					if m.NoTimeout { // 不设超时限制
-						t.Reset(maxwait)
-						continue
-					}
-					log.Warning("[bot] timeout occured when handling Handler stage, halt")
-					break loop
-				}
-				break
-			}
+		timeout = false
+		if h := m.handler(); h != nil {
+			timeout = handle(m, "Handler", h)
 		}
 		if matcher.Temp { // 临时 Matcher 删除
 			matcher.Delete()
 		}
+		if timeout {
+			abandon()
+			continue loop
+		}
 
 		if m.Engine != nil {
 			// post handler
 			for _, handler := range m.Engine.postHandler {
-				c := gohandler(handler)
-				for {
-					select {
-					case <-c:
-					case <-t.C:
-						if m.NoTimeout { // 不设超时限制
-							t.Reset(maxwait)
-							continue
-						}
-						log.Warning("[bot] timeout occured when handling postHandler stage, halt")
-						break loop
-					}
-					break
+				if handle(m, "postHandler", handlerE(handler)) {
+					abandon()
+					continue loop
 				}
 			}
 		}
@@ -400,6 +683,44 @@ loop:
 	}
 }
 
+// eventLog 带有事件与 Matcher m 字段的日志, m 可为 nil
+func eventLog(ctx *Ctx, m *Matcher) *log.Entry {
+	fields := make([]log.Field, 0, 4)
+	if e := ctx.Event; e != nil { // GetBot 得到的 Ctx 没有事件
+		fields = append(fields, log.SelfID(e.SelfID))
+		if e.GroupID != 0 {
+			fields = append(fields, log.GroupID(e.GroupID))
+		}
+		if e.UserID != 0 {
+			fields = append(fields, log.UserID(e.UserID))
+		}
+	}
+	if m != nil {
+		fields = append(fields, log.Matcher(matcherName(m)))
+	}
+	return log.With(fields...)
+}
+
+// matcherName 日志中 Matcher 的名称, 为插件名或 Handler 的函数名
+func matcherName(m *Matcher) string {
+	if m.Engine != nil && m.Engine.service != nil {
+		return m.Engine.service.info.Name
+	}
+	var h interface{}
+	switch {
+	case m.HandlerE != nil:
+		h = m.HandlerE
+	case m.Handler != nil:
+		h = m.Handler
+	default:
+		return ""
+	}
+	if f := runtime.FuncForPC(reflect.ValueOf(h).Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
 // preprocessMessageEvent 返回信息事件
 func preprocessMessageEvent(e *Event) {
 	msgs := message.ParseMessage(e.NativeMessage)
@@ -452,14 +773,17 @@ func preprocessMessageEvent(e *Event) {
 
 	switch {
 	case e.DetailType == "group":
-		log.Infof("[bot] received message from group (%v) %v : %v", e.GroupID, e.Sender.String(), e.RawMessage)
+		log.With(log.SelfID(e.SelfID), log.GroupID(e.GroupID), log.UserID(e.UserID)).
+			Infof("[bot] received message from group (%v) %v : %v", e.GroupID, e.Sender.String(), e.RawMessage)
 		processAt()
 	case e.DetailType == "guild" && e.SubType == "channel":
-		log.Infof("[bot] received message from channel (%v)(%v-%v) %v : %v", e.GroupID, e.GuildID, e.ChannelID, e.Sender.String(), e.Message)
+		log.With(log.SelfID(e.SelfID), log.GroupID(e.GroupID), log.UserID(e.UserID)).
+			Infof("[bot] received message from channel (%v)(%v-%v) %v : %v", e.GroupID, e.GuildID, e.ChannelID, e.Sender.String(), e.Message)
 		processAt()
 	default:
 		e.IsToMe = true // 私聊也判断为at
-		log.Infof("[bot] received DM message from %v : %v", e.Sender.String(), e.RawMessage)
+		log.With(log.SelfID(e.SelfID), log.UserID(e.UserID)).
+			Infof("[bot] received DM message from %v : %v", e.Sender.String(), e.RawMessage)
 	}
 	if len(e.Message) > 0 && e.Message[0].Type == "text" { // Trim Again!
 		e.Message[0].Data["text"] = strings.TrimLeft(e.Message[0].Data["text"], " ")
@@ -484,6 +808,13 @@ func GetBot(id int64) *Ctx {
 	return &Ctx{caller: caller}
 }
 
+// StoreCaller 将账号 selfID 的 caller 按 Config.SendLimit 包装后存入 APICallers
+//
+// Driver 应使用本函数而非 APICallers.Store, 使直接从 APICallers 取出的 caller 同样受发送限制
+func StoreCaller(selfID int64, caller APICaller) {
+	APICallers.Store(selfID, outbound(selfID, caller))
+}
+
 // RangeBot 遍历所有bot (Ctx)实例
 //
 // 单次操作返回 true 则继续遍历，否则退出
@@ -0,0 +1,85 @@
+package zero_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+// blockingCaller 在 release 关闭前阻塞的 APICaller
+type blockingCaller struct {
+	called  chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingCaller) CallAPI(zero.APIRequest) (zero.APIResponse, error) {
+	c.called <- struct{}{}
+	<-c.release
+	return zero.APIResponse{Status: "ok"}, nil
+}
+
+func TestCallAPIContext(t *testing.T) {
+	c := &blockingCaller{called: make(chan struct{}, 1), release: make(chan struct{})}
+	defer close(c.release)
+
+	// 已取消时不调用 caller
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := zero.CallAPIContext(canceled, c, zero.APIRequest{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled call err %v, want %v", err, context.Canceled)
+	}
+	if len(c.called) != 0 {
+		t.Fatal("caller called with a canceled context")
+	}
+
+	// 取消时放弃等待
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := zero.CallAPIContext(ctx, c, zero.APIRequest{})
+		errc <- err
+	}()
+	<-c.called
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("call err %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("call not abandoned after cancel")
+	}
+
+	// 不可取消时直接调用
+	direct := &blockingCaller{called: make(chan struct{}, 1), release: make(chan struct{})}
+	close(direct.release)
+	rsp, err := zero.CallAPIContext(context.Background(), direct, zero.APIRequest{})
+	if err != nil || rsp.Status != "ok" {
+		t.Fatalf("call returned %+v, %v", rsp, err)
+	}
+}
+
+func TestCallActionContext(t *testing.T) {
+	e := zerotest.Engine(t)
+	var canceled, ok zero.APIResponse
+	e.OnCommand("call").Handle(func(ctx *zero.Ctx) {
+		c, cancel := context.WithCancel(ctx.Context())
+		cancel()
+		canceled = ctx.CallActionContext(c, "get_login_info", nil)
+		ok = ctx.CallAction("get_login_info", nil)
+	})
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	bot.GroupMessage(1, 2, "/call")
+	bot.AssertCalled(t, "get_login_info", 1)
+	if canceled.Status == "ok" {
+		t.Fatalf("canceled call returned %+v", canceled)
+	}
+	if ok.Data.Get("user_id").Int() != zerotest.SelfID {
+		t.Fatalf("call returned %+v", ok)
+	}
+}
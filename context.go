@@ -1,9 +1,10 @@
 package zero
 
 import (
-	"fmt"
-	"reflect"
+	"context"
+	"errors"
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/cubevlmu/CZeroBot/message"
@@ -17,6 +18,11 @@ type Ctx struct {
 	State  State
 	caller APICaller
 
+	// stdctx 当前 Matcher 的 context, 在其处理超时时被取消, 处理完成时以 errFinished 释放
+	stdctx context.Context
+	// cancel 取消整个事件的处理, Stop 超时时以 nil 调用, 事件处理完成时以 errFinished 调用
+	cancel context.CancelCauseFunc
+
 	// lazy message
 	once    sync.Once
 	message string
@@ -27,54 +33,72 @@ func (ctx *Ctx) GetMatcher() *Matcher {
 	return ctx.ma
 }
 
-// ExposeCaller as *T, maybe panic if misused
-func ExposeCaller[T any](ctx *Ctx) *T {
-	return (*T)(*(*unsafe.Pointer)(unsafe.Add(unsafe.Pointer(&ctx.caller), unsafe.Sizeof(uintptr(0)))))
+// errFinished 处理完成时释放 context 的原因, 与超时或 Stop 的取消区分
+var errFinished = errors.New("zero: event finished")
+
+// Context 返回本次事件处理的 context.Context, 当前 Matcher 处理超时后将被取消
+//
+// 处理完成后返回 context.Background(), 使 Handler 启动的协程仍可调用 API.
+// FutureEvent 收到的 Ctx 的 context 不会被取消
+func (ctx *Ctx) Context() context.Context {
+	if ctx.stdctx == nil || context.Cause(ctx.stdctx) == errFinished {
+		return context.Background()
+	}
+	return ctx.stdctx
 }
 
-// decoder 反射获取的数据
-type decoder []dec
+// fork 为超时 Matcher 之后的 Matcher 复制事件的 Ctx, root 为事件的 context
+func (ctx *Ctx) fork(root context.Context) *Ctx {
+	return &Ctx{
+		Event:  ctx.Event,
+		State:  State{},
+		caller: ctx.caller,
+		stdctx: root,
+		cancel: ctx.cancel,
+	}
+}
 
-type dec struct {
-	index int
-	key   string
+// detach 复制交给 FutureEvent 接收方的 Ctx
+//
+// 接收方在其他协程中使用, 事件处理继续进行时原 Ctx 会被修改或取消,
+// 因此复制 Matcher 与 State, 并使用不会被取消的 context, 保留其中的值 (如 trace 的 Span)
+func (ctx *Ctx) detach() *Ctx {
+	state := make(State, len(ctx.State))
+	for k, v := range ctx.State {
+		state[k] = v
+	}
+	return &Ctx{
+		ma:     ctx.ma.copy(),
+		Event:  ctx.Event,
+		State:  state,
+		caller: ctx.caller,
+		stdctx: detachedContext{ctx.Context()},
+	}
+}
+
+// detachedContext 保留 parent 的值但不会被取消, 同 go1.21 的 context.WithoutCancel
+type detachedContext struct {
+	parent context.Context
 }
 
-// decoder 缓存
-var decoderCache = sync.Map{}
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// ExposeCaller as *T, maybe panic if misused
+func ExposeCaller[T any](ctx *Ctx) *T {
+	return (*T)(*(*unsafe.Pointer)(unsafe.Add(unsafe.Pointer(&ctx.caller), unsafe.Sizeof(uintptr(0)))))
+}
 
 // Parse 将 Ctx.State 映射到结构体
-func (ctx *Ctx) Parse(model interface{}) (err error) {
-	var (
-		rv       = reflect.ValueOf(model).Elem()
-		t        = rv.Type()
-		modelDec decoder
-	)
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("parse state error: %v", r)
-		}
-	}()
-	d, ok := decoderCache.Load(t)
-	if ok {
-		modelDec = d.(decoder)
-	} else {
-		modelDec = decoder{}
-		for i := 0; i < t.NumField(); i++ {
-			t1 := t.Field(i)
-			if key, ok := t1.Tag.Lookup("zero"); ok {
-				modelDec = append(modelDec, dec{
-					index: i,
-					key:   key,
-				})
-			}
-		}
-		decoderCache.Store(t, modelDec)
-	}
-	for _, d := range modelDec { // decoder类型非小内存，无法被编译器优化为快速拷贝
-		rv.Field(d.index).Set(reflect.ValueOf(ctx.State[d.key]))
-	}
-	return nil
+//
+// Deprecated: 使用 Bind, 其支持 Parse 的 `zero:"key"` tag 并会转换字符串
+func (ctx *Ctx) Parse(model interface{}) error {
+	return ctx.Bind(model)
 }
 
 // CheckSession 判断会话连续性
@@ -133,8 +157,8 @@ func (ctx *Ctx) SendChain(msg ...message.Segment) message.ID {
 
 // Echo 向自身分发虚拟事件
 func (ctx *Ctx) Echo(response []byte) {
-	if BotConfig.RingLen != 0 {
-		evring.processEvent(response, ctx.caller)
+	if r := evring.Load(); r != nil {
+		r.processEvent(response, ctx.caller)
 	} else {
 		processEventAsync(response, ctx.caller, BotConfig.MaxProcessTime)
 	}
@@ -145,12 +169,32 @@ func (ctx *Ctx) FutureEvent(typ string, rule ...Rule) *FutureEvent {
 	return ctx.ma.FutureEvent(typ, rule...)
 }
 
-// Get ..
+// Get 发送 prompt 并等待同一会话的下一条消息
+//
+// 事件处理超时 (ctx.Context() 结束) 时返回空字符串
 func (ctx *Ctx) Get(prompt string) string {
 	if prompt != "" {
 		ctx.Send(prompt)
 	}
-	return (<-ctx.FutureEvent("message", ctx.CheckSession()).Next()).Event.RawMessage
+	next := <-ctx.FutureEvent("message", ctx.CheckSession()).NextContext(ctx.Context())
+	if next == nil {
+		return ""
+	}
+	return next.Event.RawMessage
+}
+
+// GetWithTimeout 同 Get, 超过 timeout 未收到回复时返回 false
+func (ctx *Ctx) GetWithTimeout(prompt string, timeout time.Duration) (string, bool) {
+	if prompt != "" {
+		ctx.Send(prompt)
+	}
+	c, cancel := context.WithTimeout(ctx.Context(), timeout)
+	defer cancel()
+	next := <-ctx.FutureEvent("message", ctx.CheckSession()).NextContext(c)
+	if next == nil {
+		return "", false
+	}
+	return next.Event.RawMessage, true
 }
 
 // ExtractPlainText 提取消息中的纯文本
@@ -171,7 +215,7 @@ func (ctx *Ctx) Break() {
 	ctx.ma.Break = true
 }
 
-// NoTimeout 处理时不设超时
+// NoTimeout 当前 Matcher 之后的处理不设超时
 func (ctx *Ctx) NoTimeout() {
 	ctx.ma.NoTimeout = true
 }
@@ -0,0 +1,123 @@
+package zero
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+)
+
+var (
+	rawDataType = reflect.TypeOf(RawData(""))
+	gjsonType   = reflect.TypeOf(gjson.Result{})
+)
+
+// decodeFieldCache 缓存结构体字段对应的 json key
+var decodeFieldCache = sync.Map{}
+
+type decodeField struct {
+	index int
+	key   string
+}
+
+// decodeResult 将 gjson.Result 宽松地解码至 v
+//
+// 与 json.Unmarshal 不同, 数字与字符串可互相转换,
+// 以兼容不同 OneBot 实现返回的字段类型差异
+func decodeResult(v reflect.Value, r gjson.Result) {
+	switch v.Type() {
+	case gjsonType:
+		v.Set(reflect.ValueOf(r))
+		return
+	case rawDataType:
+		v.SetString(r.Raw)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !r.Exists() || r.Type == gjson.Null {
+			return
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		decodeResult(v.Elem(), r)
+	case reflect.Struct:
+		for _, f := range structDecodeFields(v.Type()) {
+			fv := v.Field(f.index)
+			if f.key == "" { // embedded 或 RawData
+				decodeResult(fv, r)
+				continue
+			}
+			decodeResult(fv, r.Get(f.key))
+		}
+	case reflect.Slice:
+		if !r.IsArray() {
+			return
+		}
+		arr := r.Array()
+		s := reflect.MakeSlice(v.Type(), len(arr), len(arr))
+		for i, item := range arr {
+			decodeResult(s.Index(i), item)
+		}
+		v.Set(s)
+	case reflect.String:
+		v.SetString(r.String())
+	case reflect.Bool:
+		v.SetBool(r.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(r.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(r.Uint())
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(r.Float())
+	}
+}
+
+func structDecodeFields(t reflect.Type) []decodeField {
+	if fs, ok := decodeFieldCache.Load(t); ok {
+		return fs.([]decodeField)
+	}
+	fs := make([]decodeField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous || f.Type == rawDataType {
+			fs = append(fs, decodeField{index: i})
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		key, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if key == "-" || key == "" {
+			continue
+		}
+		fs = append(fs, decodeField{index: i, key: key})
+	}
+	decodeFieldCache.Store(t, fs)
+	return fs
+}
+
+// decodeData 将 API 返回的 data 解码为 T
+func decodeData[T any](data gjson.Result, err error) (T, error) {
+	var v T
+	if err != nil {
+		return v, err
+	}
+	decodeResult(reflect.ValueOf(&v).Elem(), data)
+	return v, nil
+}
+
+// decodeList 将 API 返回的数组 data 解码为 []T
+func decodeList[T any](data gjson.Result, err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	arr := data.Array()
+	list := make([]T, len(arr))
+	for i, item := range arr {
+		decodeResult(reflect.ValueOf(&list[i]).Elem(), item)
+	}
+	return list, nil
+}
@@ -0,0 +1,163 @@
+package zero
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDialogTimeout 等待回答超时
+	ErrDialogTimeout = errors.New("dialog: timeout")
+	// ErrDialogCanceled 用户发送了取消关键词
+	ErrDialogCanceled = errors.New("dialog: canceled")
+	// ErrDialogInvalid 回答校验失败且已用尽重试次数
+	ErrDialogInvalid = errors.New("dialog: too many invalid answers")
+	// ErrDialogStep Next 返回了不存在的步骤
+	ErrDialogStep = errors.New("dialog: unknown step")
+)
+
+// DialogEnd 作为 DialogStep.Next 的返回值时结束对话
+const DialogEnd = "$end"
+
+// defaultDialogTimeout 每步的默认超时, 与 MustProvidePicture 相同
+const defaultDialogTimeout = 120 * time.Second
+
+// DialogStep 对话中的一步
+type DialogStep[T any] struct {
+	// Name 步骤名, 用于 Next 跳转
+	Name string
+	// Prompt 提问, 可为 string, message.Message 等 Ctx.Send 接受的类型,
+	// 或 func(result *T) interface{} 以根据已有回答生成, 为 nil 时不提问
+	Prompt interface{}
+	// Parse 校验回答并写入 result, 返回的 error 将发送给用户并重试
+	Parse func(ctx *Ctx, result *T) error
+	// Retries 校验失败时的重试次数, 小于 0 时不限
+	Retries int
+	// Timeout 等待回答的超时, 为 0 时使用 Dialog.Timeout
+	Timeout time.Duration
+	// Next 分支, 返回下一步的 Name, 为 nil 或返回 "" 时顺序执行, 返回 DialogEnd 时结束
+	Next func(result *T) string
+}
+
+// Dialog 多轮对话, 依次提问并将回答收集至 T
+//
+// 只接收与发起者同一会话 (CheckSession) 的消息, 对话期间这些消息不会触发其它 Matcher.
+// 对话可能超过事件的最大处理时间, 此时应在 Handler 中先调用 ctx.NoTimeout
+type Dialog[T any] struct {
+	Steps []DialogStep[T]
+	// Timeout 每步的默认超时, 为 0 时为 120s
+	Timeout time.Duration
+	// CancelWords 取消对话的关键词, 为 nil 时为 "取消"
+	CancelWords []string
+	// TimeoutMessage 超时时发送的消息, 为 nil 时不发送
+	TimeoutMessage interface{}
+	// CancelMessage 取消时发送的消息, 为 nil 时不发送
+	CancelMessage interface{}
+}
+
+// Run 在 ctx 的会话中进行对话, 返回收集的结果
+//
+// 超时, 取消, 回答无效或 ctx 被取消时返回对应的错误, 此时 result 为已收集的部分.
+// 返回时会移除监听会话的临时 Matcher
+func (d *Dialog[T]) Run(ctx *Ctx) (result T, err error) {
+	priority, block := 0, true
+	if ctx.ma != nil {
+		priority = ctx.ma.Priority - 1
+	}
+	recv, cancel := NewFutureEvent("message", priority, block, ctx.CheckSession()).Repeat()
+	defer cancel()
+	cancelWords := d.CancelWords
+	if cancelWords == nil {
+		cancelWords = []string{"取消"}
+	}
+	for i := 0; i < len(d.Steps); {
+		step := &d.Steps[i]
+		if err = d.ask(ctx, step, &result, recv, cancelWords); err != nil {
+			return
+		}
+		next := ""
+		if step.Next != nil {
+			next = step.Next(&result)
+		}
+		switch next {
+		case "":
+			i++
+		case DialogEnd:
+			return
+		default:
+			if i = d.index(next); i < 0 {
+				return result, ErrDialogStep
+			}
+		}
+	}
+	return
+}
+
+// index 名为 name 的步骤的下标
+func (d *Dialog[T]) index(name string) int {
+	for i := range d.Steps {
+		if d.Steps[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// ask 进行一步, 直到回答有效或出错
+func (d *Dialog[T]) ask(ctx *Ctx, step *DialogStep[T], result *T, recv <-chan *Ctx, cancelWords []string) error {
+	switch p := step.Prompt.(type) {
+	case nil:
+	case func(*T) interface{}:
+		ctx.Send(p(result))
+	default:
+		ctx.Send(p)
+	}
+	timeout := step.Timeout
+	if timeout == 0 {
+		timeout = d.Timeout
+	}
+	if timeout == 0 {
+		timeout = defaultDialogTimeout
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	for attempt := 0; ; attempt++ {
+		var ans *Ctx
+		select {
+		case ans = <-recv:
+		case <-t.C:
+			if d.TimeoutMessage != nil {
+				ctx.Send(d.TimeoutMessage)
+			}
+			return ErrDialogTimeout
+		case <-ctx.Context().Done():
+			return ctx.Context().Err()
+		}
+		text := strings.TrimSpace(ans.ExtractPlainText())
+		for _, w := range cancelWords {
+			if text == w {
+				if d.CancelMessage != nil {
+					ctx.Send(d.CancelMessage)
+				}
+				return ErrDialogCanceled
+			}
+		}
+		if step.Parse == nil {
+			return nil
+		}
+		perr := step.Parse(ans, result)
+		if perr == nil {
+			return nil
+		}
+		if step.Retries >= 0 && attempt >= step.Retries {
+			ctx.Send(perr.Error())
+			return ErrDialogInvalid
+		}
+		ctx.Send(perr.Error())
+		if !t.Stop() { // 每次重试重新计时
+			<-t.C
+		}
+		t.Reset(timeout)
+	}
+}
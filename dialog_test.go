@@ -0,0 +1,138 @@
+package zero_test
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+type signup struct {
+	Name string
+	Age  int
+	Job  string
+}
+
+func signupDialog(timeout time.Duration) *zero.Dialog[signup] {
+	return &zero.Dialog[signup]{
+		Timeout:        timeout,
+		TimeoutMessage: "timeout",
+		CancelMessage:  "canceled",
+		Steps: []zero.DialogStep[signup]{
+			{
+				Name:   "name",
+				Prompt: "name?",
+				Parse: func(ctx *zero.Ctx, r *signup) error {
+					r.Name = ctx.ExtractPlainText()
+					return nil
+				},
+			},
+			{
+				Name:    "age",
+				Prompt:  func(r *signup) interface{} { return r.Name + ", age?" },
+				Retries: 1,
+				Parse: func(ctx *zero.Ctx, r *signup) (err error) {
+					if r.Age, err = strconv.Atoi(ctx.ExtractPlainText()); err != nil {
+						return errors.New("not a number")
+					}
+					return nil
+				},
+				Next: func(r *signup) string {
+					if r.Age < 18 {
+						return zero.DialogEnd
+					}
+					return ""
+				},
+			},
+			{
+				Name:   "job",
+				Prompt: "job?",
+				Parse: func(ctx *zero.Ctx, r *signup) error {
+					r.Job = ctx.ExtractPlainText()
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// runSignup 注册 /signup 命令, 对话结束后发送结果或错误
+func runSignup(e *zero.Engine, timeout time.Duration) {
+	e.OnCommand("signup").Handle(func(ctx *zero.Ctx) {
+		r, err := signupDialog(timeout).Run(ctx)
+		if err != nil {
+			ctx.Send("error: " + err.Error())
+			return
+		}
+		ctx.Send(fmt.Sprintf("%s %d %s", r.Name, r.Age, r.Job))
+	})
+}
+
+func TestDialog(t *testing.T) {
+	e := zerotest.Engine(t)
+	runSignup(e, 0)
+	e.OnKeyword("hi").Handle(func(ctx *zero.Ctx) {
+		ctx.Send("hey")
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	done := bot.Go(bot.GroupEvent(1, 2, "/signup"))
+	bot.WaitSent(t, 1)
+	bot.GroupMessage(1, 3, "hi") // 其他用户的消息照常处理
+	bot.WaitSent(t, 2)
+	bot.GroupMessage(1, 2, "hi") // 对话中的消息不触发其它 Matcher
+	bot.WaitSent(t, 3)
+	bot.GroupMessage(1, 2, "old")
+	bot.WaitSent(t, 4)
+	bot.GroupMessage(1, 2, "30")
+	bot.WaitSent(t, 5)
+	bot.GroupMessage(1, 2, "tester")
+	<-done
+	bot.AssertSent(t, "name?", "hey", "hi, age?", "not a number", "job?", "hi 30 tester")
+
+	// 分支提前结束
+	bot.Reset()
+	done = bot.Go(bot.GroupEvent(1, 2, "/signup"))
+	bot.WaitSent(t, 1)
+	bot.GroupMessage(1, 2, "kid")
+	bot.WaitSent(t, 2)
+	bot.GroupMessage(1, 2, "12")
+	<-done
+	bot.AssertSent(t, "name?", "kid, age?", "kid 12 ")
+}
+
+func TestDialogErrors(t *testing.T) {
+	e := zerotest.Engine(t)
+	runSignup(e, 50*time.Millisecond)
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	// 取消
+	done := bot.Go(bot.GroupEvent(1, 2, "/signup"))
+	bot.WaitSent(t, 1)
+	bot.GroupMessage(1, 2, "取消")
+	<-done
+	bot.AssertSent(t, "name?", "canceled", "error: "+zero.ErrDialogCanceled.Error())
+
+	// 用尽重试次数
+	bot.Reset()
+	done = bot.Go(bot.GroupEvent(1, 2, "/signup"))
+	bot.WaitSent(t, 1)
+	bot.GroupMessage(1, 2, "bob")
+	bot.WaitSent(t, 2)
+	bot.GroupMessage(1, 2, "x")
+	bot.WaitSent(t, 3)
+	bot.GroupMessage(1, 2, "y")
+	<-done
+	bot.AssertSent(t, "name?", "bob, age?", "not a number", "not a number", "error: "+zero.ErrDialogInvalid.Error())
+
+	// 超时
+	bot.Reset()
+	bot.GroupMessage(1, 2, "/signup")
+	bot.AssertSent(t, "name?", "timeout", "error: "+zero.ErrDialogTimeout.Error())
+}
@@ -0,0 +1,131 @@
+package zero
+
+import "sort"
+
+// dispatchKind Matcher 的索引方式
+type dispatchKind uint8
+
+const (
+	dispatchPrefix    dispatchKind = iota + 1 // 首个文本消息段的前缀, PrefixRule
+	dispatchCommand                           // 去除 CommandPrefix 后的前缀, CommandRule
+	dispatchFullMatch                         // 完整消息, FullMatchRule
+)
+
+// dispatchKey 由 OnCommand / OnPrefix / OnFullMatch 等生成的 Matcher 的索引,
+// 与其第一个 Rule 对应, 只有可能满足该 Rule 的事件才会交给此 Matcher
+type dispatchKey struct {
+	kind dispatchKind
+	keys []string
+}
+
+// trieNode 按字节索引的前缀树
+type trieNode struct {
+	children map[byte]*trieNode
+	values   []int // 以此节点结尾的键对应的 Matcher 下标
+}
+
+func (n *trieNode) insert(key string, v int) {
+	for i := 0; i < len(key); i++ {
+		if n.children == nil {
+			n.children = make(map[byte]*trieNode)
+		}
+		c, ok := n.children[key[i]]
+		if !ok {
+			c = &trieNode{}
+			n.children[key[i]] = c
+		}
+		n = c
+	}
+	n.values = append(n.values, v)
+}
+
+// prefixes 将所有为 s 前缀的键对应的值追加至 dst
+func (n *trieNode) prefixes(s string, dst []int) []int {
+	dst = append(dst, n.values...)
+	for i := 0; i < len(s) && n.children != nil; i++ {
+		c, ok := n.children[s[i]]
+		if !ok {
+			break
+		}
+		n = c
+		dst = append(dst, n.values...)
+	}
+	return dst
+}
+
+// dispatchIndex 按优先级排序的 Matcher 列表及其索引
+type dispatchIndex struct {
+	matchers []*Matcher
+	always   []int      // 未索引的 Matcher 下标, 对每个事件都需判断
+	alwaysMs []*Matcher // always 对应的 Matcher
+	prefix   trieNode
+	command  trieNode
+	full     map[string][]int
+}
+
+// newDispatchIndex 为已排序的 matchers 建立索引, 需持有 matcherLock
+//
+// 索引以原始消息判断, 所属 Engine 的 preHandler 可能改写消息的 Matcher 不建立索引
+func newDispatchIndex(matchers []*Matcher) *dispatchIndex {
+	ix := &dispatchIndex{
+		matchers: matchers,
+		always:   make([]int, 0, len(matchers)),
+		full:     make(map[string][]int),
+	}
+	for i, m := range matchers {
+		if m.dispatch == nil || (m.Engine != nil && m.Engine.rewrite) {
+			ix.always = append(ix.always, i)
+			ix.alwaysMs = append(ix.alwaysMs, m)
+			continue
+		}
+		for _, key := range m.dispatch.keys {
+			switch m.dispatch.kind {
+			case dispatchPrefix:
+				ix.prefix.insert(key, i)
+			case dispatchCommand:
+				ix.command.insert(key, i)
+			case dispatchFullMatch:
+				ix.full[key] = append(ix.full[key], i)
+			}
+		}
+	}
+	return ix
+}
+
+// candidates 可能处理 ctx 的 Matcher, 保持优先级顺序
+func (ix *dispatchIndex) candidates(ctx *Ctx) []*Matcher {
+	var hits []int
+	if ctx.Event.PostType == "message" {
+		if msg := ctx.Event.Message; len(msg) > 0 && msg[0].Type == "text" {
+			text := msg[0].Data["text"]
+			hits = ix.prefix.prefixes(text, hits)
+			if p := BotConfig.CommandPrefix; len(text) >= len(p) && text[:len(p)] == p {
+				hits = ix.command.prefixes(text[len(p):], hits)
+			}
+		}
+		if len(ix.full) > 0 {
+			hits = append(hits, ix.full[ctx.MessageString()]...)
+		}
+	}
+	if len(hits) == 0 {
+		return ix.alwaysMs
+	}
+	sort.Ints(hits)
+	result := make([]*Matcher, 0, len(ix.always)+len(hits))
+	i, j := 0, 0
+	for i < len(ix.always) || j < len(hits) {
+		var p int
+		if j >= len(hits) || (i < len(ix.always) && ix.always[i] < hits[j]) {
+			p = ix.always[i]
+			i++
+		} else {
+			p = hits[j]
+			j++
+			for j < len(hits) && hits[j] == p { // 多个键命中同一 Matcher
+				j++
+			}
+		}
+		result = append(result, ix.matchers[p])
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package zero
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dispatchRegistrations 注册命令的不同方式
+var dispatchRegistrations = []struct {
+	name     string
+	register func(e *Engine, cmd string) *Matcher
+}{
+	// 未声明为不阻塞, 每个 Rule 都在新协程中判断
+	{"blocking", func(e *Engine, cmd string) *Matcher {
+		return e.OnMessage(CommandRule(cmd))
+	}},
+	// Rule 在分发协程中直接判断, 但需逐个判断所有 Matcher
+	{"linear", func(e *Engine, cmd string) *Matcher {
+		return e.OnMessage(CommandRule(cmd)).SetNonBlocking(true)
+	}},
+	// 按前缀树索引, 只判断可能命中的 Matcher
+	{"indexed", func(e *Engine, cmd string) *Matcher {
+		return e.OnCommand(cmd)
+	}},
+}
+
+// BenchmarkDispatch 注册 500 个命令时分发命中与未命中事件的耗时
+//
+//	go test -run '^$' -bench Dispatch -benchmem
+func BenchmarkDispatch(b *testing.B) {
+	const n = 500
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.WarnLevel)
+	defer logrus.SetLevel(level)
+	prefix := BotConfig.CommandPrefix
+	BotConfig.CommandPrefix = "/"
+	defer func() { BotConfig.CommandPrefix = prefix }()
+
+	events := []struct {
+		name   string
+		events [][]byte
+	}{
+		{"hit", [][]byte{groupMessage("/cmd0"), groupMessage("/cmd" + strconv.Itoa(n/2)), groupMessage("/cmd" + strconv.Itoa(n-1))}},
+		{"miss", [][]byte{groupMessage("hello"), groupMessage("/unknown")}},
+	}
+	for _, reg := range dispatchRegistrations {
+		e := New()
+		for i := 0; i < n; i++ {
+			reg.register(e, "cmd"+strconv.Itoa(i)).Handle(func(*Ctx) {})
+		}
+		for _, ev := range events {
+			b.Run(reg.name+"/"+ev.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					Dispatch(ev.events[i%len(ev.events)], nopCaller{})
+				}
+			})
+		}
+		e.Delete()
+	}
+}
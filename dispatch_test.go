@@ -0,0 +1,116 @@
+package zero
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// nopCaller 不做任何事的 APICaller
+type nopCaller struct{}
+
+func (nopCaller) CallAPI(_ APIRequest) (APIResponse, error) {
+	return APIResponse{}, nil
+}
+
+// groupMessage 群消息事件
+func groupMessage(text string) []byte {
+	return []byte(`{"post_type":"message","message_type":"group","sub_type":"normal","time":1700000000,` +
+		`"self_id":1,"user_id":2,"group_id":3,"message_id":4,"font":0,` +
+		`"message":[{"type":"text","data":{"text":` + strconv.Quote(text) + `}}],` +
+		`"raw_message":` + strconv.Quote(text) + `,"sender":{"user_id":2,"nickname":"test"}}`)
+}
+
+// passes m 的 Type 与第一个 Rule 是否满足, 即索引需要保证的条件
+func passes(ctx *Ctx, m *Matcher) bool {
+	for k := range ctx.State {
+		delete(ctx.State, k)
+	}
+	return m.Type(ctx) && (len(m.Rules) == 0 || m.Rules[0](ctx))
+}
+
+func TestDispatchIndexOrder(t *testing.T) {
+	prefix := BotConfig.CommandPrefix
+	BotConfig.CommandPrefix = "/"
+	defer func() { BotConfig.CommandPrefix = prefix }()
+
+	e := New()
+	defer e.Delete()
+	e.OnCommand("ping").SetPriority(5)
+	e.OnCommand("pi").SetPriority(1)
+	e.OnCommandGroup([]string{"pong", "ping"}).SetPriority(3)
+	e.OnPrefix("/p").SetPriority(4)
+	e.OnPrefixGroup([]string{"he", "/pi"}).SetPriority(2)
+	e.OnFullMatch("/ping").SetPriority(6)
+	e.OnFullMatchGroup([]string{"hello", "/ping"}).SetPriority(0)
+	e.OnMessage().SetPriority(3)
+	e.OnKeyword("ing").SetPriority(1)
+	e.OnNotice().SetPriority(2)
+
+	matchers := make([]*Matcher, len(e.matchers))
+	copy(matchers, e.matchers)
+	sort.SliceStable(matchers, func(i, j int) bool { return matchers[i].Priority < matchers[j].Priority })
+	ix := newDispatchIndex(matchers)
+
+	for _, text := range []string{"/ping", "/ping x", "/pi", "/pong", "/p", "hello", "help", "ping", "", "/unknown"} {
+		ctx, _, ok := newEventCtx(groupMessage(text), nopCaller{})
+		if !ok {
+			t.Fatal("newEventCtx failed")
+		}
+		var linear, indexed []*Matcher
+		for _, m := range matchers {
+			if passes(ctx, m) {
+				linear = append(linear, m)
+			}
+		}
+		for _, m := range ix.candidates(ctx) {
+			if passes(ctx, m) {
+				indexed = append(indexed, m)
+			}
+		}
+		running.release(ctx)
+		if !reflect.DeepEqual(linear, indexed) {
+			t.Errorf("%q: indexed order differs from linear scan:\nlinear  %v\nindexed %v", text, priorities(linear), priorities(indexed))
+		}
+	}
+}
+
+func priorities(ms []*Matcher) []int {
+	p := make([]int, len(ms))
+	for i, m := range ms {
+		p[i] = m.Priority
+	}
+	return p
+}
+
+func TestDispatchPreHandlerRewrite(t *testing.T) {
+	prefix := BotConfig.CommandPrefix
+	BotConfig.CommandPrefix = "/"
+	defer func() { BotConfig.CommandPrefix = prefix }()
+
+	e := New()
+	defer e.Delete()
+	var got []string
+	e.OnCommand("ping").Handle(func(ctx *Ctx) {
+		got = append(got, ctx.Event.Message[0].Data["text"])
+	})
+	Dispatch(groupMessage("bot /ping"), nopCaller{})
+	if len(got) != 0 {
+		t.Fatalf("command matched without preHandler: %q", got)
+	}
+
+	// 索引已建立后添加 preHandler, 去除消息的称呼前缀
+	e.UsePreHandler(func(ctx *Ctx) bool {
+		if msg := ctx.Event.Message; len(msg) > 0 && msg[0].Type == "text" {
+			msg[0].Data["text"] = strings.TrimPrefix(msg[0].Data["text"], "bot ")
+		}
+		return true
+	})
+	Dispatch(groupMessage("bot /ping"), nopCaller{})
+	Dispatch(groupMessage("/ping"), nopCaller{})
+	if !reflect.DeepEqual(got, []string{"/ping", "/ping"}) {
+		t.Fatalf("command got %q after preHandler rewrite, want 2 pings", got)
+	}
+}
@@ -0,0 +1,83 @@
+package driver
+
+import (
+	"errors"
+
+	"github.com/RomiChan/websocket"
+	log "github.com/cubevlmu/CZeroBot/log"
+	"github.com/tidwall/gjson"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/utils/helper"
+)
+
+// handshakeEcho 握手时 get_status 使用的 echo, 正常调用的 echo 从 1 开始
+const handshakeEcho = 0
+
+// oneBot12UnsupportedAction OneBot 12 中不支持的动作的 retcode
+const oneBot12UnsupportedAction = 10002
+
+// maxHandshakeMessages 等待 get_status 响应时最多读取的消息数
+const maxHandshakeMessages = 64
+
+var errNoOnlineBot = errors.New("no online bot in get_status response")
+
+// handshake 读取连接后的首个事件, 获取账号与协议版本
+//
+// OneBot 11 首个事件为带有 self_id 的 lifecycle 元事件,
+// OneBot 12 为 connect 元事件, 之后通过 get_status 获取第一个在线的账号
+func handshake(conn *websocket.Conn, protocol zero.Protocol) (selfID int64, proto zero.Protocol, err error) {
+	_, payload, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+	first := gjson.Parse(helper.BytesToString(payload))
+	proto = protocol
+	if proto == zero.ProtocolAuto {
+		proto = zero.OneBot11
+		if first.Get("type").Str == "meta" && first.Get("detail_type").Str == "connect" {
+			proto = zero.OneBot12
+		}
+	}
+	if proto == zero.OneBot11 {
+		selfID = first.Get("self_id").Int()
+		return
+	}
+	log.Debugf("[driver] onebot 12 connected: %s", first.Get("version").Raw)
+	err = conn.WriteJSON(&zero.APIRequest{Action: "get_status", Params: zero.Params{}, Echo: handshakeEcho})
+	if err != nil {
+		return
+	}
+	for i := 0; i < maxHandshakeMessages; i++ {
+		_, payload, err = conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		rsp := gjson.Parse(helper.BytesToString(payload))
+		if !rsp.Get("echo").Exists() { // 握手完成前的事件将被丢弃
+			continue
+		}
+		if rsp.Get("retcode").Int() != 0 {
+			err = errors.New("get_status failed: " + rsp.Get("message").Str)
+			return
+		}
+		for _, bot := range rsp.Get("data.bots").Array() {
+			if bot.Get("online").Bool() {
+				selfID = zero.OneBot12ID(bot.Get("self.user_id").String())
+				return
+			}
+		}
+		err = errNoOnlineBot
+		return
+	}
+	err = errors.New("no get_status response in handshake")
+	return
+}
+
+// wrapCaller 根据协议版本包装 caller
+func wrapCaller(c zero.APICaller, proto zero.Protocol) zero.APICaller {
+	if proto == zero.OneBot12 {
+		return zero.NewOneBot12Caller(c)
+	}
+	return c
+}
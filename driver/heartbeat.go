@@ -0,0 +1,52 @@
+package driver
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/RomiChan/websocket"
+	"github.com/tidwall/gjson"
+)
+
+// HeartbeatPolicy 心跳检测策略
+//
+// 收到 OneBot 实现的心跳元事件后按其 interval 设置读超时,
+// 连续 MaxMissed 个间隔内没有收到任何数据即认为连接已断开
+type HeartbeatPolicy struct {
+	MaxMissed int  // 连续错过多少次心跳后断开连接 (默认 3, 小于 0 时关闭检测)
+	Deliver   bool // 是否将心跳事件交给 handler, 以便 OnMetaEvent 处理
+}
+
+// timeout 心跳间隔为 interval 时的读超时
+func (p *HeartbeatPolicy) timeout(interval time.Duration) time.Duration {
+	n := p.MaxMissed
+	if n < 0 || interval <= 0 {
+		return 0
+	}
+	if n == 0 {
+		n = 3
+	}
+	return interval * time.Duration(n)
+}
+
+// received 收到数据后刷新读超时, interval 为该连接当前的心跳间隔
+//
+// 返回是否为心跳事件
+func (p *HeartbeatPolicy) received(conn *websocket.Conn, interval *time.Duration, rsp gjson.Result) bool {
+	isHeartbeat := rsp.Get("meta_event_type").Str == "heartbeat" ||
+		(rsp.Get("type").Str == "meta" && rsp.Get("detail_type").Str == "heartbeat") // OneBot 12
+	if isHeartbeat {
+		*interval = time.Duration(rsp.Get("interval").Int()) * time.Millisecond
+	}
+	if t := p.timeout(*interval); t > 0 {
+		_ = conn.SetReadDeadline(time.Now().Add(t))
+	}
+	return isHeartbeat
+}
+
+// isTimeout 是否为心跳超时导致的读取失败
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
@@ -0,0 +1,99 @@
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RomiChan/websocket"
+	"github.com/tidwall/gjson"
+
+	zero "github.com/cubevlmu/CZeroBot"
+)
+
+func TestHeartbeatTimeout(t *testing.T) {
+	for _, c := range []struct {
+		maxMissed int
+		interval  time.Duration
+		want      time.Duration
+	}{
+		{0, 5 * time.Second, 15 * time.Second}, // 默认 3 次
+		{2, 5 * time.Second, 10 * time.Second},
+		{-1, 5 * time.Second, 0}, // 关闭检测
+		{3, 0, 0},                // 尚未收到心跳
+	} {
+		p := HeartbeatPolicy{MaxMissed: c.maxMissed}
+		if got := p.timeout(c.interval); got != c.want {
+			t.Errorf("MaxMissed %d: timeout(%v) = %v, want %v", c.maxMissed, c.interval, got, c.want)
+		}
+	}
+}
+
+func TestHeartbeatReceived(t *testing.T) {
+	srv := newTestServer(t, 10001)
+	ws := NewWebSocketClient(srv.url(), "")
+	ws.Connect()
+	defer ws.Close()
+	conn := srv.accept(t)
+
+	var p HeartbeatPolicy
+	var interval time.Duration
+	for _, c := range []struct {
+		event     string
+		heartbeat bool
+		interval  time.Duration
+	}{
+		{`{"post_type":"message"}`, false, 0},
+		{`{"post_type":"meta_event","meta_event_type":"heartbeat","interval":5000}`, true, 5 * time.Second},
+		{`{"type":"meta","detail_type":"heartbeat","interval":3000}`, true, 3 * time.Second}, // OneBot 12
+		{`{"post_type":"message"}`, false, 3 * time.Second},                                  // 保持上次的间隔
+	} {
+		if got := p.received(conn, &interval, gjson.Parse(c.event)); got != c.heartbeat {
+			t.Errorf("%s: heartbeat = %v", c.event, got)
+		}
+		if interval != c.interval {
+			t.Errorf("%s: interval %v, want %v", c.event, interval, c.interval)
+		}
+	}
+}
+
+func TestHeartbeatDeadConnection(t *testing.T) {
+	srv := newTestServer(t, 10001)
+	ws := NewWebSocketClient(srv.url(), "")
+	ws.Reconnect = ReconnectPolicy{InitialDelay: time.Millisecond, Jitter: -1}
+	ws.Heartbeat = HeartbeatPolicy{MaxMissed: 2, Deliver: true}
+	events := make(chan zero.ConnectionEvent, 16)
+	ws.Subscribe(func(ev zero.ConnectionEvent) { events <- ev })
+	ws.Connect()
+	defer ws.Close()
+	conn := srv.accept(t)
+	nextEvent(t, events)
+
+	heartbeats := make(chan struct{}, 16)
+	go ws.Listen(func(b []byte, _ zero.APICaller) {
+		if gjson.GetBytes(b, "meta_event_type").Str == "heartbeat" {
+			heartbeats <- struct{}{}
+		}
+	})
+
+	// 心跳间隔 20ms, 之后保持连接但不再发送任何数据
+	start := time.Now()
+	if err := conn.WriteMessage(websocket.TextMessage,
+		[]byte(`{"time":0,"self_id":10001,"post_type":"meta_event","meta_event_type":"heartbeat","interval":20}`)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-heartbeats:
+	case <-time.After(5 * time.Second):
+		t.Fatal("heartbeat not delivered with Deliver set")
+	}
+	if ev := nextEvent(t, events); ev.Connected {
+		t.Fatalf("event after missed heartbeats %+v", ev)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Fatalf("connection considered dead after %v, before 2 missed heartbeats", d)
+	}
+	srv.accept(t) // 断开后重连
+	if ev := nextEvent(t, events); !ev.Connected {
+		t.Fatalf("event after reconnect %+v", ev)
+	}
+}
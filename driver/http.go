@@ -2,6 +2,7 @@ package driver
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha1"
 	"encoding/hex"
@@ -12,6 +13,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	log "github.com/cubevlmu/CZeroBot/log"
@@ -24,25 +26,85 @@ import (
 type HTTP struct {
 	URL         string
 	AccessToken string
-	lst         net.Listener
+	Reconnect   ReconnectPolicy // 握手失败时的重试策略
+	Protocol    zero.Protocol   // OneBot 协议版本 (默认自动检测)
 	caller      *HTTPCaller
+	api         zero.APICaller // 按协议包装后的 caller
+
+	mu     sync.Mutex
+	lst    net.Listener
+	server *http.Server
+	done   chan struct{} // Close 后关闭
+
+	lifecycle
 }
 
 func (h *HTTP) Connect() {
+	h.setState(zero.DriverConnecting)
+	h.mu.Lock()
+	if h.done == nil {
+		h.done = make(chan struct{})
+	}
+	done := h.done
+	h.mu.Unlock()
+	for attempt := 1; ; attempt++ {
+		err := h.handshake()
+		if err == nil {
+			zero.StoreCaller(h.caller.selfID, h.apiCaller()) // 添加Caller到 APICaller list...
+			h.setState(zero.DriverConnected)
+			log.With(log.SelfID(h.caller.selfID)).Infof("[httpcaller] 与服务器 %s 握手成功, 账号: %d", h.caller.URL, h.caller.selfID)
+			h.emit(h.caller.selfID, true, h.apiCaller())
+			return
+		}
+		if h.Reconnect.exhausted(attempt) {
+			log.Errorf("[httpcaller] 与服务器 %s 握手失败 %d 次, 放弃握手", h.caller.URL, attempt)
+			h.Reconnect.giveUp(err)
+			return
+		}
+		d := h.Reconnect.Delay(attempt)
+		log.Infof("[httpcaller] 将在 %v 后重新与服务器握手: %s", d, h.caller.URL)
+		if !sleep(done, d) {
+			return
+		}
+	}
+}
+
+// handshake 调用 get_login_info 获取账号
+//
+// 自动检测协议时, 如果服务器不支持 get_login_info 则尝试 OneBot 12 的 get_self_info
+func (h *HTTP) handshake() error {
 	log.Infof("[httpcaller] 正在尝试与服务器握手: %s", h.caller.URL)
-	rsp, err := h.caller.CallAPI(zero.APIRequest{Action: "get_login_info", Params: nil})
+	proto := h.Protocol
+	if proto == zero.ProtocolAuto {
+		proto = zero.OneBot11
+	}
+	api := wrapCaller(h.caller, proto)
+	rsp, err := api.CallAPI(zero.APIRequest{Action: "get_login_info", Params: zero.Params{}})
+	if err == nil && rsp.RetCode == oneBot12UnsupportedAction && h.Protocol == zero.ProtocolAuto {
+		proto = zero.OneBot12
+		api = wrapCaller(h.caller, proto)
+		rsp, err = api.CallAPI(zero.APIRequest{Action: "get_login_info", Params: zero.Params{}})
+	}
 	if err != nil {
 		log.Warningf("[httpcaller] 与服务器握手失败: %s\n%v", h.caller.URL, err)
-		return
+		return err
 	}
-	if rsp.RetCode == 0 {
-		h.caller.selfID = rsp.Data.Get("user_id").Int()
-		zero.APICallers.Store(h.caller.selfID, h.caller) // 添加Caller到 APICaller list...
-		log.Infof("[httpcaller] 与服务器 %s 握手成功, 账号: %d", h.caller.URL, h.caller.selfID)
-	} else {
+	if rsp.RetCode != 0 {
 		log.Warningf("[httpcaller] 与服务器握手失败: %s", h.caller.URL)
 		log.Warningf("[httpcaller] status:%s, retcode:%d, msg:%s, wording:%s", rsp.Status, rsp.RetCode, rsp.Message, rsp.Wording)
+		return fmt.Errorf("handshake returned retcode %d: %s", rsp.RetCode, rsp.Message)
+	}
+	h.caller.selfID = rsp.Data.Get("user_id").Int()
+	h.api = api
+	return nil
+}
+
+// apiCaller 按协议包装后的 caller, 握手前为 HTTPCaller
+func (h *HTTP) apiCaller() zero.APICaller {
+	if h.api == nil {
+		return h.caller
 	}
+	return h.api
 }
 
 type HTTPCaller struct {
@@ -68,11 +130,17 @@ func (h *HTTP) listen() {
 	}
 
 	listener, err := net.Listen(network, address)
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	if err != nil {
 		log.Warningf("[httpsever] server failed to listen at port: %v", err)
 		h.lst = nil
 		return
 	}
+	if h.done == nil { // 已被 Close
+		_ = listener.Close()
+		return
+	}
 
 	h.lst = listener
 	log.Infof("[httpsever] server listening at port %v", listener.Addr())
@@ -114,7 +182,7 @@ func (h *HTTP) any(w http.ResponseWriter, r *http.Request, apiHandler func([]byt
 		}
 	}
 
-	apiHandler(content, h.caller)
+	apiHandler(content, h.apiCaller())
 }
 
 // Listen 监听 HTTP 请求
@@ -127,29 +195,69 @@ func (h *HTTP) Listen(handler func([]byte, zero.APICaller)) {
 	server := &http.Server{
 		Handler: mux,
 	}
+	h.mu.Lock()
+	done := h.done
+	h.server = server
+	h.mu.Unlock()
+	if done == nil { // 未 Connect 或已被 Close
+		return
+	}
 
 	for {
-		if h.lst == nil {
-			time.Sleep(2 * time.Second)
+		h.mu.Lock()
+		lst := h.lst
+		h.mu.Unlock()
+		if lst == nil {
+			if !sleep(done, 2*time.Second) {
+				log.Info("[httpserver] server closed")
+				return
+			}
 			h.listen()
 			continue
 		}
-		log.Infof("[httpserver] server start handling at : %v", h.lst.Addr())
-		err := server.Serve(h.lst)
+		log.Infof("[httpserver] server start handling at : %v", lst.Addr())
+		err := server.Serve(lst)
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Warningf("[httpserver] 服务器在端点 %s 失败: %s", h.lst.Addr(), err)
+			log.Warningf("[httpserver] 服务器在端点 %s 失败: %s", lst.Addr(), err)
+		}
+		h.mu.Lock()
+		if h.lst == lst { // 已随 server 关闭或失败
 			h.lst = nil
-		} else if errors.Is(err, http.ErrServerClosed) {
+		}
+		h.mu.Unlock()
+		if errors.Is(err, http.ErrServerClosed) {
 			log.Info("[httpserver] server closed")
 			return
 		}
 	}
 }
 
+// Close 关闭 HTTP 服务器, 之后 Listen 返回
+func (h *HTTP) Close() error {
+	h.mu.Lock()
+	if h.done != nil {
+		close(h.done)
+		h.done = nil
+	}
+	old := h.setState(zero.DriverClosed)
+	zero.APICallers.Delete(h.caller.selfID)
+	api := h.apiCaller()
+	var err error
+	if h.server != nil {
+		err = h.server.Close()
+		h.server = nil
+	}
+	h.mu.Unlock()
+	if old == zero.DriverConnected {
+		h.emit(h.caller.selfID, false, api)
+	}
+	return err
+}
+
 // httpCaller 对 api 进行调用
 // 不关闭body会导致资源泄漏!
-func (c *HTTPCaller) httpCaller(action string, payload []byte) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodPost, c.URL+"/"+action, bytes.NewBuffer(payload))
+func (c *HTTPCaller) httpCaller(ctx context.Context, action string, payload []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/"+action, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -169,14 +277,25 @@ func (c *HTTPCaller) httpCaller(action string, payload []byte) (*http.Response,
 	return resp, nil
 }
 
+// CallAPI 发送 http 请求
 func (c *HTTPCaller) CallAPI(request zero.APIRequest) (zero.APIResponse, error) {
+	return c.CallAPIContext(context.Background(), request)
+}
+
+// CallAPIContext 发送 http 请求, ctx 取消时中止请求
+func (c *HTTPCaller) CallAPIContext(ctx context.Context, request zero.APIRequest) (rsp zero.APIResponse, err error) {
+	ctx, span := startAPISpan(ctx, request.Action)
+	defer func() { endAPISpan(span, c.selfID, request.Echo, rsp, err) }()
 	p, err := json.Marshal(request.Params)
 	if err != nil {
 		return nullResponse, err
 	}
 
-	resp, err := c.httpCaller(request.Action, p)
+	resp, err := c.httpCaller(ctx, request.Action, p)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nullResponse, ctx.Err()
+		}
 		return nullResponse, err
 	}
 
@@ -190,17 +309,17 @@ func (c *HTTPCaller) CallAPI(request zero.APIRequest) (zero.APIResponse, error)
 	if resp.StatusCode != http.StatusOK {
 		return zero.APIResponse{Status: payload, RetCode: int64(1000 + resp.StatusCode)}, fmt.Errorf("caller returned invalid data : %d", resp.StatusCode)
 	}
-	rsp := gjson.Parse(payload)
-	msg := rsp.Get("message").Str
-	if msg != "" {
-		msg = rsp.Get("msg").Str
+	result := gjson.Parse(payload)
+	msg := result.Get("message").Str
+	if msg == "" {
+		msg = result.Get("msg").Str
 	}
 	return zero.APIResponse{
-		Status:  rsp.Get("status").Str,
-		Data:    rsp.Get("data"),
+		Status:  result.Get("status").Str,
+		Data:    result.Get("data"),
 		Message: msg,
-		Wording: rsp.Get("wording").Str,
-		RetCode: rsp.Get("retcode").Int(),
-		Echo:    rsp.Get("echo").Uint(),
+		Wording: result.Get("wording").Str,
+		RetCode: result.Get("retcode").Int(),
+		Echo:    result.Get("echo").Uint(),
 	}, nil
 }
@@ -0,0 +1,106 @@
+package driver
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+)
+
+func TestHTTPCallerAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/bad":
+			_, _ = w.Write([]byte(`{"status":"failed","retcode":100,"message":"bad group_id"}`))
+		case "/denied":
+			_, _ = w.Write([]byte(`{"status":"failed","retcode":102,"msg":"no permission"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	zero.APICallers.Store(8, &HTTPCaller{URL: srv.URL, selfID: 8})
+	defer zero.APICallers.Delete(8)
+	api := zero.GetBot(8).API()
+
+	for _, c := range []struct {
+		action  string
+		want    error
+		message string
+		status  int // 非 0 时为 HTTP 状态码错误
+	}{
+		{"limited", zero.ErrRateLimited, "", http.StatusTooManyRequests},
+		{"missing", zero.ErrActionNotFound, "", http.StatusNotFound},
+		{"bad", zero.ErrBadParams, "bad group_id", 0},
+		{"denied", zero.ErrPermissionDenied, "no permission", 0},
+	} {
+		_, err := api.Call(c.action, nil)
+		var apiErr *zero.APIError
+		if !errors.As(err, &apiErr) || !errors.Is(err, c.want) {
+			t.Errorf("%s: err %v, want *APIError matching %v", c.action, err, c.want)
+			continue
+		}
+		if apiErr.Message != c.message {
+			t.Errorf("%s: message %q, want %q", c.action, apiErr.Message, c.message)
+		}
+		if (c.status != 0) != (errors.Unwrap(err) != nil) || (c.status != 0 && apiErr.RetCode != int64(1000+c.status)) {
+			t.Errorf("%s: retcode %d, transport error %v", c.action, apiErr.RetCode, errors.Unwrap(err))
+		}
+	}
+}
+
+func TestHTTPListenClose(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"ok","retcode":0,"data":{"user_id":9,"nickname":"bot"}}`))
+	}))
+	defer api.Close()
+	h := NewHTTPClient("http://127.0.0.1:0", "", api.URL, "")
+	h.Connect()
+	events := make(chan []byte, 1)
+	listened := make(chan struct{})
+	go func() {
+		defer close(listened)
+		h.Listen(func(b []byte, _ zero.APICaller) { events <- b })
+	}()
+
+	var addr string
+	for deadline := time.Now().Add(5 * time.Second); addr == "" && time.Now().Before(deadline); {
+		h.mu.Lock()
+		if h.lst != nil {
+			addr = h.lst.Addr().String()
+		}
+		h.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if addr == "" {
+		t.Fatal("server not listening")
+	}
+	rsp, err := http.Post("http://"+addr, "application/json", strings.NewReader(`{"post_type":"meta_event"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rsp.Body.Close()
+	if b := <-events; string(b) != `{"post_type":"meta_event"}` {
+		t.Fatalf("event %s", b)
+	}
+
+	if err = h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-listened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after Close")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.lst != nil {
+		t.Fatal("listener kept after Close")
+	}
+}
@@ -0,0 +1,69 @@
+package driver
+
+import (
+	"sync"
+	"sync/atomic"
+
+	zero "github.com/cubevlmu/CZeroBot"
+)
+
+// lifecycle 实现 zero.LifecycleDriver 中的状态与订阅
+type lifecycle struct {
+	state  int32
+	submu  sync.Mutex
+	subs   map[uint64]func(zero.ConnectionEvent)
+	subseq uint64
+}
+
+// State 当前连接状态
+func (l *lifecycle) State() zero.DriverState {
+	return zero.DriverState(atomic.LoadInt32(&l.state))
+}
+
+// setState 设置状态并返回旧状态
+func (l *lifecycle) setState(s zero.DriverState) zero.DriverState {
+	return zero.DriverState(atomic.SwapInt32(&l.state, int32(s)))
+}
+
+// compareAndSetState 仅在当前状态为 old 时设置为 s
+func (l *lifecycle) compareAndSetState(old, s zero.DriverState) bool {
+	return atomic.CompareAndSwapInt32(&l.state, int32(old), int32(s))
+}
+
+// Subscribe 订阅连接事件, 返回取消订阅的函数
+func (l *lifecycle) Subscribe(f func(zero.ConnectionEvent)) (cancel func()) {
+	l.submu.Lock()
+	defer l.submu.Unlock()
+	if l.subs == nil {
+		l.subs = make(map[uint64]func(zero.ConnectionEvent))
+	}
+	l.subseq++
+	id := l.subseq
+	l.subs[id] = f
+	return func() {
+		l.submu.Lock()
+		defer l.submu.Unlock()
+		delete(l.subs, id)
+	}
+}
+
+// emit 通知所有订阅者
+func (l *lifecycle) emit(selfID int64, connected bool, caller zero.APICaller) {
+	l.submu.Lock()
+	subs := make([]func(zero.ConnectionEvent), 0, len(l.subs))
+	for _, f := range l.subs {
+		subs = append(subs, f)
+	}
+	l.submu.Unlock()
+	ev := zero.ConnectionEvent{SelfID: selfID, Connected: connected, Caller: caller}
+	for _, f := range subs {
+		f(ev)
+	}
+}
+
+var (
+	_ zero.LifecycleDriver = (*WSClient)(nil)
+	_ zero.LifecycleDriver = (*WSServer)(nil)
+	_ zero.LifecycleDriver = (*HTTP)(nil)
+	_ zero.LifecycleDriver = (*Replay)(nil)
+)
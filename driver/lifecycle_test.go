@@ -0,0 +1,155 @@
+package driver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RomiChan/websocket"
+
+	zero "github.com/cubevlmu/CZeroBot"
+)
+
+// testServer 测试用的 OneBot 11 正向 websocket 服务端
+//
+// 每个连接建立后发送 lifecycle 元事件, 并通过 conns 交给测试
+type testServer struct {
+	*httptest.Server
+	selfID int64
+	conns  chan *websocket.Conn
+}
+
+func newTestServer(t *testing.T, selfID int64) *testServer {
+	s := &testServer{selfID: selfID, conns: make(chan *websocket.Conn, 16)}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"time":0,"self_id":`+
+			strconv.FormatInt(selfID, 10)+`,"post_type":"meta_event","meta_event_type":"lifecycle","sub_type":"connect"}`))
+		s.conns <- conn
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// url 连接地址
+func (s *testServer) url() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// accept 等待下一个连接
+func (s *testServer) accept(t *testing.T) *websocket.Conn {
+	t.Helper()
+	select {
+	case conn := <-s.conns:
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("no connection from client")
+		return nil
+	}
+}
+
+// nextEvent 等待下一个连接事件
+func nextEvent(t *testing.T, events <-chan zero.ConnectionEvent) zero.ConnectionEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no connection event")
+		return zero.ConnectionEvent{}
+	}
+}
+
+func TestLifecycleSubscribe(t *testing.T) {
+	var l lifecycle
+	if s := l.State(); s != zero.DriverClosed {
+		t.Fatalf("initial state %v", s)
+	}
+	if old := l.setState(zero.DriverConnecting); old != zero.DriverClosed {
+		t.Fatalf("setState returned %v", old)
+	}
+	if l.compareAndSetState(zero.DriverConnected, zero.DriverClosed) {
+		t.Fatal("compareAndSetState succeeded with a wrong old state")
+	}
+	if !l.compareAndSetState(zero.DriverConnecting, zero.DriverConnected) || l.State() != zero.DriverConnected {
+		t.Fatalf("compareAndSetState failed, state %v", l.State())
+	}
+
+	var a, b []zero.ConnectionEvent
+	cancelA := l.Subscribe(func(ev zero.ConnectionEvent) { a = append(a, ev) })
+	l.Subscribe(func(ev zero.ConnectionEvent) { b = append(b, ev) })
+	l.emit(1, true, nil)
+	cancelA()
+	l.emit(1, false, nil)
+	if len(a) != 1 || !a[0].Connected || a[0].SelfID != 1 {
+		t.Fatalf("canceled subscriber got %+v", a)
+	}
+	if len(b) != 2 || !b[0].Connected || b[1].Connected {
+		t.Fatalf("subscriber got %+v", b)
+	}
+}
+
+func TestWSClientLifecycle(t *testing.T) {
+	srv := newTestServer(t, 10001)
+	ws := NewWebSocketClient(srv.url(), "")
+	ws.Reconnect = ReconnectPolicy{InitialDelay: time.Millisecond, Jitter: -1}
+	events := make(chan zero.ConnectionEvent, 16)
+	ws.Subscribe(func(ev zero.ConnectionEvent) { events <- ev })
+
+	ws.Connect()
+	conn := srv.accept(t)
+	if s := ws.State(); s != zero.DriverConnected {
+		t.Fatalf("state after Connect %v", s)
+	}
+	if ev := nextEvent(t, events); !ev.Connected || ev.SelfID != 10001 || ev.Caller == nil {
+		t.Fatalf("connect event %+v", ev)
+	}
+	if _, ok := zero.APICallers.Load(10001); !ok {
+		t.Fatal("caller not stored after connect")
+	}
+
+	listened := make(chan struct{})
+	go func() {
+		defer close(listened)
+		ws.Listen(func([]byte, zero.APICaller) {})
+	}()
+
+	// 服务端断开后重连
+	_ = conn.Close()
+	if ev := nextEvent(t, events); ev.Connected {
+		t.Fatalf("event after server closed %+v", ev)
+	}
+	srv.accept(t)
+	if ev := nextEvent(t, events); !ev.Connected {
+		t.Fatalf("event after reconnect %+v", ev)
+	}
+	if s := ws.State(); s != zero.DriverConnected {
+		t.Fatalf("state after reconnect %v", s)
+	}
+
+	if err := ws.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextEvent(t, events); ev.Connected {
+		t.Fatalf("event after Close %+v", ev)
+	}
+	if s := ws.State(); s != zero.DriverClosed {
+		t.Fatalf("state after Close %v", s)
+	}
+	if _, ok := zero.APICallers.Load(10001); ok {
+		t.Fatal("caller not removed after Close")
+	}
+	select {
+	case <-listened:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after Close")
+	}
+}
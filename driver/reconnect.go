@@ -0,0 +1,59 @@
+package driver
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ReconnectPolicy 连接失败后的重试策略, 零值字段使用默认值
+type ReconnectPolicy struct {
+	InitialDelay time.Duration   // 首次失败后的等待时间 (默认 2s)
+	MaxDelay     time.Duration   // 等待时间上限 (默认 1min)
+	Multiplier   float64         // 每次失败后等待时间的倍率 (默认 2, 为 1 时固定间隔)
+	Jitter       float64         // 随机抖动比例 [0, 1], 实际等待 delay*(1±Jitter) (默认 0.2, 小于 0 时关闭)
+	MaxAttempts  int             // 最大尝试次数 (默认 0 不限)
+	OnGiveUp     func(err error) // 达到最大尝试次数放弃时调用, err 为最后一次失败的原因
+}
+
+// Delay 第 attempt 次失败后的等待时间, attempt 从 1 开始
+func (p *ReconnectPolicy) Delay(attempt int) time.Duration {
+	initial, maxDelay, multiplier, jitter := p.InitialDelay, p.MaxDelay, p.Multiplier, p.Jitter
+	if initial <= 0 {
+		initial = 2 * time.Second
+	}
+	if maxDelay <= 0 {
+		maxDelay = time.Minute
+	}
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	if jitter == 0 {
+		jitter = 0.2
+	} else if jitter < 0 {
+		jitter = 0
+	} else if jitter > 1 {
+		jitter = 1
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if d > float64(maxDelay) {
+		d = float64(maxDelay)
+	}
+	d *= 1 + jitter*(2*rand.Float64()-1)
+	return time.Duration(d)
+}
+
+// exhausted 第 attempt 次失败后是否应当放弃
+func (p *ReconnectPolicy) exhausted(attempt int) bool {
+	return p.MaxAttempts > 0 && attempt >= p.MaxAttempts
+}
+
+// giveUp 放弃时调用 OnGiveUp
+func (p *ReconnectPolicy) giveUp(err error) {
+	if p.OnGiveUp != nil {
+		p.OnGiveUp(err)
+	}
+}
@@ -0,0 +1,113 @@
+package driver
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		policy  ReconnectPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"default first", ReconnectPolicy{Jitter: -1}, 1, 2 * time.Second},
+		{"default doubles", ReconnectPolicy{Jitter: -1}, 3, 8 * time.Second},
+		{"default max", ReconnectPolicy{Jitter: -1}, 10, time.Minute},
+		{"attempt below 1", ReconnectPolicy{Jitter: -1}, 0, 2 * time.Second},
+		{"custom", ReconnectPolicy{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 3, Jitter: -1}, 3, 9 * time.Second},
+		{"custom max", ReconnectPolicy{InitialDelay: time.Second, MaxDelay: 10 * time.Second, Multiplier: 3, Jitter: -1}, 4, 10 * time.Second},
+		{"fixed", ReconnectPolicy{InitialDelay: time.Second, Multiplier: 1, Jitter: -1}, 5, time.Second},
+		{"multiplier below 1", ReconnectPolicy{InitialDelay: time.Second, Multiplier: 0.5, Jitter: -1}, 2, 2 * time.Second},
+	} {
+		if got := c.policy.Delay(c.attempt); got != c.want {
+			t.Errorf("%s: Delay(%d) = %v, want %v", c.name, c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestReconnectJitter(t *testing.T) {
+	for _, c := range []struct {
+		policy   ReconnectPolicy
+		min, max time.Duration
+	}{
+		{ReconnectPolicy{InitialDelay: time.Second}, 800 * time.Millisecond, 1200 * time.Millisecond}, // 默认 0.2
+		{ReconnectPolicy{InitialDelay: time.Second, Jitter: 0.5}, 500 * time.Millisecond, 1500 * time.Millisecond},
+		{ReconnectPolicy{InitialDelay: time.Second, Jitter: 2}, 0, 2 * time.Second}, // 超过 1 按 1 处理
+	} {
+		varied := false
+		for i := 0; i < 100; i++ {
+			d := c.policy.Delay(1)
+			if d < c.min || d > c.max {
+				t.Fatalf("jitter %v: Delay(1) = %v, want in [%v, %v]", c.policy.Jitter, d, c.min, c.max)
+			}
+			varied = varied || d != time.Second
+		}
+		if !varied {
+			t.Errorf("jitter %v: delay never varied", c.policy.Jitter)
+		}
+	}
+}
+
+func TestReconnectExhausted(t *testing.T) {
+	p := ReconnectPolicy{}
+	if p.exhausted(1000) {
+		t.Fatal("unlimited policy exhausted")
+	}
+	var gaveUp error
+	p = ReconnectPolicy{MaxAttempts: 3, OnGiveUp: func(err error) { gaveUp = err }}
+	if p.exhausted(2) || !p.exhausted(3) {
+		t.Fatal("MaxAttempts 3 not exhausted exactly at attempt 3")
+	}
+	errBoom := errors.New("boom")
+	p.giveUp(errBoom)
+	if gaveUp != errBoom {
+		t.Fatalf("OnGiveUp got %v", gaveUp)
+	}
+	(&ReconnectPolicy{}).giveUp(errBoom) // 未设置 OnGiveUp
+}
+
+func TestWSClientGiveUp(t *testing.T) {
+	// 监听后立即关闭, 得到一个必然拒绝连接的地址
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	gaveUp := make(chan error, 1)
+	ws := NewWebSocketClient("ws://"+addr, "")
+	ws.Reconnect = ReconnectPolicy{
+		InitialDelay: time.Millisecond,
+		Jitter:       -1,
+		MaxAttempts:  3,
+		OnGiveUp:     func(err error) { gaveUp <- err },
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ws.Connect()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Connect did not give up")
+	}
+	select {
+	case err := <-gaveUp:
+		if err == nil {
+			t.Fatal("OnGiveUp called with nil error")
+		}
+	default:
+		t.Fatal("OnGiveUp not called")
+	}
+	if s := ws.State(); s != zero.DriverClosed {
+		t.Fatalf("state after giving up %v", s)
+	}
+}
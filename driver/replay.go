@@ -0,0 +1,193 @@
+package driver
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/cubevlmu/CZeroBot/log"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/record"
+)
+
+// Replay 重放 zero.Config.Record 记录的事件, API 调用以记录中的响应回答
+//
+// Listen 在所有事件送出后返回, 未启用事件环时之后可调用 zero.Stop 等待处理完成
+type Replay struct {
+	Speed float64 // 重放速度, 1 为原速, 2 为两倍速, <= 0 时不等待
+
+	entries []record.Entry
+	callers map[int64]*ReplayCaller
+
+	once sync.Once
+	done chan struct{} // Close 后关闭
+
+	lifecycle
+}
+
+// NewReplay 读取 files 中的记录, 通常为 record.Files(path)
+func NewReplay(speed float64, files ...string) (*Replay, error) {
+	entries, err := record.Read(files...)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{
+		Speed:   speed,
+		entries: entries,
+		callers: map[int64]*ReplayCaller{},
+		done:    make(chan struct{}),
+	}
+	for i := range entries {
+		e := &entries[i]
+		c := r.callers[e.SelfID]
+		if c == nil {
+			c = newReplayCaller(e.SelfID)
+			r.callers[e.SelfID] = c
+		}
+		if e.Kind == record.KindAPI {
+			if err := c.add(e); err != nil {
+				log.Warningf("[replay] 忽略无法解析的 API 记录 (%v): %v", e.Time, err)
+			}
+		}
+	}
+	return r, nil
+}
+
+// Connect 注册记录中出现的所有账号
+func (r *Replay) Connect() {
+	r.setState(zero.DriverConnected)
+	for id, c := range r.callers {
+		zero.StoreCaller(id, c)
+		r.emit(id, true, c)
+	}
+	log.Infof("[replay] 共 %d 条记录, %d 个账号", len(r.entries), len(r.callers))
+}
+
+// Listen 按记录的时间间隔除以 Speed 依次送出事件
+func (r *Replay) Listen(handler func([]byte, zero.APICaller)) {
+	var last time.Time
+	n := 0
+	for i := range r.entries {
+		e := &r.entries[i]
+		if e.Kind != record.KindEvent {
+			continue
+		}
+		if r.Speed > 0 && !last.IsZero() {
+			if d := time.Duration(float64(e.Time.Sub(last)) / r.Speed); d > 0 && !sleep(r.done, d) {
+				return
+			}
+		}
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+		last = e.Time
+		handler(e.Event, r.callers[e.SelfID])
+		n++
+	}
+	log.Infof("[replay] 已送出全部 %d 个事件", n)
+}
+
+// Close 停止重放, 之后 Listen 返回
+func (r *Replay) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+		r.setState(zero.DriverClosed)
+		for id := range r.callers {
+			r.emit(id, false, nil)
+		}
+	})
+	return nil
+}
+
+// ReplayCaller 以记录中的响应回答 API 调用
+//
+// 优先使用 action 与参数均相同的记录, 其次使用 action 相同的记录, 每条记录只使用一次
+type ReplayCaller struct {
+	selfID   int64
+	mu       sync.Mutex
+	exact    map[string][]*replayAnswer // action 与参数 -> 响应
+	byAction map[string][]*replayAnswer // action -> 响应
+}
+
+// replayAnswer 一次记录的调用结果
+type replayAnswer struct {
+	rsp  zero.APIResponse
+	err  string
+	used bool
+}
+
+func newReplayCaller(selfID int64) *ReplayCaller {
+	return &ReplayCaller{
+		selfID:   selfID,
+		exact:    map[string][]*replayAnswer{},
+		byAction: map[string][]*replayAnswer{},
+	}
+}
+
+// add 添加一条 API 记录
+func (c *ReplayCaller) add(e *record.Entry) error {
+	var req struct {
+		Action string          `json:"action"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.Unmarshal(e.Request, &req); err != nil {
+		return err
+	}
+	a := &replayAnswer{err: e.Error}
+	if len(e.Response) > 0 {
+		if err := json.Unmarshal(e.Response, &a.rsp); err != nil {
+			return err
+		}
+	}
+	key := req.Action + "\x00" + string(req.Params)
+	c.exact[key] = append(c.exact[key], a)
+	c.byAction[req.Action] = append(c.byAction[req.Action], a)
+	return nil
+}
+
+// pop 取出 m[key] 中第一个未使用的响应
+func pop(m map[string][]*replayAnswer, key string) *replayAnswer {
+	queue := m[key]
+	for i, a := range queue {
+		if a.used {
+			continue
+		}
+		a.used = true
+		if i+1 < len(queue) {
+			m[key] = queue[i+1:]
+		} else {
+			delete(m, key)
+		}
+		return a
+	}
+	delete(m, key)
+	return nil
+}
+
+// CallAPI 返回记录中的响应, 没有对应的记录时返回 retcode 1404
+func (c *ReplayCaller) CallAPI(request zero.APIRequest) (zero.APIResponse, error) {
+	key := request.Action
+	if p, err := json.Marshal(request.Params); err == nil {
+		key += "\x00" + string(p)
+	}
+	c.mu.Lock()
+	a := pop(c.exact, key)
+	if a == nil {
+		a = pop(c.byAction, request.Action)
+	}
+	c.mu.Unlock()
+	if a == nil {
+		log.With(log.SelfID(c.selfID), log.Action(request.Action)).Warningf("[replay] 记录中没有 %s 的响应", request.Action)
+		return zero.APIResponse{Status: "failed", RetCode: 1404, Message: "replay: no recorded response", Echo: request.Echo}, nil
+	}
+	if a.err != "" {
+		return zero.APIResponse{}, errors.New(a.err)
+	}
+	rsp := a.rsp
+	rsp.Echo = request.Echo
+	return rsp, nil
+}
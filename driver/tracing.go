@@ -0,0 +1,34 @@
+package driver
+
+import (
+	"context"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/trace"
+)
+
+// startAPISpan 开始 API 调用的 Span, 以 ctx 中事件的 Span 为父
+func startAPISpan(ctx context.Context, action string) (context.Context, *trace.Span) {
+	if !trace.Enabled() {
+		return ctx, nil
+	}
+	return trace.Start(ctx, "api "+action, trace.String("api.action", action))
+}
+
+// endAPISpan 记录调用结果并结束 Span
+func endAPISpan(span *trace.Span, selfID int64, echo uint64, rsp zero.APIResponse, err error) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(
+		trace.Int64("self_id", selfID),
+		trace.Int64("api.echo", int64(echo)),
+		trace.Int64("api.retcode", rsp.RetCode),
+	)
+	if err != nil {
+		span.RecordError(err)
+	} else if rsp.RetCode != 0 {
+		span.SetStatus(trace.StatusError, rsp.Message)
+	}
+	span.End()
+}
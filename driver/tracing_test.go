@@ -0,0 +1,54 @@
+package driver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/trace"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestAPISpan(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			_, _ = w.Write([]byte(`{"status":"failed","retcode":100,"message":"bad"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok","retcode":0}`))
+	}))
+	defer srv.Close()
+	spans := zerotest.RecordSpans(t)
+	c := &HTTPCaller{URL: srv.URL, selfID: 7}
+
+	ctx, event := trace.Start(context.Background(), "event message")
+	for _, action := range []string{"ok", "fail"} {
+		if _, err := c.CallAPIContext(ctx, zero.APIRequest{Action: action}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	event.End()
+
+	for action, status := range map[string]trace.StatusCode{"ok": trace.StatusUnset, "fail": trace.StatusError} {
+		named := spans.Named("api " + action)
+		if len(named) != 1 {
+			t.Fatalf("%d spans for %s, want 1", len(named), action)
+		}
+		d := named[0]
+		if d.TraceID != event.TraceID() || d.ParentSpanID != event.SpanID() {
+			t.Errorf("api %s span is not a child of the event span", action)
+		}
+		if d.StatusCode != status {
+			t.Errorf("api %s status %v, want %v", action, d.StatusCode, status)
+		}
+		attrs := make(map[string]interface{})
+		for _, a := range d.Attributes {
+			attrs[a.Key] = a.Value
+		}
+		if attrs["api.action"] != action || attrs["self_id"] != int64(7) {
+			t.Errorf("api %s attributes %v", action, attrs)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package driver
 
 import (
+	"context"
 	"encoding/base64"
 	"io"
 	"net"
@@ -23,6 +24,44 @@ var (
 	nullResponse = zero.APIResponse{}
 )
 
+// defaultCallTimeout 未设置 deadline 时 API 调用的最长等待时间
+const defaultCallTimeout = time.Minute
+
+// waitResponse 等待 echo 对应的响应, 超时或取消时从 seqMap 中移除
+//
+// ctx 未设置 deadline 时最多等待 defaultCallTimeout
+func waitResponse(ctx context.Context, seqMap *seqSyncMap, echo uint64, ch <-chan zero.APIResponse) (zero.APIResponse, error) {
+	var timeout <-chan time.Time
+	if _, ok := ctx.Deadline(); !ok {
+		t := time.NewTimer(defaultCallTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select { // 等待数据返回
+	case rsp, ok := <-ch:
+		if !ok {
+			return nullResponse, io.ErrClosedPipe
+		}
+		return rsp, nil
+	case <-ctx.Done():
+		seqMap.Delete(echo)
+		return nullResponse, ctx.Err()
+	case <-timeout:
+		seqMap.Delete(echo)
+		return nullResponse, os.ErrDeadlineExceeded
+	}
+}
+
+// closeSeqMap 关闭所有等待中的 API 调用
+func closeSeqMap(seqMap *seqSyncMap) {
+	seqMap.Range(func(key uint64, c chan<- zero.APIResponse) bool {
+		if _, ok := seqMap.LoadAndDelete(key); ok {
+			close(c)
+		}
+		return true
+	})
+}
+
 // WSClient ...
 type WSClient struct {
 	seq         uint64
@@ -31,7 +70,15 @@ type WSClient struct {
 	seqMap      seqSyncMap
 	URL         string // ws连接地址
 	AccessToken string
+	Reconnect   ReconnectPolicy // 连接失败时的重试策略
+	Heartbeat   HeartbeatPolicy // 心跳检测策略
+	Protocol    zero.Protocol   // OneBot 协议版本 (默认自动检测)
 	selfID      int64
+	api         zero.APICaller // 按协议包装后的 caller
+	interval    time.Duration  // 当前连接的心跳间隔
+	done        chan struct{}  // Close 后关闭
+
+	lifecycle
 }
 
 // NewWebSocketClient 默认Driver，使用正向WS通信
@@ -44,6 +91,22 @@ func NewWebSocketClient(url, accessToken string) *WSClient {
 
 // Connect 连接ws服务端
 func (ws *WSClient) Connect() {
+	ws.setState(zero.DriverConnecting)
+	ws.connect(ws.doneChan())
+}
+
+// doneChan 获取本次运行的 done, Close 后再次 Connect 将重新创建
+func (ws *WSClient) doneChan() chan struct{} {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.done == nil {
+		ws.done = make(chan struct{})
+	}
+	return ws.done
+}
+
+// connect 按重试策略连接ws服务端直至成功, done 关闭或放弃时返回 false
+func (ws *WSClient) connect(done <-chan struct{}) bool {
 	log.Infof("[ws] trying to connect websocket server: %v", ws.URL)
 	header := http.Header{
 		"X-Client-Role": []string{"Universal"},
@@ -70,48 +133,143 @@ func (ws *WSClient) Connect() {
 		},
 	}
 
-	for {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-done:
+			return false
+		default:
+		}
 		conn, res, err := dialer.Dial(address, header)
 		if err != nil {
 			log.Warningf("[ws] failed to connect websocket server: %v error: %v", ws.URL, err)
-			time.Sleep(2 * time.Second) // 等待两秒后重新连接
+			if !ws.retry(done, attempt, err) {
+				return false
+			}
 			continue
 		}
-		ws.conn = conn
 		_ = res.Body.Close()
-		var rsp struct {
-			SelfID int64 `json:"self_id"`
-		}
-		err = ws.conn.ReadJSON(&rsp)
+		selfID, proto, err := handshake(conn, ws.Protocol)
 		if err != nil {
+			_ = conn.Close()
 			log.Warningf("[ws] failed to connect websocket server: %v with error when handshake : %v", ws.URL, err)
-			time.Sleep(2 * time.Second) // 等待两秒后重新连接
+			if !ws.retry(done, attempt, err) {
+				return false
+			}
 			continue
 		}
-		ws.selfID = rsp.SelfID
-		zero.APICallers.Store(ws.selfID, ws) // 添加Caller到 APICaller list...
-		log.Infof("[ws] connected to websocket server: %s , QQ account : %d", ws.URL, rsp.SelfID)
-		break
+		ws.mu.Lock()
+		select {
+		case <-done: // 握手期间被关闭
+			ws.mu.Unlock()
+			_ = conn.Close()
+			return false
+		default:
+		}
+		ws.conn = conn
+		ws.selfID = selfID
+		ws.api = wrapCaller(ws, proto)
+		ws.interval = 0
+		ws.setState(zero.DriverConnected)
+		ws.mu.Unlock()
+		zero.StoreCaller(ws.selfID, ws.api) // 添加Caller到 APICaller list...
+		log.With(log.SelfID(selfID)).Infof("[ws] connected to websocket server: %s , QQ account : %d, protocol : OneBot %d", ws.URL, selfID, proto)
+		ws.emit(selfID, true, ws.api)
+		return true
 	}
 }
 
+// retry 第 attempt 次连接失败后等待, 达到最大尝试次数时关闭并返回 false
+func (ws *WSClient) retry(done <-chan struct{}, attempt int, err error) bool {
+	if ws.Reconnect.exhausted(attempt) {
+		log.Errorf("[ws] give up connecting websocket server: %v after %d attempts", ws.URL, attempt)
+		_ = ws.Close()
+		ws.Reconnect.giveUp(err)
+		return false
+	}
+	d := ws.Reconnect.Delay(attempt)
+	log.Infof("[ws] reconnect to websocket server: %v in %v", ws.URL, d)
+	return sleep(done, d)
+}
+
+// sleep 等待 d, done 关闭时返回 false
+func sleep(done <-chan struct{}, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// Close 关闭连接并停止重连, 之后 Listen 返回
+func (ws *WSClient) Close() error {
+	ws.mu.Lock()
+	if ws.done != nil {
+		close(ws.done)
+		ws.done = nil
+	}
+	old := ws.setState(zero.DriverClosed)
+	selfID, api := ws.selfID, ws.api
+	zero.APICallers.Delete(selfID)
+	var err error
+	if ws.conn != nil {
+		err = ws.conn.Close()
+	}
+	ws.mu.Unlock()
+	closeSeqMap(&ws.seqMap)
+	if old == zero.DriverConnected {
+		ws.emit(selfID, false, api)
+	}
+	return err
+}
+
 // Listen 开始监听事件
 func (ws *WSClient) Listen(handler func([]byte, zero.APICaller)) {
+	ws.mu.Lock()
+	done := ws.done
+	ws.mu.Unlock()
+	if done == nil { // 未 Connect 或已被 Close
+		return
+	}
 	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
 		t, payload, err := ws.conn.ReadMessage()
 		if err != nil { // reconnect
+			select {
+			case <-done:
+				log.Info("[ws] websocket client closed")
+				return
+			default:
+			}
 			zero.APICallers.Delete(ws.selfID) // 断开从apicaller中删除
-			log.Warning("[ws] websocket server's connection closed...")
-			time.Sleep(time.Millisecond * time.Duration(3))
-			ws.Connect()
+			if isTimeout(err) {
+				log.With(log.SelfID(ws.selfID)).Warningf("[ws] no heartbeat from websocket server in %v, connection is dead", ws.Heartbeat.timeout(ws.interval))
+			}
+			_ = ws.conn.Close()
+			closeSeqMap(&ws.seqMap)
+			log.With(log.SelfID(ws.selfID)).Warning("[ws] websocket server's connection closed...")
+			ws.setState(zero.DriverReconnecting)
+			ws.emit(ws.selfID, false, ws.api)
+			if !ws.connect(done) {
+				ws.setState(zero.DriverClosed)
+				log.Info("[ws] websocket client closed")
+				return
+			}
 			continue
 		}
 		if t != websocket.TextMessage {
 			continue
 		}
 		rsp := gjson.Parse(helper.BytesToString(payload))
+		isHeartbeat := ws.Heartbeat.received(ws.conn, &ws.interval, rsp)
 		if rsp.Get("echo").Exists() { // 存在echo字段，是api调用的返回
-			log.Debugf("[ws] received from api calling: %s", strings.TrimSpace(helper.BytesToString(payload)))
+			log.With(log.SelfID(ws.selfID)).Debugf("[ws] received from api calling: %s", strings.TrimSpace(helper.BytesToString(payload)))
 			if c, ok := ws.seqMap.LoadAndDelete(rsp.Get("echo").Uint()); ok {
 				msg := rsp.Get("message").Str
 				if msg == "" {
@@ -129,11 +287,11 @@ func (ws *WSClient) Listen(handler func([]byte, zero.APICaller)) {
 			}
 			continue
 		}
-		if rsp.Get("meta_event_type").Str == "heartbeat" { // 忽略心跳事件
+		if isHeartbeat && !ws.Heartbeat.Deliver { // 忽略心跳事件
 			continue
 		}
-		log.Debugf("[ws] recevied event : %s", helper.BytesToString(payload))
-		handler(payload, ws)
+		log.With(log.SelfID(ws.selfID)).Debugf("[ws] recevied event : %s", helper.BytesToString(payload))
+		handler(payload, ws.api)
 	}
 }
 
@@ -143,27 +301,36 @@ func (ws *WSClient) nextSeq() uint64 {
 
 // CallAPI 发送ws请求
 func (ws *WSClient) CallAPI(req zero.APIRequest) (zero.APIResponse, error) {
+	return ws.CallAPIContext(context.Background(), req)
+}
+
+// CallAPIContext 发送ws请求, ctx 取消时放弃等待响应
+func (ws *WSClient) CallAPIContext(ctx context.Context, req zero.APIRequest) (rsp zero.APIResponse, err error) {
+	if err := ctx.Err(); err != nil {
+		return nullResponse, err
+	}
+	ctx, span := startAPISpan(ctx, req.Action)
+	var selfID int64
+	defer func() { endAPISpan(span, selfID, req.Echo, rsp, err) }()
 	ch := make(chan zero.APIResponse, 1)
 	req.Echo = ws.nextSeq()
 	ws.seqMap.Store(req.Echo, ch)
 
 	// send message
 	ws.mu.Lock() // websocket write is not goroutine safe
-	err := ws.conn.WriteJSON(&req)
+	err = ws.conn.WriteJSON(&req)
+	selfID = ws.selfID
+	logger := log.With(log.SelfID(selfID), log.Action(req.Action))
 	ws.mu.Unlock()
 	if err != nil {
-		log.Warningf("[ws] failed to send api call to websocket server: %s", err.Error())
+		ws.seqMap.Delete(req.Echo)
+		logger.Warningf("[ws] failed to send api call to websocket server: %s", err.Error())
 		return nullResponse, err
 	}
-	log.Debugf("[ws] sending request to server : %v", &req)
+	logger.Debugf("[ws] sending request to server : %v", &req)
 
-	select { // 等待数据返回
-	case rsp, ok := <-ch:
-		if !ok {
-			return nullResponse, io.ErrClosedPipe
-		}
-		return rsp, nil
-	case <-time.After(time.Minute):
-		return nullResponse, os.ErrDeadlineExceeded
-	}
+	start := time.Now()
+	rsp, err = waitResponse(ctx, &ws.seqMap, req.Echo, ch)
+	logger.With(log.Latency(time.Since(start))).Debugf("[ws] api call %s finished, err: %v", req.Action, err)
+	return rsp, err
 }
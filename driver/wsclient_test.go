@@ -0,0 +1,72 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+)
+
+func TestWaitResponse(t *testing.T) {
+	var seqMap seqSyncMap
+
+	ch := make(chan zero.APIResponse, 1)
+	seqMap.Store(1, ch)
+	ch <- zero.APIResponse{Status: "ok", Echo: 1}
+	rsp, err := waitResponse(context.Background(), &seqMap, 1, ch)
+	if err != nil || rsp.Echo != 1 {
+		t.Fatalf("waitResponse returned %+v, %v", rsp, err)
+	}
+
+	// 连接关闭
+	ch = make(chan zero.APIResponse, 1)
+	close(ch)
+	if _, err := waitResponse(context.Background(), &seqMap, 2, ch); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("closed channel err %v, want %v", err, io.ErrClosedPipe)
+	}
+
+	// 取消时移除等待中的 echo
+	ch = make(chan zero.APIResponse, 1)
+	seqMap.Store(3, ch)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := waitResponse(ctx, &seqMap, 3, ch); !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled err %v, want %v", err, context.Canceled)
+	}
+	if _, ok := seqMap.Load(3); ok {
+		t.Fatal("echo 3 not removed after cancel")
+	}
+
+	// ctx 的 deadline 优先于默认超时
+	ch = make(chan zero.APIResponse, 1)
+	seqMap.Store(4, ch)
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if _, err := waitResponse(ctx, &seqMap, 4, ch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("deadline err %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, ok := seqMap.Load(4); ok {
+		t.Fatal("echo 4 not removed after deadline")
+	}
+}
+
+func TestCloseSeqMap(t *testing.T) {
+	var seqMap seqSyncMap
+	chs := make([]chan zero.APIResponse, 3)
+	for i := range chs {
+		chs[i] = make(chan zero.APIResponse, 1)
+		seqMap.Store(uint64(i), chs[i])
+	}
+	closeSeqMap(&seqMap)
+	for i, ch := range chs {
+		if _, ok := <-ch; ok {
+			t.Fatalf("channel %d not closed", i)
+		}
+		if _, ok := seqMap.Load(uint64(i)); ok {
+			t.Fatalf("echo %d not removed", i)
+		}
+	}
+}
@@ -1,12 +1,11 @@
 package driver
 
 import (
+	"context"
 	"encoding/json"
-	"io"
 	"net"
 	"net/http"
 	"net/url"
-	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -28,8 +27,17 @@ type WSServer struct {
 	lstn        net.Listener
 	caller      chan *WSSCaller
 	hook        ConnectHook
+	Heartbeat   HeartbeatPolicy // 心跳检测策略
+	Protocol    zero.Protocol   // OneBot 协议版本 (默认自动检测)
 
 	json.Unmarshaler
+
+	mu    sync.Mutex
+	done  chan struct{} // Close 后关闭
+	conns sync.Map      // 已连接的 *WSSCaller
+	nconn int32         // 已连接数
+
+	lifecycle
 }
 
 // UnmarshalJSON init WSServer with waitn=16
@@ -48,6 +56,7 @@ func (wss *WSServer) UnmarshalJSON(data []byte) error {
 
 // hack
 
+// ConnectHook 账号连接时调用, 新代码请使用 Subscribe
 type ConnectHook func(id int64)
 
 // NewWebSocketServer 使用反向WS通信
@@ -67,6 +76,10 @@ type WSSCaller struct {
 	conn   *websocket.Conn
 	selfID int64
 	seq    uint64
+	api    zero.APICaller // 按协议包装后的 caller
+
+	heartbeat *HeartbeatPolicy
+	interval  time.Duration // 心跳间隔
 }
 
 var upgrader = websocket.Upgrader{
@@ -77,6 +90,16 @@ var upgrader = websocket.Upgrader{
 
 // Connect 监听ws服务
 func (wss *WSServer) Connect() {
+	wss.setState(zero.DriverConnecting)
+	wss.mu.Lock()
+	if wss.done == nil {
+		wss.done = make(chan struct{})
+	}
+	wss.mu.Unlock()
+	wss.listen()
+}
+
+func (wss *WSServer) listen() {
 	network, address := resolveURI(wss.URL)
 	uri, err := url.Parse(address)
 	if err == nil && uri.Scheme != "" {
@@ -84,11 +107,17 @@ func (wss *WSServer) Connect() {
 	}
 
 	listener, err := net.Listen(network, address)
+	wss.mu.Lock()
+	defer wss.mu.Unlock()
 	if err != nil {
 		log.Warningf("[wss] failed to listen at (WS_Server): %v", err)
 		wss.lstn = nil
 		return
 	}
+	if wss.done == nil { // 已被 Close
+		_ = listener.Close()
+		return
+	}
 
 	wss.lstn = listener
 	log.Infof("[wss] websocket server listening at port: %s", listener.Addr())
@@ -133,65 +162,147 @@ func (wss *WSServer) any(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var rsp struct {
-		SelfID int64 `json:"self_id"`
-	}
-	err = conn.ReadJSON(&rsp)
+	selfID, proto, err := handshake(conn, wss.Protocol)
 	if err != nil {
+		_ = conn.Close()
 		log.Warningf("[wss] handshake with websocket server %v failed: %v", wss.URL, err)
 		return
 	}
 
 	c := &WSSCaller{
-		conn:   conn,
-		selfID: rsp.SelfID,
+		conn:      conn,
+		selfID:    selfID,
+		heartbeat: &wss.Heartbeat,
 	}
-	zero.APICallers.Store(rsp.SelfID, c) // 添加Caller到 APICaller list...
+	c.api = wrapCaller(c, proto)
+	wss.mu.Lock()
+	done := wss.done
+	wss.mu.Unlock()
+	if done == nil { // 已被 Close
+		_ = conn.Close()
+		return
+	}
+	wss.conns.Store(c, struct{}{})
+	atomic.AddInt32(&wss.nconn, 1)
+	wss.setState(zero.DriverConnected)
+	zero.StoreCaller(selfID, c.api) // 添加Caller到 APICaller list...
 	if wss.hook != nil {
-		wss.hook(rsp.SelfID)
+		wss.hook(selfID)
+	}
+	log.With(log.SelfID(selfID)).Infof("[wss] connected to websocket server: %s QQ account : %d, protocol : OneBot %d", wss.URL, selfID, proto)
+	wss.emit(selfID, true, c.api)
+	select {
+	case wss.caller <- c:
+	case <-done:
 	}
-	log.Infof("[wss] connected to websocket server: %s QQ account : %d", wss.URL, rsp.SelfID)
-	wss.caller <- c
+}
+
+// Close 关闭监听与所有连接, 之后 Listen 返回
+func (wss *WSServer) Close() error {
+	wss.mu.Lock()
+	if wss.done != nil {
+		close(wss.done)
+		wss.done = nil
+	}
+	wss.setState(zero.DriverClosed)
+	var err error
+	if wss.lstn != nil {
+		err = wss.lstn.Close()
+		wss.lstn = nil
+	}
+	wss.mu.Unlock()
+	wss.conns.Range(func(key, _ interface{}) bool {
+		wssc := key.(*WSSCaller)
+		if _, ok := wss.conns.LoadAndDelete(wssc); !ok {
+			return true
+		}
+		atomic.AddInt32(&wss.nconn, -1)
+		zero.APICallers.Delete(wssc.selfID)
+		_ = wssc.conn.Close()
+		closeSeqMap(&wssc.seqMap)
+		wss.emit(wssc.selfID, false, wssc.api)
+		return true
+	})
+	return err
 }
 
 // Listen 开始监听事件
 func (wss *WSServer) Listen(handler func([]byte, zero.APICaller)) {
+	wss.mu.Lock()
+	done := wss.done
+	wss.mu.Unlock()
+	if done == nil { // 未 Connect 或已被 Close
+		return
+	}
 	mux := http.ServeMux{}
 	mux.HandleFunc("/", wss.any)
 	go func() {
 		for {
-			if wss.lstn == nil {
-				time.Sleep(time.Millisecond * time.Duration(3))
-				wss.Connect()
+			wss.mu.Lock()
+			lstn := wss.lstn
+			wss.mu.Unlock()
+			if lstn == nil {
+				if !sleep(done, time.Millisecond*time.Duration(3)) {
+					return
+				}
+				wss.listen()
 				continue
 			}
-			log.Infof("[wss] webSocket server handling : %v", wss.lstn.Addr())
-			err := http.Serve(wss.lstn, &mux)
+			log.Infof("[wss] webSocket server handling : %v", lstn.Addr())
+			err := http.Serve(lstn, &mux)
+			select {
+			case <-done:
+				log.Info("[wss] websocket server closed")
+				return
+			default:
+			}
 			if err != nil {
-				log.Warningf("[wss] websocket server occured an error at end point : %s with error : %v", wss.lstn.Addr(), err)
-				wss.lstn = nil
+				log.Warningf("[wss] websocket server occured an error at end point : %s with error : %v", lstn.Addr(), err)
+				wss.mu.Lock()
+				if wss.lstn == lstn {
+					wss.lstn = nil
+				}
+				wss.mu.Unlock()
 			}
 		}
 	}()
-	for wssc := range wss.caller {
-		go wssc.listen(handler)
+	for {
+		select {
+		case wssc := <-wss.caller:
+			go wssc.listen(handler, wss)
+		case <-done:
+			return
+		}
 	}
 }
 
-func (wssc *WSSCaller) listen(handler func([]byte, zero.APICaller)) {
+func (wssc *WSSCaller) listen(handler func([]byte, zero.APICaller), wss *WSServer) {
 	for {
 		t, payload, err := wssc.conn.ReadMessage()
 		if err != nil { // reconnect
+			if _, ok := wss.conns.LoadAndDelete(wssc); !ok { // 已被 Close
+				return
+			}
+			if atomic.AddInt32(&wss.nconn, -1) == 0 {
+				wss.compareAndSetState(zero.DriverConnected, zero.DriverReconnecting)
+			}
+			if isTimeout(err) {
+				log.With(log.SelfID(wssc.selfID)).Warningf("[wss] no heartbeat from QQ account %v in %v, connection is dead", wssc.selfID, wssc.heartbeat.timeout(wssc.interval))
+			}
+			_ = wssc.conn.Close()
+			closeSeqMap(&wssc.seqMap)
 			zero.APICallers.Delete(wssc.selfID) // 断开从apicaller中删除
-			log.Warningf("[wss] disconnected from websocket server, QQ account : %v", wssc.selfID)
+			log.With(log.SelfID(wssc.selfID)).Warningf("[wss] disconnected from websocket server, QQ account : %v", wssc.selfID)
+			wss.emit(wssc.selfID, false, wssc.api)
 			return
 		}
 		if t != websocket.TextMessage {
 			continue
 		}
 		rsp := gjson.Parse(helper.BytesToString(payload))
+		isHeartbeat := wssc.heartbeat.received(wssc.conn, &wssc.interval, rsp)
 		if rsp.Get("echo").Exists() { // 存在echo字段，是api调用的返回
-			log.Debugf("[wss] received from api calling : %v", strings.TrimSpace(helper.BytesToString(payload)))
+			log.With(log.SelfID(wssc.selfID)).Debugf("[wss] received from api calling : %v", strings.TrimSpace(helper.BytesToString(payload)))
 			if c, ok := wssc.seqMap.LoadAndDelete(rsp.Get("echo").Uint()); ok {
 				msg := rsp.Get("message").Str
 				if msg == "" {
@@ -209,11 +320,11 @@ func (wssc *WSSCaller) listen(handler func([]byte, zero.APICaller)) {
 			}
 			continue
 		}
-		if rsp.Get("meta_event_type").Str == "heartbeat" { // 忽略心跳事件
+		if isHeartbeat && !wssc.heartbeat.Deliver { // 忽略心跳事件
 			continue
 		}
-		log.Debugf("[wss] received event : %v", helper.BytesToString(payload))
-		handler(payload, wssc)
+		log.With(log.SelfID(wssc.selfID)).Debugf("[wss] received event : %v", helper.BytesToString(payload))
+		handler(payload, wssc.api)
 	}
 }
 
@@ -223,27 +334,34 @@ func (wssc *WSSCaller) nextSeq() uint64 {
 
 // CallAPI 发送ws请求
 func (wssc *WSSCaller) CallAPI(req zero.APIRequest) (zero.APIResponse, error) {
+	return wssc.CallAPIContext(context.Background(), req)
+}
+
+// CallAPIContext 发送ws请求, ctx 取消时放弃等待响应
+func (wssc *WSSCaller) CallAPIContext(ctx context.Context, req zero.APIRequest) (rsp zero.APIResponse, err error) {
+	if err := ctx.Err(); err != nil {
+		return nullResponse, err
+	}
+	ctx, span := startAPISpan(ctx, req.Action)
+	defer func() { endAPISpan(span, wssc.selfID, req.Echo, rsp, err) }()
 	ch := make(chan zero.APIResponse, 1)
 	req.Echo = wssc.nextSeq()
 	wssc.seqMap.Store(req.Echo, ch)
 
 	// send message
 	wssc.mu.Lock() // websocket write is not goroutine safe
-	err := wssc.conn.WriteJSON(&req)
+	err = wssc.conn.WriteJSON(&req)
 	wssc.mu.Unlock()
+	logger := log.With(log.SelfID(wssc.selfID), log.Action(req.Action))
 	if err != nil {
-		log.Warningf("[wss] failed to send api request to websocket server: %v", err.Error())
+		wssc.seqMap.Delete(req.Echo)
+		logger.Warningf("[wss] failed to send api request to websocket server: %v", err.Error())
 		return nullResponse, err
 	}
-	log.Debugf("[wss] sending api request to server: %v", &req)
+	logger.Debugf("[wss] sending api request to server: %v", &req)
 
-	select { // 等待数据返回
-	case rsp, ok := <-ch:
-		if !ok {
-			return nullResponse, io.ErrClosedPipe
-		}
-		return rsp, nil
-	case <-time.After(time.Minute):
-		return nullResponse, os.ErrDeadlineExceeded
-	}
+	start := time.Now()
+	rsp, err = waitResponse(ctx, &wssc.seqMap, req.Echo, ch)
+	logger.With(log.Latency(time.Since(start))).Debugf("[wss] api call %s finished, err: %v", req.Action, err)
+	return rsp, err
 }
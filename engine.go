@@ -1,11 +1,21 @@
 package zero
 
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// engineID 上一个 New 生成的 Engine 的序号
+var engineID uint64
+
 // New 生成空引擎
 func New() *Engine {
 	return &Engine{
 		preHandler:  []Rule{},
 		midHandler:  []Rule{},
 		postHandler: []Handler{},
+		name:        strconv.FormatUint(atomic.AddUint64(&engineID, 1), 10),
 	}
 }
 
@@ -18,13 +28,36 @@ type Engine struct {
 	postHandler []Handler
 	block       bool
 	matchers    []*Matcher
+	service     *Service // Register 生成的插件
+	errorHooks  []ErrorHook
+	timeout     Timeout
+	nonBlocking bool   // preHandler 与 midHandler 均不会阻塞
+	name        string // Storage 的 bucket 名, 默认为生成的序号
+	// rewrite 有 UsePreHandler 添加的 preHandler, 其可能改写消息, 因此 Matcher 不使用分发索引
+	rewrite bool
 }
 
-// Delete 移除该 Engine 注册的所有 Matchers
+// Delete 移除该 Engine 注册的所有 Matchers, 若为插件则同时注销
 func (e *Engine) Delete() {
 	for _, m := range e.matchers {
 		m.Delete()
 	}
+	if e.service != nil {
+		unregister(e.service)
+	}
+}
+
+// Service 插件的开关控制, 非 Register 生成的 Engine 返回 nil
+func (e *Engine) Service() *Service {
+	return e.service
+}
+
+// SetName 设置该 Engine 在 Storage 中的 bucket 名
+//
+// 默认的序号取决于 New 的调用顺序, 需要跨进程保留数据时应设置固定的名称
+func (e *Engine) SetName(name string) *Engine {
+	e.name = name
+	return e
 }
 
 func (e *Engine) SetBlock(block bool) *Engine {
@@ -32,13 +65,29 @@ func (e *Engine) SetBlock(block bool) *Engine {
 	return e
 }
 
+// SetTimeout 设置该 Engine 的 Matcher 各阶段的最长处理时间, 见 Timeout
+func (e *Engine) SetTimeout(t Timeout) *Engine {
+	e.timeout = t
+	return e
+}
+
+// SetMaxProcessTime 将该 Engine 的 Matcher 所有阶段的最长处理时间设为 d, 小于 0 时不设超时
+func (e *Engine) SetMaxProcessTime(d time.Duration) *Engine {
+	return e.SetTimeout(Timeout{Rule: d, Handler: d, PostHandler: d})
+}
+
 // UsePreHandler 向该 Engine 添加新 PreHandler(Rule),
 // 会在 Rule 判断前触发，如果 preHandler
 // 没有通过，则 Rule, Matcher 不会触发
 //
-// 可用于分群组管理插件等
+// 可用于分群组管理插件等. preHandler 可以改写消息, 该 Engine 的 Matcher
+// 此后对每条消息都会判断, 不再由 OnCommand 等的索引筛选
 func (e *Engine) UsePreHandler(rules ...Rule) {
+	matcherLock.Lock()
+	defer matcherLock.Unlock()
 	e.preHandler = append(e.preHandler, rules...)
+	e.rewrite = true
+	hasMatcherListChanged = true
 }
 
 // UseMidHandler 向该 Engine 添加新 MidHandler(Rule),
@@ -89,13 +138,13 @@ func (e *Engine) OnNotice(rules ...Rule) *Matcher { return e.On("notice", rules.
 func OnRequest(rules ...Rule) *Matcher { return On("request", rules...) }
 
 // OnRequest 请求消息触发器
-func (e *Engine) OnRequest(rules ...Rule) *Matcher { return On("request", rules...) }
+func (e *Engine) OnRequest(rules ...Rule) *Matcher { return e.On("request", rules...) }
 
 // OnMetaEvent 元事件触发器
 func OnMetaEvent(rules ...Rule) *Matcher { return On("meta_event", rules...) }
 
 // OnMetaEvent 元事件触发器
-func (e *Engine) OnMetaEvent(rules ...Rule) *Matcher { return On("meta_event", rules...) }
+func (e *Engine) OnMetaEvent(rules ...Rule) *Matcher { return e.On("meta_event", rules...) }
 
 // OnPrefix 前缀触发器
 func OnPrefix(prefix string, rules ...Rule) *Matcher { return defaultEngine.OnPrefix(prefix, rules...) }
@@ -103,9 +152,11 @@ func OnPrefix(prefix string, rules ...Rule) *Matcher { return defaultEngine.OnPr
 // OnPrefix 前缀触发器
 func (e *Engine) OnPrefix(prefix string, rules ...Rule) *Matcher {
 	matcher := &Matcher{
-		Type:   Type("message"),
-		Rules:  append([]Rule{PrefixRule(prefix)}, rules...),
-		Engine: e,
+		Type:     Type("message"),
+		Rules:    append([]Rule{PrefixRule(prefix)}, rules...),
+		Engine:   e,
+		dispatch: &dispatchKey{kind: dispatchPrefix, keys: []string{prefix}},
+		inline:   1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -122,6 +173,7 @@ func (e *Engine) OnSuffix(suffix string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{SuffixRule(suffix)}, rules...),
 		Engine: e,
+		inline: 1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -135,9 +187,11 @@ func OnCommand(commands string, rules ...Rule) *Matcher {
 // OnCommand 命令触发器
 func (e *Engine) OnCommand(commands string, rules ...Rule) *Matcher {
 	matcher := &Matcher{
-		Type:   Type("message"),
-		Rules:  append([]Rule{CommandRule(commands)}, rules...),
-		Engine: e,
+		Type:     Type("message"),
+		Rules:    append([]Rule{CommandRule(commands)}, rules...),
+		Engine:   e,
+		dispatch: &dispatchKey{kind: dispatchCommand, keys: []string{commands}},
+		inline:   1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -154,6 +208,7 @@ func (e *Engine) OnRegex(regexPattern string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{RegexRule(regexPattern)}, rules...),
 		Engine: e,
+		inline: 1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -170,6 +225,7 @@ func (e *Engine) OnKeyword(keyword string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{KeywordRule(keyword)}, rules...),
 		Engine: e,
+		inline: 1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -183,9 +239,11 @@ func OnFullMatch(src string, rules ...Rule) *Matcher {
 // OnFullMatch 完全匹配触发器
 func (e *Engine) OnFullMatch(src string, rules ...Rule) *Matcher {
 	matcher := &Matcher{
-		Type:   Type("message"),
-		Rules:  append([]Rule{FullMatchRule(src)}, rules...),
-		Engine: e,
+		Type:     Type("message"),
+		Rules:    append([]Rule{FullMatchRule(src)}, rules...),
+		Engine:   e,
+		dispatch: &dispatchKey{kind: dispatchFullMatch, keys: []string{src}},
+		inline:   1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -199,9 +257,11 @@ func OnFullMatchGroup(src []string, rules ...Rule) *Matcher {
 // OnFullMatchGroup 完全匹配触发器组
 func (e *Engine) OnFullMatchGroup(src []string, rules ...Rule) *Matcher {
 	matcher := &Matcher{
-		Type:   Type("message"),
-		Rules:  append([]Rule{FullMatchRule(src...)}, rules...),
-		Engine: e,
+		Type:     Type("message"),
+		Rules:    append([]Rule{FullMatchRule(src...)}, rules...),
+		Engine:   e,
+		dispatch: &dispatchKey{kind: dispatchFullMatch, keys: src},
+		inline:   1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -218,6 +278,7 @@ func (e *Engine) OnKeywordGroup(keywords []string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{KeywordRule(keywords...)}, rules...),
 		Engine: e,
+		inline: 1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -230,7 +291,15 @@ func OnCommandGroup(commands []string, rules ...Rule) *Matcher {
 
 // OnCommandGroup 命令触发器组
 func (e *Engine) OnCommandGroup(commands []string, rules ...Rule) *Matcher {
-	return e.On("message", append([]Rule{CommandRule(commands...)}, rules...)...)
+	matcher := &Matcher{
+		Type:     Type("message"),
+		Rules:    append([]Rule{CommandRule(commands...)}, rules...),
+		Engine:   e,
+		dispatch: &dispatchKey{kind: dispatchCommand, keys: commands},
+		inline:   1,
+	}
+	e.matchers = append(e.matchers, matcher)
+	return StoreMatcher(matcher)
 }
 
 // OnPrefixGroup 前缀触发器组
@@ -241,9 +310,11 @@ func OnPrefixGroup(prefix []string, rules ...Rule) *Matcher {
 // OnPrefixGroup 前缀触发器组
 func (e *Engine) OnPrefixGroup(prefix []string, rules ...Rule) *Matcher {
 	matcher := &Matcher{
-		Type:   Type("message"),
-		Rules:  append([]Rule{PrefixRule(prefix...)}, rules...),
-		Engine: e,
+		Type:     Type("message"),
+		Rules:    append([]Rule{PrefixRule(prefix...)}, rules...),
+		Engine:   e,
+		dispatch: &dispatchKey{kind: dispatchPrefix, keys: prefix},
+		inline:   1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -260,6 +331,7 @@ func (e *Engine) OnSuffixGroup(suffix []string, rules ...Rule) *Matcher {
 		Type:   Type("message"),
 		Rules:  append([]Rule{SuffixRule(suffix...)}, rules...),
 		Engine: e,
+		inline: 1,
 	}
 	e.matchers = append(e.matchers, matcher)
 	return StoreMatcher(matcher)
@@ -0,0 +1,208 @@
+package zero
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/cubevlmu/CZeroBot/log"
+	"github.com/cubevlmu/CZeroBot/message"
+	"github.com/cubevlmu/CZeroBot/utils/helper"
+)
+
+// HandlerError Matcher 执行中的 panic 或 HandlerE 返回的错误
+type HandlerError struct {
+	Matcher *Matcher
+	Stage   string      // 出错的阶段, 如 rule, Handler, postHandler
+	Err     error       // HandlerE 返回的错误, panic 时为 nil
+	Panic   interface{} // panic 的值, 非 panic 时为 nil
+	Stack   []byte      // panic 时的调用栈
+}
+
+func (e *HandlerError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprint("panic: ", e.Panic)
+}
+
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorHook 错误钩子, 在出错的协程中同步调用
+type ErrorHook func(ctx *Ctx, err *HandlerError)
+
+var (
+	errorHooks   []ErrorHook
+	errorHooksMu sync.RWMutex
+)
+
+// UseErrorHook 添加全局错误钩子, 任意 Matcher 出错时调用
+func UseErrorHook(hooks ...ErrorHook) {
+	errorHooksMu.Lock()
+	errorHooks = append(errorHooks, hooks...)
+	errorHooksMu.Unlock()
+}
+
+// UseErrorHook 添加该 Engine 的错误钩子, 先于全局钩子调用
+func (e *Engine) UseErrorHook(hooks ...ErrorHook) {
+	e.errorHooks = append(e.errorHooks, hooks...)
+}
+
+// recoverPanic 将 recover 得到的 pa 交给 reportError
+func recoverPanic(ctx *Ctx, m *Matcher, stage string, pa interface{}) {
+	reportError(ctx, &HandlerError{Matcher: m, Stage: stage, Panic: pa, Stack: debug.Stack()})
+}
+
+// reportError 记录日志并依次调用 Engine 与全局的错误钩子
+func reportError(ctx *Ctx, herr *HandlerError) {
+	l := eventLog(ctx, herr.Matcher).With(log.Stage(herr.Stage))
+	if herr.Panic != nil {
+		kind := "rule"
+		if herr.Stage == "Handler" || herr.Stage == "postHandler" {
+			kind = "handler"
+		}
+		l.Errorf("[bot] execute %s err: %v\n%v", kind, herr.Panic, helper.BytesToString(herr.Stack))
+	} else {
+		l.With(log.Err(herr.Err)).Warning("[bot] handler returned error")
+	}
+	if herr.Matcher != nil && herr.Matcher.Engine != nil {
+		for _, hook := range herr.Matcher.Engine.errorHooks {
+			callErrorHook(ctx, hook, herr)
+		}
+	}
+	errorHooksMu.RLock()
+	hooks := errorHooks
+	errorHooksMu.RUnlock()
+	for _, hook := range hooks {
+		callErrorHook(ctx, hook, herr)
+	}
+}
+
+func callErrorHook(ctx *Ctx, hook ErrorHook, herr *HandlerError) {
+	defer func() {
+		if pa := recover(); pa != nil {
+			eventLog(ctx, herr.Matcher).Errorf("[bot] execute error hook err: %v\n%v", pa, helper.BytesToString(debug.Stack()))
+		}
+	}()
+	hook(ctx, herr)
+}
+
+// NotifyConfig NotifySuperUsers 的设置
+type NotifyConfig struct {
+	Dedup    time.Duration // 相同的错误在此时间内只通知一次, 默认 10 分钟
+	Interval time.Duration // 平均每 Interval 最多通知一次, 默认 1 分钟
+	Burst    int           // 最多连续通知的次数, 默认 3
+	Stack    bool          // 是否附带 panic 的调用栈
+}
+
+// maxNotifyStack 通知中调用栈的最大长度
+const maxNotifyStack = 2000
+
+// errorNotifier 对错误通知去重与限速
+type errorNotifier struct {
+	cfg        NotifyConfig
+	mu         sync.Mutex
+	seen       map[string]time.Time // 错误 -> 上次通知的时间
+	bucket     tokenBucket
+	state      interface{}
+	suppressed int // 上次通知后被略过的错误数
+}
+
+// NotifySuperUsers 返回私聊通知 SuperUsers 的错误钩子
+//
+// 相同的错误 (Matcher, 阶段与错误信息均相同) 在 Dedup 内只通知一次,
+// 超出 Interval 与 Burst 限制的错误不通知, 其数量附在下一次通知中
+//
+//	zero.UseErrorHook(zero.NotifySuperUsers(zero.NotifyConfig{}))
+func NotifySuperUsers(cfg NotifyConfig) ErrorHook {
+	if cfg.Dedup <= 0 {
+		cfg.Dedup = 10 * time.Minute
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.Burst < 1 {
+		cfg.Burst = 3
+	}
+	n := &errorNotifier{
+		cfg:    cfg,
+		seen:   map[string]time.Time{},
+		bucket: tokenBucket{interval: cfg.Interval, burst: float64(cfg.Burst)},
+	}
+	n.state = n.bucket.newState()
+	return n.notify
+}
+
+// allow 是否通知 key 对应的错误, 返回上次通知后被略过的错误数
+func (n *errorNotifier) allow(key string, now time.Time) (bool, int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if last, ok := n.seen[key]; ok && now.Sub(last) < n.cfg.Dedup {
+		n.suppressed++
+		return false, 0
+	}
+	if ok, _ := n.bucket.take(n.state, now, false); !ok {
+		n.suppressed++
+		return false, 0
+	}
+	for k, t := range n.seen { // 清理过期的记录
+		if now.Sub(t) >= n.cfg.Dedup {
+			delete(n.seen, k)
+		}
+	}
+	n.seen[key] = now
+	suppressed := n.suppressed
+	n.suppressed = 0
+	return true, suppressed
+}
+
+func (n *errorNotifier) notify(ctx *Ctx, herr *HandlerError) {
+	name := "未知"
+	if herr.Matcher != nil {
+		if s := matcherName(herr.Matcher); s != "" {
+			name = s
+		}
+	}
+	ok, suppressed := n.allow(name+"\x00"+herr.Stage+"\x00"+herr.Error(), time.Now())
+	if !ok {
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("[ZeroBot] " + name + " 在 " + herr.Stage + " 阶段出错\n")
+	sb.WriteString("错误: " + herr.Error())
+	bot := ctx
+	if e := ctx.Event; e != nil {
+		if e.GroupID != 0 {
+			sb.WriteString("\n群: " + strconv.FormatInt(e.GroupID, 10))
+		}
+		if e.UserID != 0 {
+			sb.WriteString("\n用户: " + strconv.FormatInt(e.UserID, 10))
+		}
+		if e.RawMessage != "" {
+			sb.WriteString("\n消息: " + e.RawMessage)
+		}
+		// 事件的 Ctx 可能已因超时被取消, 使用新的 Ctx 发送
+		if b := GetBot(e.SelfID); b != nil {
+			bot = b
+		}
+	}
+	if suppressed > 0 {
+		sb.WriteString("\n(此前另有 " + strconv.Itoa(suppressed) + " 个错误未通知)")
+	}
+	if n.cfg.Stack && len(herr.Stack) > 0 {
+		stack := helper.BytesToString(herr.Stack)
+		if len(stack) > maxNotifyStack {
+			stack = stack[:maxNotifyStack] + "..."
+		}
+		sb.WriteString("\n" + stack)
+	}
+	msg := message.Message{message.Text(sb.String())}
+	for _, su := range BotConfig.SuperUsers {
+		bot.SendPrivateMessage(su, msg)
+	}
+}
@@ -0,0 +1,91 @@
+package zero_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+var errBoom = errors.New("boom")
+
+func TestErrorHook(t *testing.T) {
+	e := zerotest.Engine(t)
+	var errs []*zero.HandlerError
+	e.UseErrorHook(func(ctx *zero.Ctx, err *zero.HandlerError) {
+		errs = append(errs, err)
+	}, func(*zero.Ctx, *zero.HandlerError) {
+		panic("hook panic") // 钩子的 panic 不影响之后的钩子
+	}, func(ctx *zero.Ctx, err *zero.HandlerError) {
+		ctx.Send("sorry: " + err.Error())
+	})
+	e.OnCommand("fail").HandleE(func(ctx *zero.Ctx) error {
+		return errBoom
+	})
+	e.OnCommand("panic").Handle(func(ctx *zero.Ctx) {
+		panic("oops")
+	})
+	e.OnCommand("ok").HandleE(func(ctx *zero.Ctx) error {
+		ctx.Send("fine")
+		return nil
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	bot.GroupMessage(1, 2, "/fail")
+	bot.GroupMessage(1, 2, "/panic")
+	bot.GroupMessage(1, 2, "/ok")
+	bot.AssertSent(t, "sorry: boom", "sorry: panic: oops", "fine")
+
+	if len(errs) != 2 {
+		t.Fatalf("hook called %d times, want 2", len(errs))
+	}
+	if !errors.Is(errs[0], errBoom) || errs[0].Stage != "Handler" || errs[0].Panic != nil {
+		t.Fatalf("HandleE error reported as %+v", errs[0])
+	}
+	if errs[1].Panic != "oops" || errs[1].Stage != "Handler" || len(errs[1].Stack) == 0 {
+		t.Fatalf("panic reported as %+v", errs[1])
+	}
+}
+
+func TestNotifySuperUsers(t *testing.T) {
+	e := zerotest.Engine(t)
+	e.UseErrorHook(zero.NotifySuperUsers(zero.NotifyConfig{Interval: 200 * time.Millisecond, Burst: 2}))
+	e.OnCommand("fail").HandleE(func(ctx *zero.Ctx) error {
+		return errors.New("boom " + ctx.State["args"].(string))
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/", SuperUsers: []int64{9}})
+
+	bot.GroupMessage(1, 2, "/fail a")
+	bot.GroupMessage(1, 2, "/fail a") // 相同的错误只通知一次
+	bot.GroupMessage(1, 2, "/fail b")
+	bot.GroupMessage(1, 2, "/fail c") // 超出 Burst
+	sent := bot.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d notifications %q, want 2", len(sent), bot.SentTexts())
+	}
+	for _, s := range sent {
+		if s.UserID != 9 || s.GroupID != 0 {
+			t.Fatalf("notification sent to group %d user %d, want user 9", s.GroupID, s.UserID)
+		}
+	}
+	texts := bot.SentTexts()
+	if !strings.Contains(texts[0], "错误: boom a") || !strings.Contains(texts[0], "群: 1") || !strings.Contains(texts[0], "消息: /fail a") {
+		t.Fatalf("first notification %q", texts[0])
+	}
+	if !strings.Contains(texts[1], "错误: boom b") || !strings.Contains(texts[1], "此前另有 1 个错误未通知") {
+		t.Fatalf("second notification %q", texts[1])
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	bot.Reset()
+	bot.GroupMessage(1, 2, "/fail d")
+	texts = bot.SentTexts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "错误: boom d") || !strings.Contains(texts[0], "此前另有 1 个错误未通知") {
+		t.Fatalf("notifications after interval %q", texts)
+	}
+}
@@ -1,5 +1,11 @@
 package zero
 
+import (
+	"context"
+	"sync"
+	"time"
+)
+
 // FutureEvent 是 ZeroBot 交互式的核心，用于异步获取指定事件
 type FutureEvent struct {
 	Type     string
@@ -30,56 +36,131 @@ func (m *Matcher) FutureEvent(typ string, rule ...Rule) *FutureEvent {
 	}
 }
 
-// Next 返回一个 chan 用于接收下一个指定事件
-//
-// 该 chan 必须接收，如需手动取消监听，请使用 Repeat 方法
-func (n *FutureEvent) Next() <-chan *Ctx {
-	ch := make(chan *Ctx, 1)
-	StoreTempMatcher(&Matcher{
+// futureChan 只接收一个事件的 chan, 可被 Matcher.Delete 关闭
+type futureChan struct {
+	mu     sync.Mutex
+	ch     chan *Ctx
+	done   chan struct{} // 与 ch 同时关闭, 供内部等待
+	closed bool
+}
+
+// send 发送事件的副本并关闭, 已关闭时丢弃
+func (f *futureChan) send(ctx *Ctx) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+	f.ch <- ctx.detach()
+	close(f.ch)
+	close(f.done)
+	f.closed = true
+}
+
+// close 不发送事件直接关闭
+func (f *futureChan) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.closed {
+		close(f.ch)
+		close(f.done)
+		f.closed = true
+	}
+}
+
+// next 注册接收下一个事件的临时 Matcher
+func (n *FutureEvent) next() (*futureChan, *Matcher) {
+	f := &futureChan{ch: make(chan *Ctx, 1), done: make(chan struct{})}
+	m := &Matcher{
 		Type:     Type(n.Type),
 		Block:    n.Block,
 		Priority: n.Priority,
 		Rules:    n.Rule,
 		Engine:   defaultEngine,
-		Handler: func(ctx *Ctx) {
-			ch <- ctx
-			close(ch)
-		},
-	})
-	return ch
+		Handler:  f.send,
+		onDelete: f.close,
+	}
+	StoreTempMatcher(m)
+	return f, m
+}
+
+// Next 返回一个 chan 用于接收下一个指定事件
+//
+// 该 chan 必须接收，如需手动取消监听，请使用 NextContext 或 Repeat 方法.
+// 临时 Matcher 被移除时 chan 将被关闭, 此时接收到 nil
+func (n *FutureEvent) Next() <-chan *Ctx {
+	f, _ := n.next()
+	return f.ch
+}
+
+// NextContext 同 Next, c 结束时移除临时 Matcher 并关闭 chan
+func (n *FutureEvent) NextContext(c context.Context) <-chan *Ctx {
+	f, m := n.next()
+	if c.Done() == nil {
+		return f.ch
+	}
+	go func() {
+		select {
+		case <-c.Done():
+			m.Delete()
+		case <-f.done:
+		}
+	}()
+	return f.ch
+}
+
+// NextTimeout 同 Next, 超过 d 未收到事件时移除临时 Matcher 并关闭 chan
+func (n *FutureEvent) NextTimeout(d time.Duration) <-chan *Ctx {
+	f, m := n.next()
+	t := time.AfterFunc(d, m.Delete)
+	go func() {
+		<-f.done
+		t.Stop()
+	}()
+	return f.ch
 }
 
 // Repeat 返回一个 chan 用于接收无穷个指定事件，和一个取消监听的函数
 //
-// 如果没有取消监听，将不断监听指定事件
+// 如果没有取消监听，将不断监听指定事件; 取消后 chan 将被关闭
 func (n *FutureEvent) Repeat() (recv <-chan *Ctx, cancel func()) {
 	ch, done := make(chan *Ctx, 1), make(chan struct{})
+	in := make(chan *Ctx, 1)
+	matcher := StoreMatcher(&Matcher{
+		Type:     Type(n.Type),
+		Block:    n.Block,
+		Priority: n.Priority,
+		Rules:    n.Rule,
+		Engine:   defaultEngine,
+		Handler: func(ctx *Ctx) {
+			select {
+			case in <- ctx.detach():
+			case <-done: // 已取消, 不再转发
+			}
+		},
+	})
 	go func() {
 		defer close(ch)
-		in := make(chan *Ctx, 1)
-		matcher := StoreMatcher(&Matcher{
-			Type:     Type(n.Type),
-			Block:    n.Block,
-			Priority: n.Priority,
-			Rules:    n.Rule,
-			Engine:   defaultEngine,
-			Handler: func(ctx *Ctx) {
-				in <- ctx
-			},
-		})
+		defer matcher.Delete()
 		for {
 			select {
 			case e := <-in:
-				ch <- e
+				select {
+				case ch <- e:
+				case <-done:
+					return
+				}
 			case <-done:
-				matcher.Delete()
-				close(in)
 				return
 			}
 		}
 	}()
+	var once sync.Once
 	return ch, func() {
-		close(done)
+		once.Do(func() {
+			close(done)
+			matcher.Delete() // 返回前移除, 之后的事件不再被拦截
+		})
 	}
 }
 
@@ -0,0 +1,128 @@
+package zero_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+// wait 等待 done 关闭, 超过 5s 时失败
+func wait(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler did not finish")
+	}
+}
+
+// argDuration 以命令参数为等待时间
+func argDuration(ctx *zero.Ctx) time.Duration {
+	d, err := time.ParseDuration(ctx.State["args"].(string))
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestNextTimeout(t *testing.T) {
+	e := zerotest.Engine(t)
+	e.OnCommand("wait").SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		next := ctx.FutureEvent("message", ctx.CheckSession()).NextTimeout(argDuration(ctx))
+		ctx.Send("say something")
+		if reply := <-next; reply != nil {
+			ctx.Send("got " + reply.Event.RawMessage)
+		} else {
+			ctx.Send("closed")
+		}
+	})
+	e.OnCommand("cancel").SetBlock(true).Handle(func(ctx *zero.Ctx) {
+		c, cancel := context.WithCancel(context.Background())
+		next := ctx.FutureEvent("message", ctx.CheckSession()).NextContext(c)
+		cancel()
+		if <-next == nil {
+			ctx.Send("canceled")
+		}
+	})
+	e.OnKeyword("hi").Handle(func(ctx *zero.Ctx) {
+		ctx.Send("hey")
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	// 超时远长于测试, 回复必然先到
+	done := bot.Go(bot.GroupEvent(1, 2, "/wait 1h"))
+	bot.WaitSent(t, 1)
+	bot.GroupMessage(1, 2, "hi")
+	wait(t, done)
+	bot.AssertSent(t, "say something", "got hi")
+
+	// 超时结束后才发送消息, 临时 Matcher 已被移除, 消息照常处理
+	bot.Reset()
+	wait(t, bot.Go(bot.GroupEvent(1, 2, "/wait 1ms")))
+	bot.GroupMessage(1, 2, "hi")
+	bot.AssertSent(t, "say something", "closed", "hey")
+
+	bot.Reset()
+	bot.GroupMessage(1, 2, "/cancel")
+	bot.GroupMessage(1, 2, "hi")
+	bot.AssertSent(t, "canceled", "hey")
+}
+
+func TestGetWithTimeout(t *testing.T) {
+	e := zerotest.Engine(t)
+	e.OnCommand("name").Handle(func(ctx *zero.Ctx) {
+		if name, ok := ctx.GetWithTimeout("name?", argDuration(ctx)); ok {
+			ctx.Send("hello " + name)
+		} else {
+			ctx.Send("too slow")
+		}
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	done := bot.Go(bot.GroupEvent(1, 2, "/name 1h"))
+	bot.WaitSent(t, 1)
+	bot.GroupMessage(1, 2, "alice")
+	wait(t, done)
+	bot.AssertSent(t, "name?", "hello alice")
+
+	bot.Reset()
+	wait(t, bot.Go(bot.GroupEvent(1, 2, "/name 1ms")))
+	bot.AssertSent(t, "name?", "too slow")
+}
+
+func TestFutureEventDetached(t *testing.T) {
+	e := zerotest.Engine(t)
+	moved, checked := make(chan struct{}), make(chan struct{})
+	e.OnCommand("wait").Handle(func(ctx *zero.Ctx) {
+		next := ctx.FutureEvent("message", ctx.CheckSession(), zero.KeywordRule("hi")).Next()
+		ctx.Send("say something")
+		reply := <-next
+		<-moved // 回复的事件已交给后续 Matcher 处理, 其 State 已被清空
+		keyword, _ := reply.State["keyword"].(string)
+		err := reply.Context().Err()
+		reply.Block() // 不影响回复的事件
+		defer close(checked)
+		if err != nil {
+			ctx.Send("canceled: " + err.Error())
+			return
+		}
+		reply.Send("got " + keyword)
+	})
+	e.OnMessage(func(ctx *zero.Ctx) bool { return ctx.Event.RawMessage == "hi" }).SetPriority(10).Handle(func(ctx *zero.Ctx) {
+		close(moved)
+		<-checked
+		ctx.Send("hey")
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+	done := bot.Go(bot.GroupEvent(1, 2, "/wait"))
+	bot.WaitSent(t, 1)
+	bot.GroupMessage(1, 2, "hi")
+	wait(t, done)
+	bot.AssertSent(t, "say something", "got hi", "hey")
+}
@@ -9,12 +9,13 @@ import (
 // ShellRule Example
 // 本插件仅作为演示
 // Note: 只有带 flag 的Tag的字段才会注册,
-// 支支持 bool, int, string, float64 四种类型
+// 支持 bool, int, int64, uint, uint64, string, float64, time.Duration
+// 及其切片类型 (bool 除外), 可用 help tag 填写选项说明
 
 type Ping struct {
-	T       bool   `flag:"t"`
-	Timeout int    `flag:"w"`
-	Host    string `flag:"host"`
+	T       bool   `flag:"t" help:"持续 ping 直至手动停止"`
+	Timeout int    `flag:"w" help:"超时时间 (秒)"`
+	Host    string `flag:"host" help:"目标主机"`
 }
 
 func init() {
@@ -0,0 +1,100 @@
+package zero
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	log "github.com/cubevlmu/CZeroBot/log"
+)
+
+// DriverState Driver 的连接状态
+type DriverState int32
+
+const (
+	// DriverClosed 未连接或已关闭
+	DriverClosed DriverState = iota
+	// DriverConnecting 正在进行首次连接
+	DriverConnecting
+	// DriverConnected 已连接
+	DriverConnected
+	// DriverReconnecting 连接断开, 正在重连
+	DriverReconnecting
+)
+
+// String impls fmt.Stringer
+func (s DriverState) String() string {
+	switch s {
+	case DriverClosed:
+		return "closed"
+	case DriverConnecting:
+		return "connecting"
+	case DriverConnected:
+		return "connected"
+	case DriverReconnecting:
+		return "reconnecting"
+	default:
+		return "unknown(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// ConnectionEvent Driver 中某个账号的连接或断开
+type ConnectionEvent struct {
+	SelfID    int64
+	Connected bool      // true 为连接, false 为断开
+	Caller    APICaller // 该账号对应的 APICaller, 断开后调用将失败
+}
+
+// LifecycleDriver 支持生命周期管理的 Driver
+//
+// Run 时将订阅其连接事件, 并以 post_type 为 meta_event,
+// meta_event_type 为 DriverMetaEventType 的元事件分发给 matcher
+type LifecycleDriver interface {
+	Driver
+	io.Closer
+	// State 当前连接状态
+	State() DriverState
+	// Subscribe 订阅连接事件, 返回取消订阅的函数
+	Subscribe(func(ConnectionEvent)) (cancel func())
+}
+
+// Driver 连接状态变化时分发的元事件
+//
+//	OnMetaEvent(Type("meta_event/" + DriverMetaEventType + "/" + DriverConnectSubType))
+const (
+	DriverMetaEventType     = "driver"
+	DriverConnectSubType    = "connect"
+	DriverDisconnectSubType = "disconnect"
+)
+
+// subscribeDrivers 订阅所有 LifecycleDriver 的连接事件并转为元事件交给 linkf
+func subscribeDrivers(drivers []Driver, linkf func([]byte, APICaller)) (cancels []func()) {
+	for _, driver := range drivers {
+		ld, ok := driver.(LifecycleDriver)
+		if !ok {
+			continue
+		}
+		cancels = append(cancels, ld.Subscribe(func(ev ConnectionEvent) {
+			log.With(log.SelfID(ev.SelfID)).Debugf("[bot] driver connection event, self id: %d, connected: %v", ev.SelfID, ev.Connected)
+			linkf(ev.metaEvent(), ev.Caller)
+		}))
+	}
+	return
+}
+
+// metaEvent 生成对应的元事件
+func (ev *ConnectionEvent) metaEvent() []byte {
+	subType := DriverDisconnectSubType
+	if ev.Connected {
+		subType = DriverConnectSubType
+	}
+	b := make([]byte, 0, 128)
+	b = append(b, `{"time":`...)
+	b = strconv.AppendInt(b, time.Now().Unix(), 10)
+	b = append(b, `,"self_id":`...)
+	b = strconv.AppendInt(b, ev.SelfID, 10)
+	b = append(b, `,"post_type":"meta_event","meta_event_type":"`+DriverMetaEventType+`","sub_type":"`...)
+	b = append(b, subType...)
+	b = append(b, `"}`...)
+	return b
+}
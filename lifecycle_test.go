@@ -0,0 +1,96 @@
+package zero_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+// lifecycleDriver Connect 时发出连接事件的 LifecycleDriver
+type lifecycleDriver struct {
+	mu    sync.Mutex
+	subs  []func(zero.ConnectionEvent)
+	state zero.DriverState
+	done  chan struct{}
+}
+
+func (d *lifecycleDriver) Connect() {
+	d.mu.Lock()
+	d.state = zero.DriverConnected
+	subs := d.subs
+	d.mu.Unlock()
+	for _, f := range subs {
+		f(zero.ConnectionEvent{SelfID: zerotest.SelfID, Connected: true})
+	}
+}
+
+func (d *lifecycleDriver) Listen(func([]byte, zero.APICaller)) { <-d.done }
+
+func (d *lifecycleDriver) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.state = zero.DriverClosed
+	close(d.done)
+	return nil
+}
+
+func (d *lifecycleDriver) State() zero.DriverState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state
+}
+
+func (d *lifecycleDriver) Subscribe(f func(zero.ConnectionEvent)) func() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subs = append(d.subs, f)
+	return func() {}
+}
+
+func TestDriverMetaEvent(t *testing.T) {
+	e := zerotest.Engine(t)
+	got := make(chan *zero.Event, 1)
+	e.OnMetaEvent(func(ctx *zero.Ctx) bool {
+		return ctx.Event.MetaEventType == zero.DriverMetaEventType
+	}).Handle(func(ctx *zero.Ctx) {
+		got <- ctx.Event
+	})
+
+	d := &lifecycleDriver{done: make(chan struct{})}
+	zero.Run(&zero.Config{Driver: []zero.Driver{d}})
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = zero.Stop(ctx)
+	}()
+
+	select {
+	case ev := <-got:
+		if ev.SelfID != zerotest.SelfID || ev.SubType != zero.DriverConnectSubType {
+			t.Fatalf("driver meta event %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no driver meta event")
+	}
+	if s := d.State(); s != zero.DriverConnected {
+		t.Fatalf("state %v", s)
+	}
+}
+
+func TestDriverStateString(t *testing.T) {
+	for s, want := range map[zero.DriverState]string{
+		zero.DriverClosed:       "closed",
+		zero.DriverConnecting:   "connecting",
+		zero.DriverConnected:    "connected",
+		zero.DriverReconnecting: "reconnecting",
+		zero.DriverState(9):     "unknown(9)",
+	} {
+		if got := s.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", s, got, want)
+		}
+	}
+}
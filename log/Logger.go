@@ -2,10 +2,68 @@ package log
 
 import (
 	"fmt"
-	log "github.com/sirupsen/logrus"
 	"os"
+	"strings"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
 )
 
+// Level 日志级别
+type Level int32
+
+const (
+	TraceLevel Level = iota
+	DebugLevel
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+func (l Level) String() string {
+	switch l {
+	case TraceLevel:
+		return "trace"
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warning"
+	case ErrorLevel:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int32(l))
+}
+
+// ParseLevel 解析 trace, debug, info, warn(ing), error
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "trace":
+		return TraceLevel, nil
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return 0, fmt.Errorf("log: unknown level %q", s)
+}
+
+// Logger 结构化日志的输出
+type Logger interface {
+	Log(level Level, msg string, fields []Field)
+}
+
+// LevelEnabler 可由 Logger 实现, 用于在格式化前跳过后端不输出的级别
+type LevelEnabler interface {
+	Enabled(level Level) bool
+}
+
+// ILogger 旧的日志接口, 字段将以 key=value 追加在消息后
 type ILogger interface {
 	Info(tmp string)
 	Error(tmp string)
@@ -36,57 +94,208 @@ func (d DefaultLogger) Trace(tmp string) {
 	log.Trace(tmp)
 }
 
-var sharedLogger ILogger = DefaultLogger{}
+// legacyLogger 将 ILogger 作为 Logger 使用
+type legacyLogger struct {
+	ILogger
+}
+
+func (l legacyLogger) Log(level Level, msg string, fields []Field) {
+	if len(fields) > 0 {
+		var sb strings.Builder
+		sb.WriteString(msg)
+		for _, f := range fields {
+			sb.WriteByte(' ')
+			sb.WriteString(f.Key)
+			sb.WriteByte('=')
+			sb.WriteString(f.String())
+		}
+		msg = sb.String()
+	}
+	switch level {
+	case TraceLevel:
+		l.Trace(msg)
+	case DebugLevel:
+		l.Debug(msg)
+	case InfoLevel:
+		l.Info(msg)
+	case WarnLevel:
+		l.Warn(msg)
+	default:
+		l.Error(msg)
+	}
+}
+
+// pointerLogger 在输出时读取 sharedILogger, 使通过 GetDefaultLogger 的指针写入的 ILogger 生效
+type pointerLogger struct{}
+
+func (pointerLogger) Log(level Level, msg string, fields []Field) {
+	legacyLogger{sharedILogger}.Log(level, msg, fields)
+}
+
+// loggerHolder 使 atomic.Value 中存储的类型一致
+type loggerHolder struct {
+	Logger
+}
+
+var (
+	sharedLogger  atomic.Value // loggerHolder
+	sharedILogger ILogger
+	// minLevel 低于此级别的日志不输出
+	minLevel atomic.Int32
+)
+
+func init() {
+	SetLogger(NewLogrus(nil))
+}
+
+// SetLogger 设置日志的输出, 如 NewLogrus, NewSlog
+func SetLogger(logger Logger) {
+	sharedLogger.Store(loggerHolder{logger})
+}
+
+// GetLogger 获取日志的输出
+func GetLogger() Logger {
+	return sharedLogger.Load().(loggerHolder).Logger
+}
 
+// SetDefaultLogger 使用旧的 ILogger 输出日志
+//
+// Deprecated: 使用 SetLogger
 func SetDefaultLogger(logger ILogger) {
-	sharedLogger = logger
+	sharedILogger = logger
+	SetLogger(legacyLogger{logger})
 }
 
+// GetDefaultLogger 获取旧的 ILogger 的指针, 未设置时为 DefaultLogger
+//
+// 调用后日志改由该指针指向的 ILogger 输出, 通过其写入的 ILogger 立即生效, 直至调用 SetLogger
+//
+// Deprecated: 使用 GetLogger 与 SetLogger
 func GetDefaultLogger() *ILogger {
-	if sharedLogger == nil {
-		sharedLogger = DefaultLogger{}
+	if sharedILogger == nil {
+		sharedILogger = DefaultLogger{}
 	}
-	return &sharedLogger
+	SetLogger(pointerLogger{})
+	return &sharedILogger
 }
 
+// SetLevel 设置输出的最低级别, 可在运行时调整, 默认为 TraceLevel 即由后端决定
+func SetLevel(level Level) {
+	minLevel.Store(int32(level))
+}
+
+// GetLevel 获取输出的最低级别
+func GetLevel() Level {
+	return Level(minLevel.Load())
+}
+
+// Enabled level 级别的日志是否会被输出
+func Enabled(level Level) bool {
+	return enabled(GetLogger(), level)
+}
+
+func enabled(l Logger, level Level) bool {
+	if level < GetLevel() {
+		return false
+	}
+	if e, ok := l.(LevelEnabler); ok {
+		return e.Enabled(level)
+	}
+	return true
+}
+
+// Entry 带有字段的日志
+type Entry struct {
+	fields []Field
+}
+
+// With 生成带有 fields 的日志
+//
+//	log.With(log.SelfID(id), log.Action(action)).Warningf("[ws] failed to send api call: %v", err)
+func With(fields ...Field) *Entry {
+	return &Entry{fields: fields}
+}
+
+// With 追加字段
+func (e *Entry) With(fields ...Field) *Entry {
+	all := make([]Field, 0, len(e.fields)+len(fields))
+	all = append(all, e.fields...)
+	return &Entry{fields: append(all, fields...)}
+}
+
+func (e *Entry) log(level Level, msg string) {
+	if l := GetLogger(); enabled(l, level) {
+		l.Log(level, msg, e.fields)
+	}
+}
+
+func (e *Entry) logf(level Level, msg string, args []interface{}) {
+	if l := GetLogger(); enabled(l, level) {
+		l.Log(level, fmt.Sprintf(msg, args...), e.fields)
+	}
+}
+
+func (e *Entry) Trace(msg string) { e.log(TraceLevel, msg) }
+
+func (e *Entry) Debug(msg string) { e.log(DebugLevel, msg) }
+
+func (e *Entry) Info(msg string) { e.log(InfoLevel, msg) }
+
+func (e *Entry) Warning(msg string) { e.log(WarnLevel, msg) }
+
+func (e *Entry) Error(msg string) { e.log(ErrorLevel, msg) }
+
+func (e *Entry) Tracef(msg string, args ...interface{}) { e.logf(TraceLevel, msg, args) }
+
+func (e *Entry) Debugf(msg string, args ...interface{}) { e.logf(DebugLevel, msg, args) }
+
+func (e *Entry) Infof(msg string, args ...interface{}) { e.logf(InfoLevel, msg, args) }
+
+func (e *Entry) Warningf(msg string, args ...interface{}) { e.logf(WarnLevel, msg, args) }
+
+func (e *Entry) Errorf(msg string, args ...interface{}) { e.logf(ErrorLevel, msg, args) }
+
+// std 不带字段的日志
+var std = &Entry{}
+
 func Infof(msg string, args ...interface{}) {
-	sharedLogger.Info(fmt.Sprintf(msg, args...))
+	std.logf(InfoLevel, msg, args)
 }
 
 func Warningf(msg string, args ...interface{}) {
-	sharedLogger.Warn(fmt.Sprintf(msg, args...))
+	std.logf(WarnLevel, msg, args)
 }
 
 func Errorf(msg string, args ...interface{}) {
-	sharedLogger.Error(fmt.Sprintf(msg, args...))
+	std.logf(ErrorLevel, msg, args)
 }
 
 func Debugf(msg string, args ...interface{}) {
-	sharedLogger.Debug(fmt.Sprintf(msg, args...))
+	std.logf(DebugLevel, msg, args)
 }
 
 func Tracef(msg string, args ...interface{}) {
-	sharedLogger.Trace(fmt.Sprintf(msg, args...))
+	std.logf(TraceLevel, msg, args)
 }
 
 func Info(msg string) {
-	sharedLogger.Info(msg)
+	std.log(InfoLevel, msg)
 }
 
 func Warning(msg string) {
-	sharedLogger.Warn(msg)
+	std.log(WarnLevel, msg)
 }
 
 func Error(msg string) {
-	sharedLogger.Error(msg)
+	std.log(ErrorLevel, msg)
 }
 
 func Trace(msg string) {
-	sharedLogger.Trace(msg)
+	std.log(TraceLevel, msg)
 }
 
 func Debug(msg string) {
-	sharedLogger.Debug(msg)
+	std.log(DebugLevel, msg)
 }
 
 func Fatal(err error) {
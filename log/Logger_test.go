@@ -0,0 +1,163 @@
+package log
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// entry 记录的一条日志
+type entry struct {
+	level  Level
+	msg    string
+	fields []Field
+}
+
+// recorder 记录日志的 Logger, min 以下的级别视为不输出
+type recorder struct {
+	min     Level
+	entries []entry
+}
+
+func (r *recorder) Log(level Level, msg string, fields []Field) {
+	r.entries = append(r.entries, entry{level: level, msg: msg, fields: fields})
+}
+
+func (r *recorder) Enabled(level Level) bool {
+	return level >= r.min
+}
+
+// use 在测试期间以 l 输出日志
+func use(t *testing.T, l Logger) {
+	old, level := GetLogger(), GetLevel()
+	SetLogger(l)
+	t.Cleanup(func() {
+		SetLogger(old)
+		SetLevel(level)
+	})
+}
+
+func TestEntryFields(t *testing.T) {
+	r := &recorder{}
+	use(t, r)
+	base := With(SelfID(1))
+	base.With(Action("send_msg")).Warningf("failed %d", 2)
+	base.Info("plain")
+	Errorf("no %s", "fields")
+	want := []entry{
+		{WarnLevel, "failed 2", []Field{SelfID(1), Action("send_msg")}},
+		{InfoLevel, "plain", []Field{SelfID(1)}},
+		{ErrorLevel, "no fields", nil},
+	}
+	if len(r.entries) != len(want) {
+		t.Fatalf("logged %d entries, want %d", len(r.entries), len(want))
+	}
+	for i, w := range want {
+		e := r.entries[i]
+		if e.level != w.level || e.msg != w.msg || len(e.fields) != len(w.fields) {
+			t.Fatalf("entry %d is %+v, want %+v", i, e, w)
+		}
+		for j := range w.fields {
+			if e.fields[j] != w.fields[j] {
+				t.Errorf("entry %d field %d is %+v, want %+v", i, j, e.fields[j], w.fields[j])
+			}
+		}
+	}
+}
+
+func TestLevelFilter(t *testing.T) {
+	r := &recorder{min: DebugLevel}
+	use(t, r)
+	Trace("dropped by logger")
+	Debug("kept")
+	SetLevel(WarnLevel)
+	Info("dropped by SetLevel")
+	Warning("kept")
+	if len(r.entries) != 2 || r.entries[0].level != DebugLevel || r.entries[1].level != WarnLevel {
+		t.Fatalf("logged %+v", r.entries)
+	}
+	if Enabled(InfoLevel) || !Enabled(ErrorLevel) {
+		t.Fatal("Enabled does not follow SetLevel")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for s, want := range map[string]Level{"trace": TraceLevel, "DEBUG": DebugLevel, "info": InfoLevel, "warn": WarnLevel, "warning": WarnLevel, "error": ErrorLevel} {
+		if got, err := ParseLevel(s); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v", s, got, err)
+		}
+	}
+	if _, err := ParseLevel("fatal"); err == nil {
+		t.Error("ParseLevel accepted an unknown level")
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	for _, c := range []struct {
+		f    Field
+		want string
+	}{
+		{UserID(42), "42"},
+		{Stage("rule"), "rule"},
+		{Latency(1500 * time.Millisecond), "1.5s"},
+		{Err(errors.New("boom")), "boom"},
+		{Any("n", 3), "3"},
+	} {
+		if got := c.f.String(); got != c.want {
+			t.Errorf("%s.String() = %q, want %q", c.f.Key, got, c.want)
+		}
+	}
+}
+
+// lines 记录的旧接口日志
+type lines []string
+
+func (l *lines) Info(s string)  { *l = append(*l, "info "+s) }
+func (l *lines) Error(s string) { *l = append(*l, "error "+s) }
+func (l *lines) Warn(s string)  { *l = append(*l, "warn "+s) }
+func (l *lines) Debug(s string) { *l = append(*l, "debug "+s) }
+func (l *lines) Trace(s string) { *l = append(*l, "trace "+s) }
+
+func TestLegacyLogger(t *testing.T) {
+	var l lines
+	use(t, GetLogger())
+	SetDefaultLogger(&l)
+	With(SelfID(1), Err(errors.New("boom"))).Warning("failed")
+	Debug("plain")
+	if len(l) != 2 || l[0] != "warn failed self_id=1 error=boom" || l[1] != "debug plain" {
+		t.Fatalf("legacy logger got %q", l)
+	}
+}
+
+func TestDefaultLoggerPointer(t *testing.T) {
+	var l lines
+	use(t, GetLogger())
+	p := GetDefaultLogger()
+	t.Cleanup(func() { *p = nil })
+	*p = &l
+	With(UserID(2)).Info("through pointer")
+	if len(l) != 1 || l[0] != "info through pointer user_id=2" {
+		t.Fatalf("logger set through GetDefaultLogger got %q", l)
+	}
+	SetLogger(&recorder{})
+	Info("not legacy")
+	if len(l) != 1 {
+		t.Fatalf("legacy logger still used after SetLogger: %q", l)
+	}
+}
+
+func TestSetLoggerConcurrent(t *testing.T) {
+	use(t, GetLogger())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			SetLogger(&recorder{})
+			SetLevel(Level(i % 3))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		Debug("concurrent")
+	}
+	<-done
+}
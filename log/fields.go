@@ -0,0 +1,86 @@
+package log
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// 常用字段的键
+const (
+	KeySelfID  = "self_id"
+	KeyGroupID = "group_id"
+	KeyUserID  = "user_id"
+	KeyAction  = "action"
+	KeyMatcher = "matcher"
+	KeyStage   = "stage"
+	KeyLatency = "latency"
+	KeyError   = "error"
+)
+
+// Field 日志的键值字段
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// String 字段值的文本形式
+func (f Field) String() string {
+	switch v := f.Value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case time.Duration:
+		return v.String()
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Any 任意字段
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// SelfID 机器人账号
+func SelfID(id int64) Field {
+	return Field{Key: KeySelfID, Value: id}
+}
+
+// GroupID 群号
+func GroupID(id int64) Field {
+	return Field{Key: KeyGroupID, Value: id}
+}
+
+// UserID 用户账号
+func UserID(id int64) Field {
+	return Field{Key: KeyUserID, Value: id}
+}
+
+// Action API 名
+func Action(action string) Field {
+	return Field{Key: KeyAction, Value: action}
+}
+
+// Matcher Matcher 的名称
+func Matcher(name string) Field {
+	return Field{Key: KeyMatcher, Value: name}
+}
+
+// Stage 匹配的阶段, 如 rule, Handler
+func Stage(stage string) Field {
+	return Field{Key: KeyStage, Value: stage}
+}
+
+// Latency 耗时
+func Latency(d time.Duration) Field {
+	return Field{Key: KeyLatency, Value: d}
+}
+
+// Err 错误
+func Err(err error) Field {
+	return Field{Key: KeyError, Value: err}
+}
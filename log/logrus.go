@@ -0,0 +1,50 @@
+package log
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+// logrusLogger 以 logrus 输出
+type logrusLogger struct {
+	l *logrus.Logger
+}
+
+// NewLogrus 以 l 输出日志的 Logger, l 为 nil 时使用 logrus 的 StandardLogger
+//
+// 字段作为 logrus.Fields 输出, 级别同时受 l 的级别限制
+func NewLogrus(l *logrus.Logger) Logger {
+	if l == nil {
+		l = logrus.StandardLogger()
+	}
+	return logrusLogger{l: l}
+}
+
+func (g logrusLogger) Log(level Level, msg string, fields []Field) {
+	entry := logrus.NewEntry(g.l)
+	if len(fields) > 0 {
+		data := make(logrus.Fields, len(fields))
+		for _, f := range fields {
+			data[f.Key] = f.Value
+		}
+		entry = entry.WithFields(data)
+	}
+	entry.Log(logrusLevel(level), msg)
+}
+
+func (g logrusLogger) Enabled(level Level) bool {
+	return g.l.IsLevelEnabled(logrusLevel(level))
+}
+
+func logrusLevel(level Level) logrus.Level {
+	switch level {
+	case TraceLevel:
+		return logrus.TraceLevel
+	case DebugLevel:
+		return logrus.DebugLevel
+	case InfoLevel:
+		return logrus.InfoLevel
+	case WarnLevel:
+		return logrus.WarnLevel
+	}
+	return logrus.ErrorLevel
+}
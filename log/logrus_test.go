@@ -0,0 +1,39 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLogrus(t *testing.T) {
+	var buf bytes.Buffer
+	l := logrus.New()
+	l.SetOutput(&buf)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	l.SetLevel(logrus.DebugLevel)
+	use(t, NewLogrus(l))
+
+	With(SelfID(1), Action("send_msg")).Warning("failed")
+	Trace("dropped")
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %q", err, buf.String())
+	}
+	for k, want := range map[string]interface{}{"level": "warning", "msg": "failed", "self_id": 1.0, "action": "send_msg"} {
+		if got[k] != want {
+			t.Errorf("%s = %v, want %v", k, got[k], want)
+		}
+	}
+	if Enabled(TraceLevel) || !Enabled(DebugLevel) {
+		t.Error("Enabled does not follow the logrus level")
+	}
+	for level, want := range map[Level]logrus.Level{TraceLevel: logrus.TraceLevel, DebugLevel: logrus.DebugLevel,
+		InfoLevel: logrus.InfoLevel, WarnLevel: logrus.WarnLevel, ErrorLevel: logrus.ErrorLevel} {
+		if got := logrusLevel(level); got != want {
+			t.Errorf("logrusLevel(%v) = %v, want %v", level, got, want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+//go:build go1.21
+
+package log
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLevelTrace TraceLevel 对应的 slog 级别
+const SlogLevelTrace = slog.LevelDebug - 4
+
+// slogLogger 以 log/slog 输出
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlog 以 l 输出日志的 Logger, l 为 nil 时使用 slog.Default()
+//
+// 字段作为 slog.Attr 输出, TraceLevel 对应 SlogLevelTrace.
+// 运行时调整级别可使用 slog.LevelVar 作为 Handler 的 Level
+func NewSlog(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return slogLogger{l: l}
+}
+
+func (g slogLogger) Log(level Level, msg string, fields []Field) {
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Any(f.Key, f.Value)
+	}
+	g.l.LogAttrs(context.Background(), slogLevel(level), msg, attrs...)
+}
+
+func (g slogLogger) Enabled(level Level) bool {
+	return g.l.Enabled(context.Background(), slogLevel(level))
+}
+
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case TraceLevel:
+		return SlogLevelTrace
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
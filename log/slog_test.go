@@ -0,0 +1,36 @@
+//go:build go1.21
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+	var level slog.LevelVar
+	level.Set(SlogLevelTrace)
+	use(t, NewSlog(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: &level}))))
+
+	With(GroupID(2), Stage("rule")).Trace("traced")
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("%v: %q", err, buf.String())
+	}
+	for k, want := range map[string]interface{}{"level": "DEBUG-4", "msg": "traced", "group_id": 2.0, "stage": "rule"} {
+		if got[k] != want {
+			t.Errorf("%s = %v, want %v", k, got[k], want)
+		}
+	}
+
+	// 级别可通过 LevelVar 在运行时调整
+	level.Set(slog.LevelWarn)
+	buf.Reset()
+	Info("dropped")
+	if buf.Len() != 0 || Enabled(InfoLevel) || !Enabled(WarnLevel) {
+		t.Errorf("slog level not applied, wrote %q", buf.String())
+	}
+}
@@ -3,6 +3,7 @@ package zero
 import (
 	"sort"
 	"sync"
+	"time"
 )
 
 type (
@@ -10,6 +11,8 @@ type (
 	Rule func(ctx *Ctx) bool
 	// Handler 事件处理函数
 	Handler func(ctx *Ctx)
+	// HandlerE 返回 error 的事件处理函数, 返回的 error 交给错误钩子
+	HandlerE func(ctx *Ctx) error
 )
 
 // Matcher 是 ZeroBot 匹配和处理事件的最小单元
@@ -22,6 +25,10 @@ type Matcher struct {
 	Break bool
 	// NoTimeout 处理是否不设超时
 	NoTimeout bool
+	// Timeout 各阶段的最长处理时间, 未设置的阶段使用 Engine 的设置
+	Timeout Timeout
+	// NonBlocking Rules 是否均不会阻塞, 见 SetNonBlocking
+	NonBlocking bool
 	// Priority 优先级，越小优先级越高
 	Priority int
 	// Event 当前匹配到的事件
@@ -32,8 +39,17 @@ type Matcher struct {
 	Rules []Rule
 	// Handler 处理事件的函数
 	Handler Handler
+	// HandlerE 返回 error 的处理函数, 设置时代替 Handler
+	HandlerE HandlerE
 	// Engine 注册 Matcher 的 Engine，Engine可为一系列 Matcher 添加通用 Rule 和 其他钩子
 	Engine *Engine
+
+	// onDelete 从列表中移除时调用, 用于关闭等待中的 chan
+	onDelete func()
+	// dispatch 第一个 Rule 对应的索引, 为 nil 时每个事件都需判断
+	dispatch *dispatchKey
+	// inline Rules 中前 inline 个为内置的不阻塞 Rule
+	inline int
 }
 
 var (
@@ -41,14 +57,36 @@ var (
 	matcherList = make([]*Matcher, 0)
 	// Matcher 修改读写锁
 	matcherLock = sync.RWMutex{}
-	// 用于迭代的所有主匹配器列表
-	matcherListForRanging []*Matcher
+	// 用于迭代的所有主匹配器列表及其索引
+	matcherIndexForRanging *dispatchIndex
 	// 是否 matcherList 已经改变
 	// 如果改变，下次迭代需要更新
-	// matcherListForRanging
+	// matcherIndexForRanging
 	hasMatcherListChanged bool
 )
 
+// Timeout 各阶段的最长处理时间
+//
+// 为 0 时使用上一级 (Matcher → Engine → Config.MaxProcessTime) 的设置, 小于 0 时不设超时.
+// 超时的 Matcher 被放弃, 其 ctx.Context() 被取消, 之后的 Matcher 继续匹配
+type Timeout struct {
+	Rule        time.Duration // preHandler, Rule 与 midHandler 各阶段
+	Handler     time.Duration
+	PostHandler time.Duration
+}
+
+// stage 阶段 stage 的设置
+func (t *Timeout) stage(stage string) time.Duration {
+	switch stage {
+	case "Handler":
+		return t.Handler
+	case "postHandler":
+		return t.PostHandler
+	default:
+		return t.Rule
+	}
+}
+
 // State store the context of a matcher.
 type State map[string]interface{}
 
@@ -65,6 +103,30 @@ func (m *Matcher) SetBlock(block bool) *Matcher {
 	return m
 }
 
+// SetTimeout 设置各阶段的最长处理时间
+func (m *Matcher) SetTimeout(t Timeout) *Matcher {
+	m.Timeout = t
+	return m
+}
+
+// SetMaxProcessTime 将所有阶段的最长处理时间设为 d, 小于 0 时不设超时
+func (m *Matcher) SetMaxProcessTime(d time.Duration) *Matcher {
+	return m.SetTimeout(Timeout{Rule: d, Handler: d, PostHandler: d})
+}
+
+// stageTimeout 阶段 stage 的最长处理时间, 均未设置时为 def
+func (m *Matcher) stageTimeout(stage string, def time.Duration) time.Duration {
+	if d := m.Timeout.stage(stage); d != 0 {
+		return d
+	}
+	if m.Engine != nil {
+		if d := m.Engine.timeout.stage(stage); d != 0 {
+			return d
+		}
+	}
+	return def
+}
+
 // SetPriority 设置当前 Matcher 优先级
 func (m *Matcher) SetPriority(priority int) *Matcher {
 	matcherLock.Lock()
@@ -116,26 +178,41 @@ func StoreTempMatcher(m *Matcher) *Matcher {
 }
 
 // Delete remove the matcher from list
+//
+// 由 FutureEvent 生成的 Matcher 被移除时, 其等待中的 chan 将被关闭
 func (m *Matcher) Delete() {
 	matcherLock.Lock()
-	defer matcherLock.Unlock()
+	deleted := false
 	for i, matcher := range matcherList {
 		if m == matcher {
 			matcherList = append(matcherList[:i], matcherList[i+1:]...)
 			hasMatcherListChanged = true
+			deleted = true
 		}
 	}
+	matcherLock.Unlock()
+	if deleted && m.onDelete != nil {
+		m.onDelete()
+	}
 }
 
 func (m *Matcher) copy() *Matcher {
+	matcherLock.RLock()
+	rules := m.Rules // 注册后仍可能被 Limit 修改
+	matcherLock.RUnlock()
 	return &Matcher{
-		Type:     m.Type,
-		Rules:    m.Rules,
-		Block:    m.Block,
-		Priority: m.Priority,
-		Handler:  m.Handler,
-		Temp:     m.Temp,
-		Engine:   m.Engine,
+		Type:        m.Type,
+		Rules:       rules,
+		Block:       m.Block,
+		NoTimeout:   m.NoTimeout,
+		NonBlocking: m.NonBlocking,
+		Timeout:     m.Timeout,
+		Priority:    m.Priority,
+		Handler:     m.Handler,
+		HandlerE:    m.HandlerE,
+		Temp:        m.Temp,
+		Engine:      m.Engine,
+		inline:      m.inline,
 	}
 }
 
@@ -144,3 +221,28 @@ func (m *Matcher) Handle(handler Handler) *Matcher {
 	m.Handler = handler
 	return m
 }
+
+// HandleE 以返回 error 的 handler 处理事件, 见 UseErrorHook
+func (m *Matcher) HandleE(handler HandlerE) *Matcher {
+	m.HandlerE = handler
+	return m
+}
+
+// handler 返回 m 的处理函数, 均未设置时为 nil
+func (m *Matcher) handler() HandlerE {
+	if m.HandlerE != nil {
+		return m.HandlerE
+	}
+	if m.Handler != nil {
+		return handlerE(m.Handler)
+	}
+	return nil
+}
+
+// handlerE 将 h 转为 HandlerE
+func handlerE(h Handler) HandlerE {
+	return func(ctx *Ctx) error {
+		h(ctx)
+		return nil
+	}
+}
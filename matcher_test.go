@@ -0,0 +1,46 @@
+package zero_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestTimeoutAbandonAndContinue(t *testing.T) {
+	e := zerotest.Engine(t)
+	abandoned := make(chan error, 1)
+	e.OnCommand("x").SetPriority(1).SetTimeout(zero.Timeout{Handler: 20 * time.Millisecond}).
+		Handle(func(ctx *zero.Ctx) {
+			<-ctx.Context().Done()
+			ctx.Send("late") // 超时后的 API 调用被取消
+			abandoned <- ctx.Context().Err()
+		})
+	var done *zero.Ctx
+	e.OnCommand("x").SetPriority(2).Handle(func(ctx *zero.Ctx) {
+		done = ctx
+		ctx.Send("continued")
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	bot.GroupMessage(1, 2, "/x")
+	select {
+	case err := <-abandoned:
+		if err != context.Canceled {
+			t.Fatalf("abandoned matcher context err %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("abandoned matcher context not canceled")
+	}
+	bot.AssertSent(t, "continued")
+
+	// 处理完成后的 Ctx 仍可调用 API
+	if err := done.Context().Err(); err != nil {
+		t.Fatalf("finished matcher context err %v", err)
+	}
+	done.Send("after")
+	bot.AssertSent(t, "continued", "after")
+}
@@ -0,0 +1,152 @@
+package zero
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/cubevlmu/CZeroBot/log"
+	"github.com/cubevlmu/CZeroBot/metrics"
+)
+
+// botMetrics 事件, Matcher 与 API 调用的指标
+type botMetrics struct {
+	registry        *metrics.Registry
+	events          *metrics.CounterVec
+	triggered       *metrics.CounterVec
+	handlerDuration *metrics.HistogramVec
+	timeouts        *metrics.CounterVec
+	apiDuration     *metrics.HistogramVec
+	apiErrors       *metrics.CounterVec
+}
+
+var (
+	// botmetrics 未启用时为 nil
+	botmetrics    atomic.Pointer[botMetrics]
+	botmetricsMu  sync.Mutex
+	metricsServer *http.Server
+)
+
+// EnableMetrics 启用指标并返回其 Registry, 可向其中注册自定义指标
+//
+// 设置 Config.MetricsAddr 时由 Run 自动启用
+func EnableMetrics() *metrics.Registry {
+	botmetricsMu.Lock()
+	defer botmetricsMu.Unlock()
+	if m := botmetrics.Load(); m != nil {
+		return m.registry
+	}
+	m := &botMetrics{
+		registry: metrics.NewRegistry(),
+		events: metrics.NewCounterVec("zerobot_events_total",
+			"Events received, by post_type and detail_type.", "post_type", "detail_type"),
+		triggered: metrics.NewCounterVec("zerobot_matcher_triggered_total",
+			"Times a matcher passed its rules and ran its handler.", "matcher"),
+		handlerDuration: metrics.NewHistogramVec("zerobot_handler_duration_seconds",
+			"Handler latency of each matcher.", nil, "matcher"),
+		timeouts: metrics.NewCounterVec("zerobot_timeouts_total",
+			"Matchers abandoned after a stage timed out, by stage.", "matcher", "stage"),
+		apiDuration: metrics.NewHistogramVec("zerobot_api_call_duration_seconds",
+			"API call latency, by action.", nil, "action"),
+		apiErrors: metrics.NewCounterVec("zerobot_api_call_errors_total",
+			"API calls that failed or returned a non-zero retcode, by action.", "action"),
+	}
+	m.registry.MustRegister(
+		m.events, m.triggered, m.handlerDuration, m.timeouts, m.apiDuration, m.apiErrors,
+		metrics.NewGaugeFunc("zerobot_ring_occupancy", "Events waiting in the event ring.", func() float64 {
+			if r := evring.Load(); r != nil {
+				return float64(r.len())
+			}
+			return 0
+		}),
+		metrics.NewGaugeFunc("zerobot_ring_capacity", "Length of the event ring, 0 if disabled.", func() float64 {
+			if r := evring.Load(); r != nil {
+				return float64(len(r.r))
+			}
+			return 0
+		}),
+		metrics.NewGaugeFunc("zerobot_connected_accounts", "Accounts with a connected driver.", func() float64 {
+			n := 0
+			APICallers.Range(func(int64, APICaller) bool {
+				n++
+				return true
+			})
+			return float64(n)
+		}),
+	)
+	botmetrics.Store(m)
+	return m.registry
+}
+
+// MetricsHandler 以文本格式输出指标的 http.Handler, 会启用指标
+func MetricsHandler() http.Handler {
+	return EnableMetrics()
+}
+
+// serveMetrics 在 addr 的 /metrics 输出指标
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", MetricsHandler())
+	lst, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Warningf("[metrics] failed to listen at %s: %v", addr, err)
+		return
+	}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	botmetricsMu.Lock()
+	metricsServer = server
+	botmetricsMu.Unlock()
+	log.Infof("[metrics] serving metrics at http://%s/metrics", lst.Addr())
+	go func() {
+		if err := server.Serve(lst); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warningf("[metrics] server stopped: %v", err)
+		}
+	}()
+}
+
+// stopMetrics 关闭 serveMetrics 启动的服务器
+func stopMetrics() {
+	botmetricsMu.Lock()
+	server := metricsServer
+	metricsServer = nil
+	botmetricsMu.Unlock()
+	if server != nil {
+		_ = server.Close()
+	}
+}
+
+// observeEvent 记录收到的事件
+func observeEvent(e *Event) {
+	if m := botmetrics.Load(); m != nil {
+		m.events.With(e.PostType, e.DetailType).Inc()
+	}
+}
+
+// observeHandler 记录 Matcher 被触发及 Handler 的耗时
+func observeHandler(ma *Matcher, d time.Duration) {
+	if m := botmetrics.Load(); m != nil {
+		name := matcherName(ma)
+		m.triggered.With(name).Inc()
+		m.handlerDuration.With(name).Observe(d.Seconds())
+	}
+}
+
+// observeTimeout 记录超时被放弃的 Matcher
+func observeTimeout(ma *Matcher, stage string) {
+	if m := botmetrics.Load(); m != nil {
+		m.timeouts.With(matcherName(ma), stage).Inc()
+	}
+}
+
+// observeAPI 记录 API 调用
+func observeAPI(action string, d time.Duration, err error) {
+	if m := botmetrics.Load(); m != nil {
+		m.apiDuration.With(action).Observe(d.Seconds())
+		if err != nil {
+			m.apiErrors.With(action).Inc()
+		}
+	}
+}
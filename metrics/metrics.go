@@ -0,0 +1,342 @@
+// Package metrics 无外部依赖的指标, 以 Prometheus 文本格式输出
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets 默认的直方图分桶, 单位为秒
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Collector 可被 Registry 输出的指标
+type Collector interface {
+	// WriteTo 以文本格式写入 HELP, TYPE 与所有样本
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// Registry 指标的集合
+type Registry struct {
+	mu         sync.Mutex
+	collectors []Collector
+}
+
+// NewRegistry 新建空的 Registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// MustRegister 注册指标, 按注册顺序输出
+func (r *Registry) MustRegister(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, cs...)
+}
+
+// WriteTo 以文本格式写入所有指标
+func (r *Registry) WriteTo(w io.Writer) (n int64, err error) {
+	r.mu.Lock()
+	cs := make([]Collector, len(r.collectors))
+	copy(cs, r.collectors)
+	r.mu.Unlock()
+	for _, c := range cs {
+		m, err := c.WriteTo(w)
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ServeHTTP 输出所有指标, 可作为 /metrics 的 http.Handler
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// desc 指标的名称与标签
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+// header 写入 HELP 与 TYPE
+func (d *desc) header(w *bufio.Writer) {
+	w.WriteString("# HELP ")
+	w.WriteString(d.name)
+	w.WriteByte(' ')
+	w.WriteString(helpEscaper.Replace(d.help))
+	w.WriteString("\n# TYPE ")
+	w.WriteString(d.name)
+	w.WriteByte(' ')
+	w.WriteString(d.typ)
+	w.WriteByte('\n')
+}
+
+// sample 写入一个样本, extra 为额外的标签如 le
+func (d *desc) sample(w *bufio.Writer, suffix string, values []string, extraKey, extraValue string, v float64) {
+	w.WriteString(d.name)
+	w.WriteString(suffix)
+	if len(values) > 0 || extraKey != "" {
+		w.WriteByte('{')
+		for i, l := range d.labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, l, values[i])
+		}
+		if extraKey != "" {
+			if len(values) > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, extraKey, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func writeLabel(w *bufio.Writer, key, value string) {
+	w.WriteString(key)
+	w.WriteString(`="`)
+	w.WriteString(labelEscaper.Replace(value))
+	w.WriteByte('"')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter 记录写入的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// write 以 bufio 写入 f 的输出
+func write(w io.Writer, f func(*bufio.Writer)) (int64, error) {
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	f(bw)
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// vec 按标签值区分的一组序列
+type vec[T any] struct {
+	desc
+	mu     sync.RWMutex
+	series map[string]*T
+	values map[string][]string
+	newT   func() *T
+}
+
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic("metrics: " + v.name + ": wrong number of label values")
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok = v.series[key]; !ok {
+		s = v.newT()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// each 按标签值顺序遍历
+func (v *vec[T]) each(f func(values []string, s *T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	series := make([]*T, len(keys))
+	values := make([][]string, len(keys))
+	for i, k := range keys {
+		series[i] = v.series[k]
+		values[i] = v.values[k]
+	}
+	v.mu.RUnlock()
+	for i := range keys {
+		f(values[i], series[i])
+	}
+}
+
+func newVec[T any](name, help, typ string, labels []string, newT func() *T) vec[T] {
+	return vec[T]{
+		desc:   desc{name: name, help: help, typ: typ, labels: labels},
+		series: make(map[string]*T),
+		values: make(map[string][]string),
+		newT:   newT,
+	}
+}
+
+// Counter 单调递增的计数
+type Counter struct {
+	bits uint64
+}
+
+// Inc 加 1
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add 加 v, v 应不小于 0
+func (c *Counter) Add(v float64) {
+	for {
+		old := atomic.LoadUint64(&c.bits)
+		if atomic.CompareAndSwapUint64(&c.bits, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Value 当前值
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+// CounterVec 按标签区分的 Counter
+type CounterVec struct {
+	vec[Counter]
+}
+
+// NewCounterVec 新建 CounterVec, name 应以 _total 结尾
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec(name, help, "counter", labels, func() *Counter { return &Counter{} })}
+}
+
+// With 标签值为 values 的 Counter
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.with(values)
+}
+
+// WriteTo 以文本格式写入
+func (v *CounterVec) WriteTo(w io.Writer) (int64, error) {
+	return write(w, func(bw *bufio.Writer) {
+		v.header(bw)
+		v.each(func(values []string, c *Counter) {
+			v.sample(bw, "", values, "", "", c.Value())
+		})
+	})
+}
+
+// Histogram 分桶统计的观测值
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64 // 与 buckets 对应, 非累计
+	count   uint64
+	sum     float64
+}
+
+// Observe 记录观测值 v
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.mu.Lock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+	h.mu.Unlock()
+}
+
+// HistogramVec 按标签区分的 Histogram
+type HistogramVec struct {
+	vec[Histogram]
+}
+
+// NewHistogramVec 新建 HistogramVec, buckets 为升序的上界, 为 nil 时使用 DefBuckets
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	return &HistogramVec{newVec(name, help, "histogram", labels, func() *Histogram {
+		return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+	})}
+}
+
+// With 标签值为 values 的 Histogram
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.with(values)
+}
+
+// WriteTo 以文本格式写入
+func (v *HistogramVec) WriteTo(w io.Writer) (int64, error) {
+	return write(w, func(bw *bufio.Writer) {
+		v.header(bw)
+		v.each(func(values []string, h *Histogram) {
+			h.mu.Lock()
+			counts := append([]uint64(nil), h.counts...)
+			count, sum := h.count, h.sum
+			h.mu.Unlock()
+			var acc uint64
+			for i, le := range h.buckets {
+				acc += counts[i]
+				v.sample(bw, "_bucket", values, "le", formatFloat(le), float64(acc))
+			}
+			v.sample(bw, "_bucket", values, "le", "+Inf", float64(count))
+			v.sample(bw, "_sum", values, "", "", sum)
+			v.sample(bw, "_count", values, "", "", float64(count))
+		})
+	})
+}
+
+// GaugeFunc 在输出时调用函数取值的 Gauge
+type GaugeFunc struct {
+	desc
+	f func() float64
+}
+
+// NewGaugeFunc 新建 GaugeFunc
+func NewGaugeFunc(name, help string, f func() float64) *GaugeFunc {
+	return &GaugeFunc{desc: desc{name: name, help: help, typ: "gauge"}, f: f}
+}
+
+// WriteTo 以文本格式写入
+func (g *GaugeFunc) WriteTo(w io.Writer) (int64, error) {
+	return write(w, func(bw *bufio.Writer) {
+		g.header(bw)
+		g.sample(bw, "", nil, "", "", g.f())
+	})
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterVecText(t *testing.T) {
+	v := NewCounterVec("test_events_total", "Events\nby type.", "type", "detail")
+	v.With("b", "2").Inc()
+	v.With("a", `q"\`+"\n").Add(2.5)
+	v.With("b", "2").Inc()
+	var sb strings.Builder
+	if _, err := v.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_events_total Events\nby type.
+# TYPE test_events_total counter
+test_events_total{type="a",detail="q\"\\\n"} 2.5
+test_events_total{type="b",detail="2"} 2
+`
+	if got := sb.String(); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+	if c := v.With("b", "2"); c.Value() != 2 {
+		t.Fatalf("Value() = %v, want 2", c.Value())
+	}
+}
+
+func TestHistogramVecText(t *testing.T) {
+	v := NewHistogramVec("test_seconds", "Latency.", []float64{0.1, 1}, "action")
+	h := v.With("send")
+	for _, x := range []float64{0.05, 0.1, 0.5, 3} {
+		h.Observe(x)
+	}
+	var sb strings.Builder
+	n, err := v.WriteTo(&sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_seconds Latency.
+# TYPE test_seconds histogram
+test_seconds_bucket{action="send",le="0.1"} 2
+test_seconds_bucket{action="send",le="1"} 3
+test_seconds_bucket{action="send",le="+Inf"} 4
+test_seconds_sum{action="send"} 3.65
+test_seconds_count{action="send"} 4
+`
+	if got := sb.String(); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+	if n != int64(len(want)) {
+		t.Fatalf("WriteTo returned %d, wrote %d bytes", n, len(want))
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	c := NewCounterVec("test_total", "Total.")
+	c.With().Inc()
+	r.MustRegister(NewGaugeFunc("test_gauge", "Gauge.", func() float64 { return 7 }), c)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	want := `# HELP test_gauge Gauge.
+# TYPE test_gauge gauge
+test_gauge 7
+# HELP test_total Total.
+# TYPE test_total counter
+test_total 1
+`
+	if got := rec.Body.String(); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWrongLabelCount(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("With accepted the wrong number of label values")
+		}
+	}()
+	NewCounterVec("test_total", "Total.", "a", "b").With("x")
+}
@@ -0,0 +1,93 @@
+package zero_test
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+// scrape 读取 /metrics 中各序列的值
+func scrape(t *testing.T) map[string]float64 {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	zero.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	samples := make(map[string]float64)
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		i := strings.LastIndexByte(line, ' ')
+		if line == "" || line[0] == '#' || i < 0 {
+			continue
+		}
+		v, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("bad sample %q: %v", line, err)
+		}
+		samples[line[:i]] = v
+	}
+	return samples
+}
+
+func TestMetrics(t *testing.T) {
+	zerotest.Plugin(t, zero.PluginInfo{Name: "metrics-plugin"}).OnCommand("metrics").Handle(func(ctx *zero.Ctx) {
+		_, _ = ctx.API().Call("metrics_ok", nil)
+		_, _ = ctx.API().Call("metrics_fail", nil)
+	})
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+	bot.Fail("metrics_fail", 100, "failed")
+
+	before := scrape(t)
+	bot.GroupMessage(1, 2, "/metrics")
+	after := scrape(t)
+
+	for series, delta := range map[string]float64{
+		`zerobot_events_total{post_type="message",detail_type="group"}`:           1,
+		`zerobot_matcher_triggered_total{matcher="metrics-plugin"}`:               1,
+		`zerobot_handler_duration_seconds_count{matcher="metrics-plugin"}`:        1,
+		`zerobot_api_call_duration_seconds_count{action="metrics_ok"}`:            1,
+		`zerobot_api_call_duration_seconds_count{action="metrics_fail"}`:          1,
+		`zerobot_api_call_duration_seconds_bucket{action="metrics_ok",le="+Inf"}`: 1,
+		`zerobot_api_call_errors_total{action="metrics_fail"}`:                    1,
+		`zerobot_api_call_errors_total{action="metrics_ok"}`:                      0,
+	} {
+		if got := after[series] - before[series]; got != delta {
+			t.Errorf("%s increased by %v, want %v", series, got, delta)
+		}
+	}
+	if after["zerobot_connected_accounts"] != 1 {
+		t.Errorf("zerobot_connected_accounts = %v, want 1", after["zerobot_connected_accounts"])
+	}
+	if _, ok := after["zerobot_ring_capacity"]; !ok {
+		t.Error("zerobot_ring_capacity not exported")
+	}
+}
+
+func TestMetricsRingRestart(t *testing.T) {
+	done := make(chan struct{})
+	scraped := make(chan struct{})
+	go func() { // 重启期间持续读取事件环的指标
+		defer close(scraped)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				zero.MetricsHandler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+			}
+		}
+	}()
+	for _, ringLen := range []uint{4, 8, 0} {
+		bot := zerotest.New(zerotest.SelfID)
+		bot.Run(zero.Config{RingLen: ringLen})
+		if got := scrape(t)["zerobot_ring_capacity"]; got != float64(ringLen) {
+			t.Errorf("zerobot_ring_capacity = %v, want %d", got, ringLen)
+		}
+		if err := bot.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	<-scraped
+}
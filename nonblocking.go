@@ -0,0 +1,44 @@
+package zero
+
+// SetNonBlocking 设置 Matcher 的 Rules 是否均不会阻塞
+//
+// 为 true 时 Rules 在分发协程中依次直接判断, 不再为每个 Rule 启动协程与计时.
+// 只应在所有 Rule (包括 Limit 添加的) 均不调用 API, 不等待事件且不进行 IO 时设置.
+// OnCommand, OnPrefix 等添加的第一个内置 Rule 总是直接判断
+func (m *Matcher) SetNonBlocking(nonBlocking bool) *Matcher {
+	m.NonBlocking = nonBlocking
+	return m
+}
+
+// SetNonBlocking 设置该 Engine 的 PreHandler 与 MidHandler 是否均不会阻塞, 见 Matcher.SetNonBlocking
+func (e *Engine) SetNonBlocking(nonBlocking bool) *Engine {
+	e.nonBlocking = nonBlocking
+	return e
+}
+
+// inlineRules m 的 Rules 中在分发协程中直接判断的前缀长度
+func (m *Matcher) inlineRules() int {
+	if m.NonBlocking {
+		return len(m.Rules)
+	}
+	return m.inline
+}
+
+// inlineHandlers PreHandler 与 MidHandler 中直接判断的前缀长度
+func (e *Engine) inlineHandlers() int {
+	if e.nonBlocking {
+		return len(e.preHandler) + len(e.midHandler)
+	}
+	return 0
+}
+
+// callRule 在当前协程中判断 rule, panic 时视为不满足
+func callRule(ctx *Ctx, m *Matcher, stage string, rule Rule) (ok bool) {
+	defer func() {
+		if pa := recover(); pa != nil {
+			recoverPanic(ctx, m, stage, pa)
+			ok = false
+		}
+	}()
+	return rule(ctx)
+}
@@ -0,0 +1,89 @@
+package zero
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// slowRule 睡眠 d 后通过, 超时时间短于 d 时只有直接判断才能通过
+func slowRule(d time.Duration) Rule {
+	return func(*Ctx) bool {
+		time.Sleep(d)
+		return true
+	}
+}
+
+func TestNonBlockingRulesRunInline(t *testing.T) {
+	for _, nonBlocking := range []bool{false, true} {
+		e := New()
+		ran := false
+		e.OnMessage(slowRule(20 * time.Millisecond)).
+			SetNonBlocking(nonBlocking).
+			SetTimeout(Timeout{Rule: time.Millisecond}).
+			Handle(func(*Ctx) { ran = true })
+		Dispatch(groupMessage("hello"), nopCaller{})
+		e.Delete()
+		if ran != nonBlocking {
+			t.Errorf("NonBlocking=%v: handler ran = %v", nonBlocking, ran)
+		}
+	}
+}
+
+func TestBuiltinRulesInline(t *testing.T) {
+	e := New()
+	defer e.Delete()
+	type flags struct {
+		V bool `flag:"v"`
+	}
+	for name, c := range map[string]struct {
+		m    *Matcher
+		want int
+	}{
+		"OnCommand": {e.OnCommand("a"), 1},
+		"OnPrefix":  {e.OnPrefix("a"), 1},
+		"OnMessage": {e.OnMessage(), 0},
+		"OnShell":   {e.OnShell("a", flags{}), 0}, // 会调用 API 发送用法说明
+	} {
+		if got := c.m.inlineRules(); got != c.want {
+			t.Errorf("%s: %d rules run inline, want %d", name, got, c.want)
+		}
+	}
+}
+
+func TestEngineNonBlockingHandlers(t *testing.T) {
+	e := New().SetNonBlocking(true).SetTimeout(Timeout{Rule: time.Millisecond})
+	defer e.Delete()
+	e.UsePreHandler(slowRule(20 * time.Millisecond))
+	e.UseMidHandler(slowRule(20 * time.Millisecond))
+	ran := false
+	e.OnMessage().Handle(func(*Ctx) { ran = true })
+	Dispatch(groupMessage("hello"), nopCaller{})
+	if !ran {
+		t.Error("handler did not run")
+	}
+}
+
+// BenchmarkRules 判断 5 个 Rule 时, 每个 Rule 启动协程与直接判断的开销
+func BenchmarkRules(b *testing.B) {
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.WarnLevel)
+	defer logrus.SetLevel(level)
+	pass := func(*Ctx) bool { return true }
+	event := groupMessage("hello")
+	for _, c := range []struct {
+		name        string
+		nonBlocking bool
+	}{{"goroutine", false}, {"inline", true}} {
+		e := New()
+		e.OnMessage(pass, pass, pass, pass, pass).SetNonBlocking(c.nonBlocking).Handle(func(*Ctx) {})
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				Dispatch(event, nopCaller{})
+			}
+		})
+		e.Delete()
+	}
+}
@@ -0,0 +1,267 @@
+package zero
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/FloatTech/ttl"
+	"github.com/tidwall/gjson"
+
+	log "github.com/cubevlmu/CZeroBot/log"
+	"github.com/cubevlmu/CZeroBot/message"
+	"github.com/cubevlmu/CZeroBot/storage"
+)
+
+// Protocol OneBot 协议版本
+type Protocol int
+
+const (
+	// ProtocolAuto 连接时自动检测
+	ProtocolAuto Protocol = 0
+	// OneBot11 https://github.com/botuniverse/onebot-11
+	OneBot11 Protocol = 11
+	// OneBot12 https://12.onebot.dev
+	OneBot12 Protocol = 12
+)
+
+// ErrUnknownOneBot12ID 伪造的 ID 没有对应的 OneBot 12 原始 ID, 如未持久化存储时进程已重启
+var ErrUnknownOneBot12ID = errors.New("zero: unknown OneBot 12 ID")
+
+// onebot12IDs 伪造的 ID 到 OneBot 12 原始字符串 ID 的缓存, 一天内未使用的将被清除,
+// 完整的映射保存在 Storage() 的 onebot12/id 中
+var onebot12IDs = ttl.NewCache[int64, string](time.Hour * 24)
+
+// oneBot12FakeIDMax 伪造的 ID 均小于该值, 与之重叠的数字 ID 同样伪造
+const oneBot12FakeIDMax = math.MinInt64 + 1<<62
+
+// isOneBot12FakeID 是否为 OneBot12ID 伪造的 ID
+func isOneBot12FakeID(id int64) bool {
+	return id < oneBot12FakeIDMax
+}
+
+// oneBot12IDBucket 持久化伪造 ID 映射的 bucket
+func oneBot12IDBucket() storage.Bucket {
+	return storage.NewBucket(Storage(), "onebot12/id")
+}
+
+// OneBot12ID 将 OneBot 12 的用户、群 ID 转为 int64
+//
+// 可无损往返的数字 ID 直接解析, 其余 (包括 "007" 等) 使用 crc64 伪造为小于 -2^62 的负数,
+// 并保存在 Storage() 中以便调用 API 时还原. 消息 ID 不经此转换, 以字符串保留
+func OneBot12ID(id string) int64 {
+	if id == "" {
+		return 0
+	}
+	if i, ok := parseOneBot12ID(id); ok && !isOneBot12FakeID(i) {
+		return i
+	}
+	r := math.MinInt64 + fakeID(id)&(1<<62-1)
+	if onebot12IDs.Get(r) != id {
+		onebot12IDs.Set(r, id)
+		if err := storage.Set(oneBot12IDBucket(), strconv.FormatInt(r, 10), id, 0); err != nil {
+			log.Warningf("[onebot12] failed to save id %q: %v", id, err)
+		}
+	}
+	return r
+}
+
+// OneBot12IDString 还原 OneBot12ID 转换前的字符串 ID
+//
+// 伪造的 ID 找不到原始 ID 时返回 ErrUnknownOneBot12ID
+func OneBot12IDString(id int64) (string, error) {
+	if !isOneBot12FakeID(id) {
+		return strconv.FormatInt(id, 10), nil
+	}
+	if s := onebot12IDs.Get(id); s != "" {
+		return s, nil
+	}
+	s, ok, err := storage.Get[string](oneBot12IDBucket(), strconv.FormatInt(id, 10))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %d", ErrUnknownOneBot12ID, id)
+	}
+	onebot12IDs.Set(id, s)
+	return s, nil
+}
+
+// parseOneBot12ID 解析数字 ID, 格式化后与 id 不同的视为非数字 ID
+func parseOneBot12ID(id string) (int64, bool) {
+	i, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || strconv.FormatInt(i, 10) != id {
+		return 0, false
+	}
+	return i, true
+}
+
+// oneBot12MessageID 可无损往返的数字消息 ID 转为 int64, 其余保留为字符串
+func oneBot12MessageID(id string) interface{} {
+	if i, ok := parseOneBot12ID(id); ok {
+		return i
+	}
+	return id
+}
+
+// isOneBot12Event OneBot 12 事件使用 type 而非 post_type
+func isOneBot12Event(raw gjson.Result) bool {
+	return raw.Get("type").Exists() && !raw.Get("post_type").Exists()
+}
+
+// oneBot12NoticeTypes OneBot 12 通知事件 detail_type 到 OneBot 11 notice_type 的映射
+var oneBot12NoticeTypes = map[string]string{
+	"friend_increase":        "friend_add",
+	"private_message_delete": "friend_recall",
+	"group_member_increase":  "group_increase",
+	"group_member_decrease":  "group_decrease",
+	"group_message_delete":   "group_recall",
+}
+
+// oneBot12Event 将 OneBot 12 事件转换为 OneBot 11 格式
+//
+// https://12.onebot.dev/interface/event/
+func oneBot12Event(raw gjson.Result) []byte {
+	ev := make(map[string]interface{}, 16)
+	raw.ForEach(func(key, value gjson.Result) bool { // 保留扩展字段
+		switch key.Str {
+		case "type", "detail_type", "self", "message", "time":
+		default:
+			ev[key.Str] = json.RawMessage(value.Raw)
+		}
+		return true
+	})
+	ev["time"] = int64(raw.Get("time").Float())
+	ev["self_id"] = OneBot12ID(raw.Get("self.user_id").String())
+	for _, k := range [...]string{"user_id", "group_id", "operator_id"} {
+		if v := raw.Get(k); v.Exists() {
+			ev[k] = OneBot12ID(v.String())
+		}
+	}
+	detailType := raw.Get("detail_type").String()
+	subType := raw.Get("sub_type").String()
+	switch raw.Get("type").String() {
+	case "message":
+		ev["post_type"] = "message"
+		ev["raw_message"] = raw.Get("alt_message").String()
+		ev["message"] = oneBot12Message(raw.Get("message"))
+		userID := raw.Get("user_id").String()
+		ev["sender"] = map[string]interface{}{"user_id": OneBot12ID(userID)}
+		if detailType == "channel" { // 与 guild 消息相同处理
+			ev["message_type"] = "guild"
+			ev["tiny_id"] = userID
+			ev["self_tiny_id"] = raw.Get("self.user_id").String()
+			ev["message_id"] = raw.Get("message_id").String()
+			subType = "channel"
+		} else {
+			ev["message_type"] = detailType
+			ev["message_id"] = oneBot12MessageID(raw.Get("message_id").String())
+		}
+	case "notice":
+		ev["post_type"] = "notice"
+		noticeType, ok := oneBot12NoticeTypes[detailType]
+		if !ok {
+			noticeType = detailType
+		}
+		ev["notice_type"] = noticeType
+		if detailType == "group_member_increase" && subType == "join" {
+			subType = "approve"
+		}
+		if v := raw.Get("message_id"); v.Exists() {
+			ev["message_id"] = oneBot12MessageID(v.String())
+		}
+	case "request":
+		ev["post_type"] = "request"
+		ev["request_type"] = detailType
+	case "meta":
+		ev["post_type"] = "meta_event"
+		ev["meta_event_type"] = detailType
+	default:
+		ev["post_type"] = raw.Get("type").String()
+	}
+	ev["sub_type"] = subType
+	b, _ := json.Marshal(ev)
+	return b
+}
+
+// oneBot12Message 将 OneBot 12 消息段转换为 OneBot 11 消息段
+//
+// https://12.onebot.dev/interface/message/segment/
+func oneBot12Message(raw gjson.Result) message.Message {
+	if !raw.IsArray() { // 非标准的字符串消息
+		return message.Message{message.Text(raw.String())}
+	}
+	msg := message.ParseMessageFromArray(raw)
+	for i, seg := range msg {
+		d := seg.Data
+		switch seg.Type {
+		case "mention":
+			msg[i] = message.At(OneBot12ID(d["user_id"]))
+		case "mention_all":
+			msg[i] = message.AtAll()
+		case "image", "video", "file":
+			d["file"] = d["file_id"]
+		case "voice", "audio":
+			msg[i].Type = "record"
+			d["file"] = d["file_id"]
+		case "location":
+			d["lat"], d["lon"] = d["latitude"], d["longitude"]
+		case "reply":
+			d["id"] = d["message_id"]
+		}
+	}
+	return msg
+}
+
+// toOneBot12Message 将 OneBot 11 消息转换为 OneBot 12 消息段
+func toOneBot12Message(v interface{}) ([]message.Segment, error) {
+	var msg message.Message
+	switch m := v.(type) {
+	case message.Message:
+		msg = m
+	case []message.Segment:
+		msg = m
+	case message.Segment:
+		msg = message.Message{m}
+	case string:
+		msg = message.ParseMessageFromString(m)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		msg = message.ParseMessage(b)
+	}
+	segs := make([]message.Segment, len(msg))
+	for i, seg := range msg {
+		d := make(map[string]string, len(seg.Data)+1)
+		for k, v := range seg.Data {
+			d[k] = v
+		}
+		segs[i] = message.Segment{Type: seg.Type, Data: d}
+		switch seg.Type {
+		case "at":
+			if d["qq"] == "all" {
+				segs[i] = message.Segment{Type: "mention_all", Data: map[string]string{}}
+				continue
+			}
+			qq, _ := strconv.ParseInt(d["qq"], 10, 64)
+			id, err := OneBot12IDString(qq)
+			if err != nil {
+				return nil, err
+			}
+			segs[i] = message.Segment{Type: "mention", Data: map[string]string{"user_id": id}}
+		case "image", "video", "file":
+			d["file_id"] = d["file"]
+		case "record":
+			segs[i].Type = "voice"
+			d["file_id"] = d["file"]
+		case "reply":
+			d["message_id"] = d["id"]
+		}
+	}
+	return segs, nil
+}
@@ -0,0 +1,233 @@
+package zero
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/tidwall/gjson"
+)
+
+// oneBot12Caller 将 OneBot 11 的 API 调用转换为 OneBot 12 动作
+type oneBot12Caller struct {
+	caller APICaller
+}
+
+// NewOneBot12Caller 包装连接至 OneBot 12 实现的 caller
+//
+// 常用的发送/获取 API 将转换为 OneBot 12 的动作名与参数, 返回值中补充 OneBot 11 的字段,
+// 其余 API 原样调用, 仅将 ID 参数还原为字符串
+func NewOneBot12Caller(caller APICaller) APICaller {
+	return &oneBot12Caller{caller: caller}
+}
+
+// CallAPI 调用 OneBot 12 动作
+func (c *oneBot12Caller) CallAPI(request APIRequest) (APIResponse, error) {
+	return c.CallAPIContext(context.Background(), request)
+}
+
+// CallAPIContext 调用 OneBot 12 动作
+func (c *oneBot12Caller) CallAPIContext(ctx context.Context, request APIRequest) (APIResponse, error) {
+	act, ok := oneBot12Actions[request.Action]
+	if !ok {
+		act = oneBot12Action{name: request.Action}
+	}
+	params, err := oneBot12Params(request.Params)
+	if err != nil {
+		return APIResponse{}, err
+	}
+	if act.params != nil {
+		act.params(params)
+	}
+	rsp, err := CallAPIContext(ctx, c.caller, APIRequest{
+		Action: act.name,
+		Params: params,
+		Echo:   request.Echo,
+	})
+	if err == nil && rsp.isSuccess() && act.result != nil {
+		rsp.Data = act.result(rsp.Data)
+	}
+	return rsp, err
+}
+
+// oneBot12Action OneBot 11 API 对应的 OneBot 12 动作
+type oneBot12Action struct {
+	name   string
+	params func(Params)                    // 在 ID 转换后修改参数
+	result func(gjson.Result) gjson.Result // 转换返回值
+}
+
+// oneBot12IDKeys 需要在 int64 与字符串间转换的 ID 字段, 消息 ID 另行处理
+var oneBot12IDKeys = [...]string{"user_id", "group_id", "operator_id"}
+
+// oneBot12Params 复制参数, 并将 ID 与消息转为 OneBot 12 格式
+func oneBot12Params(p Params) (Params, error) {
+	params := make(Params, len(p)+1)
+	for k, v := range p {
+		params[k] = v
+	}
+	for _, k := range oneBot12IDKeys {
+		var id int64
+		switch v := params[k].(type) {
+		case int64:
+			id = v
+		case int:
+			id = int64(v)
+		case string:
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				continue
+			}
+			id = i
+		default:
+			continue
+		}
+		s, err := OneBot12IDString(id)
+		if err != nil {
+			return nil, err
+		}
+		params[k] = s
+	}
+	switch v := params["message_id"].(type) { // 消息 ID 均为字符串
+	case int64:
+		params["message_id"] = strconv.FormatInt(v, 10)
+	case int:
+		params["message_id"] = strconv.Itoa(v)
+	case fmt.Stringer: // message.ID
+		params["message_id"] = v.String()
+	}
+	if m, ok := params["message"]; ok {
+		msg, err := toOneBot12Message(m)
+		if err != nil {
+			return nil, err
+		}
+		params["message"] = msg
+	}
+	return params, nil
+}
+
+// sendMessage send_message 并设置 detail_type
+func sendMessage(detailType string) oneBot12Action {
+	return oneBot12Action{
+		name: "send_message",
+		params: func(p Params) {
+			if detailType != "" {
+				p["detail_type"] = detailType
+				return
+			}
+			switch p["message_type"] { // send_msg
+			case "guild":
+				p["detail_type"] = "channel"
+			case nil:
+				if _, ok := p["group_id"]; ok {
+					p["detail_type"] = "group"
+				} else {
+					p["detail_type"] = "private"
+				}
+			default:
+				p["detail_type"] = p["message_type"]
+			}
+			delete(p, "message_type")
+		},
+		result: oneBot12Result(nil),
+	}
+}
+
+// oneBot12Actions OneBot 11 API 到 OneBot 12 动作的映射
+//
+// https://12.onebot.dev/interface/
+var oneBot12Actions = map[string]oneBot12Action{
+	"send_msg":               sendMessage(""),
+	"send_private_msg":       sendMessage("private"),
+	"send_group_msg":         sendMessage("group"),
+	"send_guild_channel_msg": sendMessage("channel"),
+	"delete_msg":             {name: "delete_message"},
+	"get_msg": {name: "get_message", result: func(r gjson.Result) gjson.Result {
+		r = oneBot12Result(nil)(r)
+		m, ok := r.Value().(map[string]interface{})
+		if !ok {
+			return r
+		}
+		m["message"] = oneBot12Message(r.Get("message"))
+		m["sender"] = map[string]interface{}{"user_id": r.Get("user_id").Int()}
+		if _, ok := m["message_type"]; !ok {
+			m["message_type"] = m["detail_type"]
+		}
+		return marshalResult(m)
+	}},
+	"get_login_info": {name: "get_self_info", result: oneBot12Result(map[string]string{
+		"user_name": "nickname",
+	})},
+	"get_stranger_info": {name: "get_user_info", result: oneBot12Result(map[string]string{
+		"user_name": "nickname",
+	})},
+	"get_friend_list": {name: "get_friend_list", result: oneBot12Result(map[string]string{
+		"user_name":   "nickname",
+		"user_remark": "remark",
+	})},
+	"get_group_info": {name: "get_group_info", result: oneBot12Result(nil)},
+	"get_group_list": {name: "get_group_list", result: oneBot12Result(nil)},
+	"get_group_member_info": {name: "get_group_member_info", result: oneBot12Result(map[string]string{
+		"user_name":        "nickname",
+		"user_displayname": "card",
+	})},
+	"get_group_member_list": {name: "get_group_member_list", result: oneBot12Result(map[string]string{
+		"user_name":        "nickname",
+		"user_displayname": "card",
+	})},
+	"set_group_name":  {name: "set_group_name"},
+	"set_group_leave": {name: "leave_group"},
+	"get_version_info": {name: "get_version", result: oneBot12Result(map[string]string{
+		"impl":           "app_name",
+		"version":        "app_version",
+		"onebot_version": "protocol_version",
+	})},
+}
+
+// oneBot12Result 在返回值中补充 OneBot 11 字段, 并将 ID 转为 int64
+//
+// rename 为 OneBot 12 字段到 OneBot 11 字段的映射, 原字段仍然保留
+func oneBot12Result(rename map[string]string) func(gjson.Result) gjson.Result {
+	convert := func(v interface{}) interface{} {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for from, to := range rename {
+			if x, ok := m[from]; ok {
+				m[to] = x
+			}
+		}
+		for _, k := range oneBot12IDKeys {
+			if s, ok := m[k].(string); ok {
+				m[k] = OneBot12ID(s)
+			}
+		}
+		if s, ok := m["message_id"].(string); ok {
+			m["message_id"] = oneBot12MessageID(s)
+		}
+		return m
+	}
+	return func(r gjson.Result) gjson.Result {
+		v := r.Value()
+		if arr, ok := v.([]interface{}); ok {
+			for i := range arr {
+				arr[i] = convert(arr[i])
+			}
+			return marshalResult(arr)
+		}
+		if _, ok := v.(map[string]interface{}); !ok {
+			return r
+		}
+		return marshalResult(convert(v))
+	}
+}
+
+func marshalResult(v interface{}) gjson.Result {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return gjson.Result{}
+	}
+	return gjson.ParseBytes(b)
+}
@@ -0,0 +1,100 @@
+package zero
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/cubevlmu/CZeroBot/message"
+	"github.com/cubevlmu/CZeroBot/storage"
+)
+
+func TestOneBot12IDRoundTrip(t *testing.T) {
+	fakeRange := strconv.FormatInt(oneBot12FakeIDMax-1, 10) // 与伪造 ID 重叠的数字 ID
+	for _, id := range []string{"123", "007", "0", "-1", "+1", "abc", fakeRange} {
+		if s, err := OneBot12IDString(OneBot12ID(id)); err != nil || s != id {
+			t.Errorf("OneBot12IDString(OneBot12ID(%q)) = %q, %v", id, s, err)
+		}
+	}
+	for _, id := range []string{"007", "abc", fakeRange} {
+		if i := OneBot12ID(id); !isOneBot12FakeID(i) {
+			t.Errorf("OneBot12ID(%q) = %d, not in the fake ID range", id, i)
+		}
+	}
+	if i := OneBot12ID("123"); i != 123 {
+		t.Errorf("OneBot12ID(%q) = %d, want 123", "123", i)
+	}
+	if i := OneBot12ID("007"); i == 7 {
+		t.Errorf("OneBot12ID(%q) collides with %q", "007", "7")
+	}
+}
+
+func TestOneBot12MessageID(t *testing.T) {
+	for _, c := range []struct {
+		id   string
+		want interface{}
+	}{
+		{"123", int64(123)},
+		{"007", "007"},
+		{"a-b", "a-b"},
+	} {
+		raw := []byte(`{"type":"message","detail_type":"group","self":{"platform":"qq","user_id":"1"},` +
+			`"user_id":"2","group_id":"g-3","message_id":"` + c.id + `","message":[],"alt_message":""}`)
+		ctx, _, ok := newEventCtx(raw, nopCaller{})
+		if !ok {
+			t.Fatal("event dropped")
+		}
+		running.release(ctx)
+		if ctx.Event.MessageID != c.want {
+			t.Errorf("message id %q decoded as %#v, want %#v", c.id, ctx.Event.MessageID, c.want)
+		}
+		params, err := oneBot12Params(Params{"group_id": ctx.Event.GroupID, "message_id": ctx.Event.MessageID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if params["message_id"] != c.id || params["group_id"] != "g-3" {
+			t.Errorf("params of message %q are %v", c.id, params)
+		}
+		params, err = oneBot12Params(Params{"message_id": message.NewMessageIDFromString(c.id)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if params["message_id"] != c.id {
+			t.Errorf("message.ID %q converted to %v", c.id, params["message_id"])
+		}
+	}
+}
+
+func TestOneBot12IDPersisted(t *testing.T) {
+	old := Storage()
+	defer SetStorage(old)
+	store := storage.NewMemory()
+	SetStorage(store)
+
+	id := OneBot12ID("persisted-user")
+	onebot12IDs.Delete(id) // 缓存过期
+	if s, err := OneBot12IDString(id); err != nil || s != "persisted-user" {
+		t.Fatalf("id restored from storage as %q, %v", s, err)
+	}
+
+	id = OneBot12ID("lost-user")
+	onebot12IDs.Delete(id)
+	SetStorage(storage.NewMemory()) // 重启后未持久化
+	if _, err := OneBot12IDString(id); !errors.Is(err, ErrUnknownOneBot12ID) {
+		t.Fatalf("unknown fake id: err = %v, want ErrUnknownOneBot12ID", err)
+	}
+	for name, p := range map[string]Params{
+		"id":      {"user_id": id},
+		"mention": {"message": message.Message{message.At(id)}},
+	} {
+		if _, err := oneBot12Params(p); !errors.Is(err, ErrUnknownOneBot12ID) {
+			t.Errorf("%s: err = %v, want ErrUnknownOneBot12ID", name, err)
+		}
+	}
+	if _, err := NewOneBot12Caller(nopCaller{}).CallAPI(APIRequest{
+		Action: "send_private_msg",
+		Params: Params{"user_id": id, "message": "hi"},
+	}); !errors.Is(err, ErrUnknownOneBot12ID) {
+		t.Errorf("CallAPI: err = %v, want ErrUnknownOneBot12ID", err)
+	}
+}
@@ -0,0 +1,81 @@
+package zero
+
+import (
+	"sort"
+	"sync"
+)
+
+// PluginInfo 插件元数据
+type PluginInfo struct {
+	Name             string // 插件名, 全局唯一, 用于开关与帮助
+	Brief            string // 简介, 显示于帮助列表
+	Help             string // 详细帮助
+	DisableOnDefault bool   // 是否默认禁用
+	Hidden           bool   // 是否在帮助列表中隐藏
+}
+
+var (
+	// 所有已注册的插件, 按注册顺序排列
+	plugins = make([]*Service, 0)
+	// 插件名到 Service 的映射
+	pluginMap = make(map[string]*Service)
+	// 插件列表读写锁
+	pluginLock sync.RWMutex
+)
+
+// Register 注册插件并生成其 Engine
+//
+// 插件的开关由 Service 作为 PreHandler 控制, 重复注册同名插件将 panic
+func Register(info PluginInfo) *Engine {
+	if info.Name == "" {
+		panic("zero: plugin name is empty")
+	}
+	e := New()
+	s := newService(e, info)
+	pluginLock.Lock()
+	defer pluginLock.Unlock()
+	if _, ok := pluginMap[info.Name]; ok {
+		panic("zero: plugin " + info.Name + " is already registered")
+	}
+	e.service = s
+	e.preHandler = append(e.preHandler, s.handler) // 不改写消息, 仍使用分发索引
+	plugins = append(plugins, s)
+	pluginMap[info.Name] = s
+	return e
+}
+
+// unregister 移除插件
+func unregister(s *Service) {
+	pluginLock.Lock()
+	defer pluginLock.Unlock()
+	if pluginMap[s.info.Name] != s {
+		return
+	}
+	delete(pluginMap, s.info.Name)
+	for i, p := range plugins {
+		if p == s {
+			plugins = append(plugins[:i], plugins[i+1:]...)
+			break
+		}
+	}
+}
+
+// LookupService 获取名为 name 的插件
+func LookupService(name string) (*Service, bool) {
+	pluginLock.RLock()
+	defer pluginLock.RUnlock()
+	s, ok := pluginMap[name]
+	return s, ok
+}
+
+// Services 所有已注册的插件, 按插件名排序
+func Services() []*Service {
+	pluginLock.RLock()
+	services := make([]*Service, len(plugins))
+	copy(services, plugins)
+	pluginLock.RUnlock()
+	sort.SliceStable(services, func(i, j int) bool {
+		return services[i].info.Name < services[j].info.Name
+	})
+	return services
+}
@@ -0,0 +1,353 @@
+package zero
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LimitKey 限速对象的键
+type LimitKey func(ctx *Ctx) string
+
+// LimitByUser 按用户限速
+func LimitByUser(ctx *Ctx) string {
+	return strconv.FormatInt(ctx.Event.UserID, 10)
+}
+
+// LimitByGroup 按群限速, 私聊按用户
+func LimitByGroup(ctx *Ctx) string {
+	return strconv.FormatInt(ServiceID(ctx), 10)
+}
+
+// LimitByUserInGroup 按群内的每个用户限速
+func LimitByUserInGroup(ctx *Ctx) string {
+	return strconv.FormatInt(ServiceID(ctx), 10) + ":" + strconv.FormatInt(ctx.Event.UserID, 10)
+}
+
+// LimitPolicy 超出限制时的处理方式
+type LimitPolicy int
+
+const (
+	// LimitDrop 直接丢弃
+	LimitDrop LimitPolicy = iota
+	// LimitReply 回复冷却提示后丢弃, 每次冷却只提示一次
+	LimitReply
+	// LimitQueue 等待至可用, 超过 MaxWait 或事件处理超时则丢弃
+	LimitQueue
+)
+
+// defaultLimitKeys 每个 RateLimiter 默认最多记录的键数
+const defaultLimitKeys = 10000
+
+// limitAlgorithm 限速算法, 由 RateLimiter 加锁调用
+type limitAlgorithm interface {
+	// newState 新键的初始状态
+	newState() interface{}
+	// take 尝试在 now 消耗一次, 失败时返回需要等待的时间
+	//
+	// reserve 为 true 时, 失败也预定 now+wait 处的一次; 为 false 时失败不改变状态
+	take(state interface{}, now time.Time, reserve bool) (ok bool, wait time.Duration)
+	// unreserve 归还 take 在 slot 处预定的一次
+	unreserve(state interface{}, slot time.Time)
+}
+
+// tokenBucket 令牌桶, 每 interval 恢复一个令牌, 最多 burst 个
+type tokenBucket struct {
+	interval time.Duration
+	burst    float64
+}
+
+type tokenBucketState struct {
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) newState() interface{} {
+	return &tokenBucketState{tokens: b.burst}
+}
+
+func (b *tokenBucket) take(state interface{}, now time.Time, reserve bool) (bool, time.Duration) {
+	s := state.(*tokenBucketState)
+	if !s.last.IsZero() && now.After(s.last) {
+		s.tokens += float64(now.Sub(s.last)) / float64(b.interval)
+		if s.tokens > b.burst {
+			s.tokens = b.burst
+		}
+	}
+	if now.After(s.last) {
+		s.last = now
+	}
+	if s.tokens >= 1 {
+		s.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - s.tokens) * float64(b.interval))
+	if reserve {
+		s.tokens--
+	}
+	return false, wait
+}
+
+func (b *tokenBucket) unreserve(state interface{}, _ time.Time) {
+	s := state.(*tokenBucketState)
+	s.tokens++
+	if s.tokens > b.burst {
+		s.tokens = b.burst
+	}
+}
+
+// slidingWindow 滑动窗口, window 内最多 n 次
+type slidingWindow struct {
+	window time.Duration
+	n      int
+}
+
+type slidingWindowState struct {
+	times []time.Time // 升序, 可能包含预定的未来时刻
+}
+
+func (w *slidingWindow) newState() interface{} {
+	return &slidingWindowState{times: make([]time.Time, 0, w.n)}
+}
+
+func (w *slidingWindow) take(state interface{}, now time.Time, reserve bool) (bool, time.Duration) {
+	s := state.(*slidingWindowState)
+	i := 0
+	for i < len(s.times) && !s.times[i].After(now.Add(-w.window)) {
+		i++
+	}
+	s.times = append(s.times[:0], s.times[i:]...)
+	if len(s.times) < w.n {
+		s.times = append(s.times, now)
+		return true, 0
+	}
+	slot := s.times[len(s.times)-w.n].Add(w.window)
+	if reserve {
+		s.times = append(s.times, slot)
+	}
+	return false, slot.Sub(now)
+}
+
+func (w *slidingWindow) unreserve(state interface{}, slot time.Time) {
+	s := state.(*slidingWindowState)
+	for i := len(s.times) - 1; i >= 0; i-- {
+		if s.times[i].Equal(slot) {
+			s.times = append(s.times[:i], s.times[i+1:]...)
+			return
+		}
+	}
+}
+
+// RateLimiter 按 LimitKey 区分对象的限速器
+//
+// 其 Allow 方法可直接作为 Rule 使用, 也可以通过 Matcher.Limit 或 Engine.UseLimiter 设置
+type RateLimiter struct {
+	key     LimitKey
+	policy  LimitPolicy
+	message func(ctx *Ctx, wait time.Duration) interface{}
+	maxWait time.Duration
+	maxKeys int
+	algo    limitAlgorithm
+
+	mu    sync.Mutex
+	lru   *list.List // *limitEntry, 最近使用的在前
+	items map[string]*list.Element
+}
+
+// limitEntry 一个键的限速状态
+type limitEntry struct {
+	key    string
+	state  interface{}
+	warned bool // 本次冷却是否已提示
+}
+
+// NewTokenBucket 令牌桶限速器, 每 interval 恢复一次, 最多累积 burst 次
+func NewTokenBucket(interval time.Duration, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return newRateLimiter(&tokenBucket{interval: interval, burst: float64(burst)})
+}
+
+// NewSlidingWindow 滑动窗口限速器, 任意 window 时长内最多 n 次
+func NewSlidingWindow(window time.Duration, n int) *RateLimiter {
+	if n < 1 {
+		n = 1
+	}
+	return newRateLimiter(&slidingWindow{window: window, n: n})
+}
+
+func newRateLimiter(algo limitAlgorithm) *RateLimiter {
+	return &RateLimiter{
+		key:     LimitByUser,
+		maxWait: time.Minute,
+		maxKeys: defaultLimitKeys,
+		algo:    algo,
+		lru:     list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// By 设置限速对象, 默认为 LimitByUser
+func (l *RateLimiter) By(key LimitKey) *RateLimiter {
+	l.key = key
+	return l
+}
+
+// OnLimit 设置超出限制时的处理方式, 默认为 LimitDrop
+func (l *RateLimiter) OnLimit(policy LimitPolicy) *RateLimiter {
+	l.policy = policy
+	return l
+}
+
+// SetMessage 设置 LimitReply 的提示, 并将处理方式设为 LimitReply
+func (l *RateLimiter) SetMessage(f func(ctx *Ctx, wait time.Duration) interface{}) *RateLimiter {
+	l.message = f
+	l.policy = LimitReply
+	return l
+}
+
+// SetMaxWait 设置 LimitQueue 的最长等待时间, 默认 1min
+func (l *RateLimiter) SetMaxWait(d time.Duration) *RateLimiter {
+	l.maxWait = d
+	return l
+}
+
+// SetMaxKeys 设置最多记录的键数, 超出时淘汰最久未使用的键, 默认 10000
+func (l *RateLimiter) SetMaxKeys(n int) *RateLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxKeys = n
+	l.evict()
+	return l
+}
+
+// Len 当前记录的键数
+func (l *RateLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lru.Len()
+}
+
+// Reset 清除 key 的限速状态
+func (l *RateLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.items[key]; ok {
+		l.lru.Remove(e)
+		delete(l.items, key)
+	}
+}
+
+// entry 获取 key 的状态, 需持有锁
+func (l *RateLimiter) entry(key string) *limitEntry {
+	if e, ok := l.items[key]; ok {
+		l.lru.MoveToFront(e)
+		return e.Value.(*limitEntry)
+	}
+	ent := &limitEntry{key: key, state: l.algo.newState()}
+	l.items[key] = l.lru.PushFront(ent)
+	l.evict()
+	return ent
+}
+
+// evict 淘汰超出 maxKeys 的键, 需持有锁
+func (l *RateLimiter) evict() {
+	for l.maxKeys > 0 && l.lru.Len() > l.maxKeys {
+		e := l.lru.Back()
+		l.lru.Remove(e)
+		delete(l.items, e.Value.(*limitEntry).key)
+	}
+}
+
+// take 在 now 消耗 key 的一次, 失败时返回等待时间与是否需要提示
+//
+// 失败且等待时间不超过 maxWait 时预定 now+wait 处的一次, maxWait 为 0 时不预定
+func (l *RateLimiter) take(key string, now time.Time, maxWait time.Duration) (ok bool, wait time.Duration, warn bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ent := l.entry(key)
+	ok, wait = l.algo.take(ent.state, now, false)
+	if !ok && wait <= maxWait {
+		_, wait = l.algo.take(ent.state, now, true)
+		return false, wait, false
+	}
+	if ok {
+		ent.warned = false
+		return
+	}
+	warn = !ent.warned
+	ent.warned = true
+	return
+}
+
+// unreserve 归还 key 在 slot 处预定的一次
+func (l *RateLimiter) unreserve(key string, slot time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.items[key]; ok {
+		l.algo.unreserve(e.Value.(*limitEntry).state, slot)
+	}
+}
+
+// AllowKey 判断 key 是否未超出限制, 并消耗一次
+func (l *RateLimiter) AllowKey(key string) bool {
+	ok, _, _ := l.take(key, time.Now(), 0)
+	return ok
+}
+
+// Allow 判断本次事件是否未超出限制, 可作为 Rule 使用
+func (l *RateLimiter) Allow(ctx *Ctx) bool {
+	var maxWait time.Duration
+	if l.policy == LimitQueue {
+		maxWait = l.maxWait
+	}
+	key, now := l.key(ctx), time.Now()
+	ok, wait, warn := l.take(key, now, maxWait)
+	if ok {
+		return true
+	}
+	switch l.policy {
+	case LimitReply:
+		if warn {
+			ctx.Send(l.cooldownMessage(ctx, wait))
+		}
+	case LimitQueue:
+		if wait > l.maxWait {
+			return false
+		}
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			return true
+		case <-ctx.Context().Done(): // 放弃排队, 归还预定
+			l.unreserve(key, now.Add(wait))
+		}
+	}
+	return false
+}
+
+func (l *RateLimiter) cooldownMessage(ctx *Ctx, wait time.Duration) interface{} {
+	if l.message != nil {
+		return l.message(ctx, wait)
+	}
+	return "操作太频繁, 请在 " + (wait + time.Second - 1).Truncate(time.Second).String() + " 后重试"
+}
+
+// Limit 为 Matcher 添加限速, 在其余 Rule 通过后判断
+func (m *Matcher) Limit(l *RateLimiter) *Matcher {
+	matcherLock.Lock()
+	defer matcherLock.Unlock()
+	rules := make([]Rule, len(m.Rules), len(m.Rules)+1)
+	copy(rules, m.Rules)
+	m.Rules = append(rules, l.Allow)
+	return m
+}
+
+// UseLimiter 为 Engine 的所有 Matcher 添加限速, 作为 MidHandler 在 Rule 通过后判断
+func (e *Engine) UseLimiter(l *RateLimiter) *Engine {
+	e.UseMidHandler(l.Allow)
+	return e
+}
@@ -0,0 +1,48 @@
+package zero_test
+
+import (
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestLimitReply(t *testing.T) {
+	e := zerotest.Engine(t)
+	l := zero.NewTokenBucket(time.Hour, 2).SetMessage(func(*zero.Ctx, time.Duration) interface{} {
+		return "slow down"
+	})
+	e.OnCommand("hi").Limit(l).Handle(func(ctx *zero.Ctx) {
+		ctx.Send("hi")
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	for i := 0; i < 4; i++ {
+		bot.GroupMessage(1, 2, "/hi")
+	}
+	bot.GroupMessage(1, 3, "/hi") // 按用户限速
+	bot.AssertSent(t, "hi", "hi", "slow down", "hi")
+}
+
+func TestLimitQueueCanceled(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	e := zerotest.Engine(t)
+	l := zero.NewTokenBucket(interval, 1).OnLimit(zero.LimitQueue).SetMaxWait(time.Second)
+	e.OnCommand("hi").Limit(l).SetTimeout(zero.Timeout{Rule: 30 * time.Millisecond}).
+		Handle(func(ctx *zero.Ctx) {
+			ctx.Send("hi")
+		})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	start := time.Now()
+	bot.GroupMessage(1, 2, "/hi")
+	bot.GroupMessage(1, 2, "/hi") // 排队超时被放弃, 预定应被归还
+	bot.AssertSent(t, "hi")
+	// 此时已恢复一个令牌, 若预定未归还则仍需等待 interval/2, 超过 Rule 的超时
+	time.Sleep(interval*3/2 - time.Since(start))
+	bot.GroupMessage(1, 2, "/hi")
+	bot.AssertSent(t, "hi", "hi")
+}
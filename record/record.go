@@ -0,0 +1,189 @@
+// Package record 以 JSON Lines 记录收到的事件与 API 调用, 用于离线重放
+//
+// 每行为一个 Entry, 文件超过 MaxSize 时轮转为 path.1, path.2 ...
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Kind 记录的类型
+type Kind string
+
+const (
+	// KindEvent 收到的事件
+	KindEvent Kind = "event"
+	// KindAPI 发出的 API 请求及其响应
+	KindAPI Kind = "api"
+)
+
+// Entry 一条记录
+type Entry struct {
+	Time     time.Time       `json:"time"`
+	Kind     Kind            `json:"kind"`
+	SelfID   int64           `json:"self_id"`
+	Event    json.RawMessage `json:"event,omitempty"`    // KindEvent: 原始事件
+	Request  json.RawMessage `json:"request,omitempty"`  // KindAPI: APIRequest
+	Response json.RawMessage `json:"response,omitempty"` // KindAPI: APIResponse, 调用失败时为空
+	Error    string          `json:"error,omitempty"`    // KindAPI: 调用失败的原因
+}
+
+// Config 记录的设置
+type Config struct {
+	Path       string `json:"path"`        // 文件路径
+	MaxSize    int64  `json:"max_size"`    // 单个文件的最大 MB 数, 默认 100
+	MaxBackups int    `json:"max_backups"` // 保留的轮转文件数, 默认 5
+}
+
+// Writer 写入记录并按大小轮转, 可并发使用
+type Writer struct {
+	cfg  Config
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// Open 以追加方式打开 cfg.Path
+func Open(cfg Config) (*Writer, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("record: empty path")
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 100
+	}
+	if cfg.MaxBackups <= 0 {
+		cfg.MaxBackups = 5
+	}
+	w := &Writer{cfg: cfg}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.f, w.size = f, info.Size()
+	return nil
+}
+
+// Write 写入一条记录, 超过大小时先轮转
+func (w *Writer) Write(e *Entry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return os.ErrClosed
+	}
+	if w.size > 0 && w.size+int64(len(b)) > w.cfg.MaxSize<<20 {
+		if err = w.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := w.f.Write(b)
+	w.size += int64(n)
+	return err
+}
+
+// rotate 将 path.i 重命名为 path.i+1, path 重命名为 path.1, 超出 MaxBackups 的删除
+func (w *Writer) rotate() error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	w.f = nil
+	_ = os.Remove(backup(w.cfg.Path, w.cfg.MaxBackups))
+	for i := w.cfg.MaxBackups - 1; i > 0; i-- {
+		_ = os.Rename(backup(w.cfg.Path, i), backup(w.cfg.Path, i+1))
+	}
+	if err := os.Rename(w.cfg.Path, backup(w.cfg.Path, 1)); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// Close 关闭文件
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+func backup(path string, i int) string {
+	return path + "." + strconv.Itoa(i)
+}
+
+// Files 返回 path 及其存在的轮转文件, 按时间从旧到新排列
+func Files(path string) []string {
+	var files []string
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backup(path, i)); err != nil {
+			break
+		}
+		files = append(files, backup(path, i))
+	}
+	for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+		files[i], files[j] = files[j], files[i]
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files
+}
+
+// Read 依次读取 files 中的所有记录
+func Read(files ...string) ([]Entry, error) {
+	var entries []Entry
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		entries, err = decode(f, name, entries)
+		_ = f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+func decode(r io.Reader, name string, entries []Entry) ([]Entry, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("record: %s:%d: %w", name, line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
@@ -0,0 +1,95 @@
+package zero
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	log "github.com/cubevlmu/CZeroBot/log"
+	"github.com/cubevlmu/CZeroBot/record"
+)
+
+// recorder 未启用时为 nil
+var recorder atomic.Pointer[record.Writer]
+
+// startRecord 打开 cfg.Path, 之后收到的事件与 API 调用将被记录
+func startRecord(cfg *record.Config) {
+	w, err := record.Open(*cfg)
+	if err != nil {
+		log.Warningf("[record] failed to open %s: %v", cfg.Path, err)
+		return
+	}
+	if old := recorder.Swap(w); old != nil {
+		_ = old.Close()
+	}
+	log.Infof("[record] recording events and api calls to %s", cfg.Path)
+}
+
+// stopRecord 关闭记录文件
+func stopRecord() {
+	if w := recorder.Swap(nil); w != nil {
+		_ = w.Close()
+	}
+}
+
+// recordEvent 记录收到的原始事件
+func recordEvent(selfID int64, response []byte) {
+	w := recorder.Load()
+	if w == nil {
+		return
+	}
+	err := w.Write(&record.Entry{
+		Time:   time.Now(),
+		Kind:   record.KindEvent,
+		SelfID: selfID,
+		Event:  json.RawMessage(response),
+	})
+	if err != nil {
+		log.With(log.SelfID(selfID), log.Err(err)).Warning("[record] failed to record event")
+	}
+}
+
+// recordCaller 记录经过的 API 调用
+type recordCaller struct {
+	selfID int64
+	caller APICaller
+}
+
+// recording 启用记录时, 返回记录 caller 的调用的 APICaller
+func recording(selfID int64, caller APICaller) APICaller {
+	if recorder.Load() == nil || caller == nil {
+		return caller
+	}
+	return &recordCaller{selfID: selfID, caller: caller}
+}
+
+// CallAPI 调用并记录
+func (r *recordCaller) CallAPI(request APIRequest) (APIResponse, error) {
+	return r.CallAPIContext(context.Background(), request)
+}
+
+// CallAPIContext 调用并记录
+func (r *recordCaller) CallAPIContext(ctx context.Context, request APIRequest) (APIResponse, error) {
+	rsp, err := CallAPIContext(ctx, r.caller, request)
+	w := recorder.Load()
+	if w == nil {
+		return rsp, err
+	}
+	e := record.Entry{Time: time.Now(), Kind: record.KindAPI, SelfID: r.selfID}
+	var merr error
+	if e.Request, merr = json.Marshal(&request); merr == nil {
+		if err != nil {
+			e.Error = err.Error()
+		} else {
+			e.Response, merr = json.Marshal(rsp)
+		}
+	}
+	if merr == nil {
+		merr = w.Write(&e)
+	}
+	if merr != nil {
+		log.With(log.SelfID(r.selfID), log.Action(request.Action), log.Err(merr)).Warning("[record] failed to record api call")
+	}
+	return rsp, err
+}
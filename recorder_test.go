@@ -0,0 +1,72 @@
+package zero_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/driver"
+	"github.com/cubevlmu/CZeroBot/record"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+	e := zerotest.Engine(t)
+	e.OnCommand("group").Handle(func(ctx *zero.Ctx) {
+		name := ctx.GetGroupInfo(ctx.Event.GroupID, false).Name
+		mu.Lock()
+		names = append(names, name)
+		mu.Unlock()
+		ctx.Send("group " + name)
+	})
+
+	path := filepath.Join(t.TempDir(), "record.jsonl")
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/", Record: &record.Config{Path: path}})
+	bot.Respond("get_group_info", map[string]interface{}{"group_id": 1, "group_name": "recorded"})
+	bot.GroupMessage(1, 2, "/group")
+	bot.GroupMessage(1, 2, "/group")
+	if err := bot.Close(); err != nil { // 关闭后记录才写完
+		t.Fatal(err)
+	}
+	bot.AssertSent(t, "group recorded", "group recorded")
+
+	entries, err := record.Read(record.Files(path)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events, calls int
+	for _, en := range entries {
+		switch en.Kind {
+		case record.KindEvent:
+			events++
+		case record.KindAPI:
+			calls++
+		}
+	}
+	// get_login_info 与 driver 连接元事件之外, 每条消息一次 get_group_info 与一次 send_group_msg
+	if events < 2 || calls < 4 {
+		t.Fatalf("recorded %d events and %d api calls", events, calls)
+	}
+
+	names = nil
+	r, err := driver.NewReplay(0, record.Files(path)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero.RunAndBlock(&zero.Config{CommandPrefix: "/", Driver: []zero.Driver{r}}, nil) // 送出全部事件后返回
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := zero.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "recorded" || names[1] != "recorded" {
+		t.Fatalf("replayed group names %q, want the recorded responses", names)
+	}
+}
@@ -14,6 +14,8 @@ type eventRing struct {
 	r []*eventRingItem
 	i uintptr
 	p []*eventRingItem
+	d chan struct{} // 关闭后 loop 处理完剩余事件并退出
+	e chan struct{} // loop 退出后关闭
 }
 
 type eventRingItem struct {
@@ -21,10 +23,12 @@ type eventRingItem struct {
 	caller   APICaller
 }
 
-func newring(ringLen uint) eventRing {
-	return eventRing{
+func newring(ringLen uint) *eventRing {
+	return &eventRing{
 		r: make([]*eventRingItem, ringLen),
 		p: make([]*eventRingItem, ringLen+1),
+		d: make(chan struct{}),
+		e: make(chan struct{}),
 	} // 同一节点, 每 ringLen*(ringLen+1) 轮将共用同一 buffer
 }
 
@@ -38,37 +42,75 @@ func (evr *eventRing) processEvent(response []byte, caller APICaller) {
 		response: response,
 		caller:   caller,
 	}
-	atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(&evr.r[r])), unsafe.Pointer(&evr.p[p]))
+	atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(&evr.r[r])), unsafe.Pointer(evr.p[p]))
 	evr.c++
 	evr.i++
 }
 
+// len 池中尚未处理的事件数
+func (evr *eventRing) len() int {
+	evr.Lock()
+	defer evr.Unlock()
+	n := 0
+	for i := range evr.r {
+		if atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&evr.r[i]))) != nil {
+			n++
+		}
+	}
+	return n
+}
+
 // loop 循环处理事件
 //
 //	latency 延迟 latency 再处理事件
 func (evr *eventRing) loop(latency, maxwait time.Duration, process func([]byte, APICaller, time.Duration)) {
-	go func(r []*eventRingItem) {
+	go func(r []*eventRingItem, d <-chan struct{}, e chan<- struct{}) {
+		defer close(e)
 		c := uintptr(0)
-		if latency < time.Millisecond {
-			latency = time.Millisecond
-		}
-		totl := time.Duration(0)
-		for range time.NewTicker(latency).C {
+		// next 处理下一个事件, 还未有消息时返回 false
+		next := func() bool {
 			i := c % uintptr(len(r))
 			it := (*eventRingItem)(atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&r[i]))))
-			if it == nil { // 还未有消息
-				continue
+			if it == nil {
+				return false
 			}
 			process(it.response, it.caller, maxwait)
 			atomic.StorePointer((*unsafe.Pointer)(unsafe.Pointer(&r[i])), unsafe.Pointer(nil))
 			it.response = nil
 			it.caller = nil
 			c++
+			return true
+		}
+		if latency < time.Millisecond {
+			latency = time.Millisecond
+		}
+		totl := time.Duration(0)
+		ticker := time.NewTicker(latency)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-d:
+				for next() { // 不再延迟, 处理剩余的事件
+				}
+				return
+			}
+			if !next() { // 还未有消息
+				continue
+			}
 			totl += latency
 			if totl > time.Second {
 				totl = 0
 				runtime.GC()
 			}
 		}
-	}(evr.r)
+	}(evr.r, evr.d, evr.e)
+}
+
+// stop 停止 loop, 返回前剩余的事件均已交给 process
+//
+// 调用前需保证不再有 processEvent
+func (evr *eventRing) stop() {
+	close(evr.d)
+	<-evr.e
 }
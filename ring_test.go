@@ -0,0 +1,60 @@
+package zero
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// linkDriver 将 Listen 收到的 handler 交给测试
+type linkDriver struct {
+	link chan func([]byte, APICaller)
+	done chan struct{}
+}
+
+func newLinkDriver() *linkDriver {
+	return &linkDriver{link: make(chan func([]byte, APICaller), 1), done: make(chan struct{})}
+}
+
+func (d *linkDriver) Connect() {}
+
+func (d *linkDriver) Listen(handler func([]byte, APICaller)) {
+	d.link <- handler
+	<-d.done
+}
+
+func (d *linkDriver) Close() error {
+	close(d.done)
+	return nil
+}
+
+func TestStopProcessesRingEvents(t *testing.T) {
+	var n int32
+	e := New()
+	defer e.Delete()
+	e.OnMessage().Handle(func(*Ctx) { atomic.AddInt32(&n, 1) })
+
+	d := newLinkDriver()
+	// 延迟足够长, 事件只能在 Stop 时被处理
+	Run(&Config{RingLen: 16, Latency: time.Hour, Driver: []Driver{d}})
+	link := <-d.link
+	for i := 0; i < 5; i++ {
+		link(groupMessage("hello"), nopCaller{})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadInt32(&n); got != 5 {
+		t.Fatalf("%d of 5 ring events handled before Stop returned", got)
+	}
+
+	// Stop 之后 Driver 的事件被丢弃, Dispatch 仍可使用
+	link(groupMessage("late"), nopCaller{})
+	Dispatch(groupMessage("dispatch"), nopCaller{})
+	if got := atomic.LoadInt32(&n); got != 6 {
+		t.Fatalf("%d events handled after Dispatch, want 6", got)
+	}
+}
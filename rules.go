@@ -300,13 +300,11 @@ func MustProvidePicture(ctx *Ctx) bool {
 	}
 	// 没有图片就索取
 	ctx.SendChain(message.Text("请发送一张图片"))
-	next := NewFutureEvent("message", 999, true, ctx.CheckSession(), HasPicture).Next()
-	select {
-	case <-time.After(time.Second * 120):
+	newCtx := <-NewFutureEvent("message", 999, true, ctx.CheckSession(), HasPicture).NextTimeout(time.Second * 120)
+	if newCtx == nil { // 超时
 		return false
-	case newCtx := <-next:
-		ctx.State["image_url"] = newCtx.State["image_url"]
-		ctx.Event.MessageID = newCtx.Event.MessageID
-		return true
 	}
+	ctx.State["image_url"] = newCtx.State["image_url"]
+	ctx.Event.MessageID = newCtx.Event.MessageID
+	return true
 }
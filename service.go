@@ -0,0 +1,364 @@
+package zero
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/cubevlmu/CZeroBot/log"
+)
+
+// ServiceStorage 插件开关状态的持久化接口
+//
+// id 为 ServiceID 的返回值, 0 表示全局; userID 为 QQ号
+type ServiceStorage interface {
+	// LoadService 读取插件 name 的所有开关状态
+	LoadService(name string) (map[int64]bool, error)
+	// SaveService 保存插件 name 在 id 处的开关状态
+	SaveService(name string, id int64, enable bool) error
+	// DeleteService 删除插件 name 在 id 处的开关状态
+	DeleteService(name string, id int64) error
+	// LoadServiceUsers 读取插件 name 对所有用户的开关状态
+	LoadServiceUsers(name string) (map[int64]bool, error)
+	// SaveServiceUser 保存插件 name 对用户 userID 的开关状态
+	SaveServiceUser(name string, userID int64, enable bool) error
+	// DeleteServiceUser 删除插件 name 对用户 userID 的开关状态
+	DeleteServiceUser(name string, userID int64) error
+}
+
+var (
+	serviceStorage     ServiceStorage
+	serviceStorageLock sync.RWMutex
+)
+
+// SetServiceStorage 设置插件开关状态的持久化存储, 为 nil 时仅保存在内存中
+//
+// 所有插件将在下次使用时从 s 重新读取状态
+func SetServiceStorage(s ServiceStorage) {
+	serviceStorageLock.Lock()
+	serviceStorage = s
+	serviceStorageLock.Unlock()
+	for _, svc := range Services() {
+		svc.mu.Lock()
+		svc.loaded = false
+		svc.mu.Unlock()
+	}
+}
+
+func getServiceStorage() ServiceStorage {
+	serviceStorageLock.RLock()
+	defer serviceStorageLock.RUnlock()
+	return serviceStorage
+}
+
+// ServiceID 会话的开关状态对应的 ID, 群聊为群号, 私聊为 -QQ号
+//
+// 同一群内的所有用户共享该群的状态, 单个用户的状态见 Service.EnableUser
+func ServiceID(ctx *Ctx) int64 {
+	if ctx.Event.GroupID != 0 {
+		return ctx.Event.GroupID
+	}
+	return -ctx.Event.UserID
+}
+
+// Service 插件的开关控制, 状态按 ServiceID 划分, 也可单独为用户设置
+type Service struct {
+	info   PluginInfo
+	engine *Engine
+	mu     sync.Mutex
+	state  map[int64]bool
+	users  map[int64]bool // 用户的状态, 作用于其所在的所有会话
+	loaded bool           // 是否已从 storage 读取
+}
+
+func newService(e *Engine, info PluginInfo) *Service {
+	return &Service{
+		info:   info,
+		engine: e,
+		state:  make(map[int64]bool),
+		users:  make(map[int64]bool),
+	}
+}
+
+// Info 插件元数据
+func (s *Service) Info() PluginInfo {
+	return s.info
+}
+
+// Engine 插件的 Engine
+func (s *Service) Engine() *Engine {
+	return s.engine
+}
+
+// load 从 storage 读取状态, 需持有锁
+func (s *Service) load() {
+	if s.loaded {
+		return
+	}
+	s.loaded = true
+	storage := getServiceStorage()
+	if storage == nil {
+		return
+	}
+	state, err := storage.LoadService(s.info.Name)
+	if err != nil {
+		log.Errorf("[service] failed to load state of plugin %s: %v", s.info.Name, err)
+		return
+	}
+	users, err := storage.LoadServiceUsers(s.info.Name)
+	if err != nil {
+		log.Errorf("[service] failed to load user state of plugin %s: %v", s.info.Name, err)
+		return
+	}
+	s.state = make(map[int64]bool, len(state))
+	for id, enable := range state {
+		s.state[id] = enable
+	}
+	s.users = make(map[int64]bool, len(users))
+	for id, enable := range users {
+		s.users[id] = enable
+	}
+}
+
+// IsEnabledIn 插件在 id 处是否启用
+//
+// 依次查找 id 处的状态, 全局状态, 最后使用 DisableOnDefault
+func (s *Service) IsEnabledIn(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	return s.enabledIn(id)
+}
+
+// enabledIn 见 IsEnabledIn, 需持有锁
+func (s *Service) enabledIn(id int64) bool {
+	if enable, ok := s.state[id]; ok {
+		return enable
+	}
+	if enable, ok := s.state[0]; ok {
+		return enable
+	}
+	return !s.info.DisableOnDefault
+}
+
+// IsEnabledFor 插件对群 groupID (私聊时为 0) 中的用户 userID 是否启用
+//
+// 用户的状态优先, 未设置时同 IsEnabledIn
+func (s *Service) IsEnabledFor(groupID, userID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	if enable, ok := s.users[userID]; ok {
+		return enable
+	}
+	if groupID != 0 {
+		return s.enabledIn(groupID)
+	}
+	return s.enabledIn(-userID)
+}
+
+// Enable 在 id 处启用插件, id 为 0 时设置全局状态
+func (s *Service) Enable(id int64) error {
+	return s.set(id, true)
+}
+
+// Disable 在 id 处禁用插件, id 为 0 时设置全局状态
+func (s *Service) Disable(id int64) error {
+	return s.set(id, false)
+}
+
+// Reset 恢复插件在 id 处的默认状态
+func (s *Service) Reset(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	delete(s.state, id)
+	if storage := getServiceStorage(); storage != nil {
+		return storage.DeleteService(s.info.Name, id)
+	}
+	return nil
+}
+
+// EnableUser 对用户 userID 启用插件, 优先于其所在会话的状态
+func (s *Service) EnableUser(userID int64) error {
+	return s.setUser(userID, true)
+}
+
+// DisableUser 对用户 userID 禁用插件, 优先于其所在会话的状态
+func (s *Service) DisableUser(userID int64) error {
+	return s.setUser(userID, false)
+}
+
+// ResetUser 删除用户 userID 的状态, 恢复由会话决定
+func (s *Service) ResetUser(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	delete(s.users, userID)
+	if storage := getServiceStorage(); storage != nil {
+		return storage.DeleteServiceUser(s.info.Name, userID)
+	}
+	return nil
+}
+
+func (s *Service) setUser(userID int64, enable bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.users[userID] = enable
+	if storage := getServiceStorage(); storage != nil {
+		return storage.SaveServiceUser(s.info.Name, userID, enable)
+	}
+	return nil
+}
+
+func (s *Service) set(id int64, enable bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.load()
+	s.state[id] = enable
+	if storage := getServiceStorage(); storage != nil {
+		return storage.SaveService(s.info.Name, id, enable)
+	}
+	return nil
+}
+
+// handler 作为 PreHandler 过滤未启用插件的事件
+func (s *Service) handler(ctx *Ctx) bool {
+	return s.IsEnabledFor(ctx.Event.GroupID, ctx.Event.UserID)
+}
+
+var serviceManagerOnce sync.Once
+
+// UseServiceManager 注册内置的插件管理命令
+//
+//	help [插件名]    插件列表 / 插件的详细帮助
+//	enable 插件名... 在本群 (私聊) 启用插件, 群内需管理员权限
+//	disable 插件名... 在本群 (私聊) 禁用插件, 群内需管理员权限
+//	enable/disable 插件名... @用户... 对用户启用 (禁用) 插件, 需超级用户权限
+func UseServiceManager() {
+	serviceManagerOnce.Do(func() {
+		e := New()
+		e.OnCommand("help").SetBlock(true).Handle(func(ctx *Ctx) {
+			name := strings.TrimSpace(ctx.State["args"].(string))
+			if name == "" {
+				ctx.Send(HelpList(ServiceID(ctx)))
+				return
+			}
+			s, ok := LookupService(name)
+			if !ok {
+				ctx.Send("没有找到插件 " + name)
+				return
+			}
+			ctx.Send(s.HelpPage(ServiceID(ctx)))
+		})
+		e.OnCommandGroup([]string{"enable", "disable"}, UserOrGrpAdmin).SetBlock(true).Handle(func(ctx *Ctx) {
+			enable := ctx.State["command"] == "enable"
+			names := strings.Fields(ctx.State["args"].(string))
+			if len(names) == 0 {
+				ctx.Send("请指定插件名")
+				return
+			}
+			users := mentionedUsers(ctx)
+			if len(users) > 0 && !SuperUserPermission(ctx) { // 用户的状态作用于所有会话
+				ctx.Send("只有超级用户可以为用户开关插件")
+				return
+			}
+			verb := "禁用"
+			if enable {
+				verb = "启用"
+			}
+			target := ""
+			if len(users) > 0 {
+				target = "对用户 " + strings.Join(users, ", ") + " "
+			}
+			id := ServiceID(ctx)
+			var sb strings.Builder
+			for i, name := range names {
+				if i > 0 {
+					sb.WriteByte('\n')
+				}
+				s, ok := LookupService(name)
+				if !ok {
+					sb.WriteString("没有找到插件 " + name)
+					continue
+				}
+				var err error
+				if len(users) == 0 {
+					err = s.set(id, enable)
+				}
+				for _, u := range users {
+					uid, _ := strconv.ParseInt(u, 10, 64)
+					if err = s.setUser(uid, enable); err != nil {
+						break
+					}
+				}
+				if err != nil {
+					log.Errorf("[service] failed to save state of plugin %s: %v", name, err)
+					sb.WriteString("设置插件 " + name + " 失败")
+					continue
+				}
+				sb.WriteString("已" + target + verb + "插件 " + name)
+			}
+			ctx.Send(sb.String())
+		})
+	})
+}
+
+// mentionedUsers 消息中 @ 的用户, 不包括 @全体成员
+func mentionedUsers(ctx *Ctx) []string {
+	var users []string
+	for _, seg := range ctx.Event.Message {
+		if seg.Type != "at" {
+			continue
+		}
+		if _, err := strconv.ParseInt(seg.Data["qq"], 10, 64); err == nil {
+			users = append(users, seg.Data["qq"])
+		}
+	}
+	return users
+}
+
+// HelpList 生成在 id 处的插件列表, 不包含隐藏插件
+func HelpList(id int64) string {
+	var sb strings.Builder
+	sb.WriteString("插件列表 (● 启用 ○ 禁用):")
+	for _, s := range Services() {
+		if s.info.Hidden {
+			continue
+		}
+		sb.WriteByte('\n')
+		if s.IsEnabledIn(id) {
+			sb.WriteString("● ")
+		} else {
+			sb.WriteString("○ ")
+		}
+		sb.WriteString(s.info.Name)
+		if s.info.Brief != "" {
+			sb.WriteString(": ")
+			sb.WriteString(s.info.Brief)
+		}
+	}
+	sb.WriteString("\n发送 " + BotConfig.CommandPrefix + "help 插件名 查看详细帮助")
+	return sb.String()
+}
+
+// HelpPage 生成插件在 id 处的详细帮助
+func (s *Service) HelpPage(id int64) string {
+	var sb strings.Builder
+	sb.WriteString(s.info.Name)
+	if s.info.Brief != "" {
+		sb.WriteString(": ")
+		sb.WriteString(s.info.Brief)
+	}
+	if s.IsEnabledIn(id) {
+		sb.WriteString("\n状态: 启用")
+	} else {
+		sb.WriteString("\n状态: 禁用")
+	}
+	if s.info.Help != "" {
+		sb.WriteByte('\n')
+		sb.WriteString(s.info.Help)
+	}
+	return sb.String()
+}
@@ -0,0 +1,267 @@
+package zero_test
+
+import (
+	"strings"
+	"testing"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/storage"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+// useServiceStorage 在测试期间使用 s 保存插件开关状态
+func useServiceStorage(t *testing.T, s zero.ServiceStorage) {
+	zero.SetServiceStorage(s)
+	t.Cleanup(func() { zero.SetServiceStorage(nil) })
+}
+
+func TestServiceEnable(t *testing.T) {
+	e := zerotest.Plugin(t, zero.PluginInfo{Name: "svc-echo"})
+	e.OnCommand("echo").Handle(func(ctx *zero.Ctx) {
+		ctx.Send(ctx.State["args"])
+	})
+	s := e.Service()
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	if err := s.Disable(1); err != nil {
+		t.Fatal(err)
+	}
+	bot.GroupMessage(1, 10, "/echo a")
+	bot.GroupMessage(2, 10, "/echo b")
+	bot.PrivateMessage(10, "/echo c")
+	bot.AssertSent(t, "b", "c")
+
+	// 全局状态作用于没有单独设置的会话
+	if err := s.Disable(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Enable(2); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[int64]bool{1: false, 2: true, 3: false, -10: false} {
+		if got := s.IsEnabledIn(id); got != want {
+			t.Errorf("IsEnabledIn(%d) = %v, want %v", id, got, want)
+		}
+	}
+	if err := s.Reset(0); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsEnabledIn(3) {
+		t.Error("plugin still disabled after Reset(0)")
+	}
+
+	// 状态以群为单位, 群内的所有用户共享
+	bot.Reset()
+	bot.GroupMessage(1, 10, "/echo a")
+	bot.GroupMessage(1, 11, "/echo b")
+	bot.AssertSent(t)
+}
+
+func TestServiceUser(t *testing.T) {
+	e := zerotest.Plugin(t, zero.PluginInfo{Name: "svc-user"})
+	e.OnCommand("echo").Handle(func(ctx *zero.Ctx) {
+		ctx.Send(ctx.State["args"])
+	})
+	s := e.Service()
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	// 用户的状态作用于其所在的所有会话
+	if err := s.DisableUser(10); err != nil {
+		t.Fatal(err)
+	}
+	bot.GroupMessage(1, 10, "/echo a")
+	bot.PrivateMessage(10, "/echo b")
+	bot.GroupMessage(1, 11, "/echo c")
+	bot.AssertSent(t, "c")
+
+	// 用户的状态优先于群的状态
+	if err := s.Disable(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EnableUser(11); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		group, user int64
+		want        bool
+	}{{2, 11, true}, {2, 12, false}, {1, 10, false}, {0, 10, false}, {0, 12, true}} {
+		if got := s.IsEnabledFor(c.group, c.user); got != c.want {
+			t.Errorf("IsEnabledFor(%d, %d) = %v, want %v", c.group, c.user, got, c.want)
+		}
+	}
+	if err := s.ResetUser(10); err != nil {
+		t.Fatal(err)
+	}
+	bot.Reset()
+	bot.GroupMessage(1, 10, "/echo d")
+	bot.GroupMessage(2, 11, "/echo e")
+	bot.GroupMessage(2, 12, "/echo f")
+	bot.AssertSent(t, "d", "e")
+}
+
+func TestServiceDisableOnDefault(t *testing.T) {
+	s := zerotest.Plugin(t, zero.PluginInfo{Name: "svc-default-off", DisableOnDefault: true}).Service()
+	if s.IsEnabledIn(1) {
+		t.Fatal("DisableOnDefault plugin enabled")
+	}
+	if err := s.Enable(1); err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsEnabledIn(1) || s.IsEnabledIn(2) {
+		t.Fatal("Enable(1) leaked into other groups")
+	}
+	if err := s.Reset(1); err != nil {
+		t.Fatal(err)
+	}
+	if s.IsEnabledIn(1) {
+		t.Fatal("Reset did not restore DisableOnDefault")
+	}
+}
+
+func TestServicePersistence(t *testing.T) {
+	store := zero.NewServiceStorage(storage.NewMemory())
+	useServiceStorage(t, store)
+	s := zerotest.Plugin(t, zero.PluginInfo{Name: "svc-persist"}).Service()
+	if err := s.Disable(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Enable(-2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Disable(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Reset(3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DisableUser(5); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := store.LoadService("svc-persist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state) != 2 || state[1] || !state[-2] {
+		t.Fatalf("saved state %v, want map[-2:true 1:false]", state)
+	}
+	if users, err := store.LoadServiceUsers("svc-persist"); err != nil || len(users) != 1 || users[5] {
+		t.Fatalf("saved user state %v, %v, want map[5:false]", users, err)
+	}
+
+	// 重新设置存储后从中读取, 内存中的状态被丢弃
+	if err := store.SaveService("svc-persist", 4, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveServiceUser("svc-persist", 6, false); err != nil {
+		t.Fatal(err)
+	}
+	useServiceStorage(t, store)
+	for id, want := range map[int64]bool{1: false, -2: true, 3: true, 4: false} {
+		if got := s.IsEnabledIn(id); got != want {
+			t.Errorf("IsEnabledIn(%d) = %v, want %v", id, got, want)
+		}
+	}
+	if s.IsEnabledFor(3, 5) || s.IsEnabledFor(3, 6) || !s.IsEnabledFor(3, 7) {
+		t.Error("user state not restored from storage")
+	}
+	if err := s.ResetUser(6); err != nil {
+		t.Fatal(err)
+	}
+	if users, _ := store.LoadServiceUsers("svc-persist"); len(users) != 1 {
+		t.Fatalf("user state after ResetUser %v", users)
+	}
+	if err := store.DeleteService("svc-persist", 4); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := store.LoadService("svc-persist"); len(state) != 2 {
+		t.Fatalf("state after DeleteService %v", state)
+	}
+	if state, _ := store.LoadService("svc-none"); len(state) != 0 {
+		t.Fatalf("state of unknown plugin %v", state)
+	}
+}
+
+func TestServiceManager(t *testing.T) {
+	zerotest.Plugin(t, zero.PluginInfo{Name: "svc-a", Brief: "插件 A", Help: "/a 命令"})
+	zerotest.Plugin(t, zero.PluginInfo{Name: "svc-b", DisableOnDefault: true})
+	zerotest.Plugin(t, zero.PluginInfo{Name: "svc-hidden", Hidden: true})
+	zero.UseServiceManager()
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/", SuperUsers: []int64{99}})
+	admin := zerotest.Sender("admin", "admin")
+
+	bot.GroupMessage(1, 10, "/help")
+	list := bot.SentTexts()[0]
+	lines := make(map[string]bool)
+	for _, line := range strings.Split(list, "\n") {
+		lines[line] = true
+	}
+	for _, line := range []string{"插件列表 (● 启用 ○ 禁用):", "● svc-a: 插件 A", "○ svc-b", "发送 /help 插件名 查看详细帮助"} {
+		if !lines[line] {
+			t.Errorf("help list %q has no line %q", list, line)
+		}
+	}
+	if strings.Contains(list, "svc-hidden") {
+		t.Errorf("help list %q shows the hidden plugin", list)
+	}
+
+	bot.Reset()
+	bot.GroupMessage(1, 10, "/help svc-a")
+	bot.GroupMessage(1, 10, "/help svc-x")
+	bot.AssertSent(t, "svc-a: 插件 A\n状态: 启用\n/a 命令", "没有找到插件 svc-x")
+
+	// 群内开关需要管理员
+	bot.Reset()
+	bot.GroupMessage(1, 10, "/disable svc-a")
+	bot.AssertSent(t)
+	bot.GroupMessage(1, 10, "/disable svc-a svc-x", admin)
+	bot.GroupMessage(1, 10, "/enable svc-b", admin)
+	bot.GroupMessage(1, 10, "/enable", admin)
+	bot.AssertSent(t, "已禁用插件 svc-a\n没有找到插件 svc-x", "已启用插件 svc-b", "请指定插件名")
+	a, _ := zero.LookupService("svc-a")
+	b, _ := zero.LookupService("svc-b")
+	if a.IsEnabledIn(1) || !b.IsEnabledIn(1) || !a.IsEnabledIn(2) || b.IsEnabledIn(2) {
+		t.Fatal("enable/disable not applied to group 1 only")
+	}
+	if got := zero.HelpList(1); !strings.Contains(got, "○ svc-a") || !strings.Contains(got, "● svc-b") {
+		t.Errorf("HelpList(1) = %q", got)
+	}
+
+	// 私聊中无需管理员, 作用于 -QQ号
+	bot.Reset()
+	bot.PrivateMessage(10, "/disable svc-a")
+	bot.AssertSent(t, "已禁用插件 svc-a")
+	if a.IsEnabledIn(-10) || !a.IsEnabledIn(-11) {
+		t.Fatal("private disable not applied to -10 only")
+	}
+	if got := a.HelpPage(-10); got != "svc-a: 插件 A\n状态: 禁用\n/a 命令" {
+		t.Errorf("HelpPage(-10) = %q", got)
+	}
+
+	// @ 用户时开关该用户的状态, 需要超级用户
+	bot.Reset()
+	bot.GroupMessage(1, 10, "/disable svc-b [CQ:at,qq=20][CQ:at,qq=21]", admin)
+	bot.GroupMessage(1, 99, "/disable svc-b [CQ:at,qq=20][CQ:at,qq=21]")
+	bot.GroupMessage(2, 99, "/enable svc-a svc-b [CQ:at,qq=22]")
+	bot.AssertSent(t, "只有超级用户可以为用户开关插件", "已对用户 20, 21 禁用插件 svc-b",
+		"已对用户 22 启用插件 svc-a\n已对用户 22 启用插件 svc-b")
+	if b.IsEnabledFor(1, 20) || b.IsEnabledFor(1, 21) || !b.IsEnabledFor(1, 10) {
+		t.Fatal("user disable not applied to users 20 and 21 only")
+	}
+	if !a.IsEnabledFor(1, 22) || !b.IsEnabledFor(2, 22) || !a.IsEnabledIn(2) || b.IsEnabledIn(2) {
+		t.Fatal("user enable changed the group state or was not applied")
+	}
+}
+
+func TestServiceStorageFailure(t *testing.T) {
+	store := storage.NewMemory()
+	useServiceStorage(t, zero.NewServiceStorage(store))
+	s := zerotest.Plugin(t, zero.PluginInfo{Name: "svc-closed"}).Service()
+	_ = store.Close()
+	if err := s.Disable(1); err == nil {
+		t.Fatal("Disable with a closed storage returned nil")
+	}
+	if err := s.Reset(1); err == nil {
+		t.Fatal("Reset with a closed storage returned nil")
+	}
+}
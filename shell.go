@@ -0,0 +1,261 @@
+package zero
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cubevlmu/CZeroBot/utils/shell"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// shellField 带 flag tag 的字段
+type shellField struct {
+	index int
+	name  string
+	usage string
+}
+
+// ShellRule 类 shell 命令规则
+//
+// 在 CommandRule 的基础上切分参数, 并将 `flag:"..."` tag 的字段
+// 从参数中解析至 model 的新实例, 可选 `help:"..."` tag 作为选项说明
+//
+// 匹配成功时 State["flag"] 为 *model, State["args"] 为剩余的位置参数 []string
+//
+// 参数解析失败时会向用户发送自动生成的用法说明
+func ShellRule(cmd string, model interface{}) Rule {
+	t := reflect.TypeOf(model)
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("can't use %v as shell command model", t))
+	}
+	fields := make([]shellField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := f.Tag.Lookup("flag")
+		if !ok || name == "" {
+			continue
+		}
+		if !isShellType(f.Type) {
+			panic(fmt.Sprintf("unsupported type %v of flag %q in %v", f.Type, name, t))
+		}
+		fields = append(fields, shellField{index: i, name: name, usage: f.Tag.Get("help")})
+	}
+	defaults := reflect.ValueOf(model)
+	cmdRule := CommandRule(cmd)
+	return func(ctx *Ctx) bool {
+		if !cmdRule(ctx) {
+			return false
+		}
+		val := reflect.New(t)
+		val.Elem().Set(defaults)
+		fs := newShellFlagSet(cmd, fields, val.Elem())
+		args, err := shell.Parse(ctx.State["args"].(string))
+		if err == nil {
+			err = fs.Parse(args)
+		}
+		if err != nil {
+			usage := shellUsage(ctx.State["command"].(string), fields, defaults)
+			if !errors.Is(err, flag.ErrHelp) {
+				usage = "参数错误: " + err.Error() + "\n" + usage
+			}
+			ctx.Send(usage)
+			return false
+		}
+		ctx.State["args"] = fs.Args()
+		ctx.State["flag"] = val.Interface()
+		return true
+	}
+}
+
+// OnShell shell命令触发器
+func OnShell(command string, model interface{}, rules ...Rule) *Matcher {
+	return defaultEngine.OnShell(command, model, rules...)
+}
+
+// OnShell shell命令触发器
+func (e *Engine) OnShell(command string, model interface{}, rules ...Rule) *Matcher {
+	matcher := &Matcher{
+		Type:   Type("message"),
+		Rules:  append([]Rule{ShellRule(command, model)}, rules...),
+		Engine: e, // ShellRule 解析失败时会发送用法说明, 不能直接判断
+	}
+	e.matchers = append(e.matchers, matcher)
+	return StoreMatcher(matcher)
+}
+
+func isShellType(t reflect.Type) bool {
+	if t == durationType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.String, reflect.Float64:
+		return true
+	case reflect.Slice:
+		e := t.Elem()
+		return e != reflect.TypeOf(false) && e.Kind() != reflect.Slice && isShellType(e)
+	default:
+		return false
+	}
+}
+
+// newShellFlagSet 将 fields 绑定至 v 的对应字段
+func newShellFlagSet(cmd string, fields []shellField, v reflect.Value) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	for _, f := range fields {
+		fv := v.Field(f.index)
+		p := fv.Addr().Interface()
+		switch x := p.(type) {
+		case *bool:
+			fs.BoolVar(x, f.name, *x, f.usage)
+		case *int:
+			fs.IntVar(x, f.name, *x, f.usage)
+		case *int64:
+			fs.Int64Var(x, f.name, *x, f.usage)
+		case *uint:
+			fs.UintVar(x, f.name, *x, f.usage)
+		case *uint64:
+			fs.Uint64Var(x, f.name, *x, f.usage)
+		case *string:
+			fs.StringVar(x, f.name, *x, f.usage)
+		case *float64:
+			fs.Float64Var(x, f.name, *x, f.usage)
+		case *time.Duration:
+			fs.DurationVar(x, f.name, *x, f.usage)
+		default: // slice
+			fs.Var(&sliceValue{v: fv}, f.name, f.usage)
+		}
+	}
+	return fs
+}
+
+// shellUsage 根据 model 生成用法说明
+func shellUsage(command string, fields []shellField, defaults reflect.Value) string {
+	sb := strings.Builder{}
+	sb.WriteString("用法: ")
+	sb.WriteString(BotConfig.CommandPrefix)
+	sb.WriteString(command)
+	if len(fields) > 0 {
+		sb.WriteString(" [选项]")
+	}
+	sb.WriteString(" [参数...]")
+	if len(fields) == 0 {
+		return sb.String()
+	}
+	sb.WriteString("\n选项:")
+	for _, f := range fields {
+		fv := defaults.Field(f.index)
+		sb.WriteString("\n  -")
+		sb.WriteString(f.name)
+		if fv.Kind() != reflect.Bool {
+			sb.WriteByte(' ')
+			sb.WriteString(shellTypeName(fv.Type()))
+		}
+		if f.usage != "" {
+			sb.WriteString("  ")
+			sb.WriteString(f.usage)
+		}
+		if !fv.IsZero() {
+			sb.WriteString(" (默认: ")
+			sb.WriteString((&sliceValue{v: fv}).format())
+			sb.WriteByte(')')
+		}
+	}
+	return sb.String()
+}
+
+func shellTypeName(t reflect.Type) string {
+	if t == durationType {
+		return "duration"
+	}
+	if t.Kind() == reflect.Slice {
+		return shellTypeName(t.Elem()) + ",..."
+	}
+	return t.Kind().String()
+}
+
+// sliceValue 切片类型的 flag, 可重复指定或以逗号分隔
+type sliceValue struct {
+	v   reflect.Value
+	set bool
+}
+
+// String impls flag.Value
+func (s *sliceValue) String() string {
+	if s == nil || !s.v.IsValid() {
+		return ""
+	}
+	return s.format()
+}
+
+// format 以逗号分隔输出切片, 非切片直接输出
+func (s *sliceValue) format() string {
+	if s.v.Kind() != reflect.Slice {
+		return fmt.Sprint(s.v.Interface())
+	}
+	strs := make([]string, s.v.Len())
+	for i := range strs {
+		strs[i] = fmt.Sprint(s.v.Index(i).Interface())
+	}
+	return strings.Join(strs, ",")
+}
+
+// Set impls flag.Value
+func (s *sliceValue) Set(str string) error {
+	if !s.set { // 首次指定时覆盖默认值
+		s.v.Set(reflect.MakeSlice(s.v.Type(), 0, 1))
+		s.set = true
+	}
+	for _, item := range strings.Split(str, ",") {
+		e := reflect.New(s.v.Type().Elem()).Elem()
+		if err := setShellValue(e, item); err != nil {
+			return err
+		}
+		s.v.Set(reflect.Append(s.v, e))
+	}
+	return nil
+}
+
+// setShellValue 将 str 转换为 v 的类型并赋值
+func setShellValue(v reflect.Value, str string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint64:
+		u, err := strconv.ParseUint(str, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
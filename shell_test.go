@@ -0,0 +1,111 @@
+package zero_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+type pingFlags struct {
+	T       bool          `flag:"t" help:"持续 ping"`
+	Count   int           `flag:"n"`
+	Host    string        `flag:"host" help:"目标主机"`
+	Wait    time.Duration `flag:"w"`
+	Ports   []uint        `flag:"p"`
+	Tags    []string      `flag:"tag"`
+	Ignored string
+}
+
+func TestShellBind(t *testing.T) {
+	e := zerotest.Engine(t)
+	var (
+		got  *pingFlags
+		args []string
+	)
+	e.OnShell("ping", pingFlags{Count: 4, Tags: []string{"default"}}).Handle(func(ctx *zero.Ctx) {
+		got = ctx.State["flag"].(*pingFlags)
+		args = ctx.State["args"].([]string)
+	})
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	for _, c := range []struct {
+		in   string
+		want pingFlags
+		args []string
+	}{
+		{"/ping", pingFlags{Count: 4, Tags: []string{"default"}}, nil},
+		{"/ping -t -n 2 a b", pingFlags{T: true, Count: 2, Tags: []string{"default"}}, []string{"a", "b"}},
+		{`/ping --host="a b" -w=1m30s x`, pingFlags{Count: 4, Host: "a b", Wait: 90 * time.Second, Tags: []string{"default"}}, []string{"x"}},
+		{"/ping -t=false -p 80,443 -p 8080 -tag x", pingFlags{Count: 4, Ports: []uint{80, 443, 8080}, Tags: []string{"x"}}, nil},
+		{"/ping -- -t", pingFlags{Count: 4, Tags: []string{"default"}}, []string{"-t"}},
+	} {
+		got, args = nil, nil
+		bot.GroupMessage(1, 2, c.in)
+		if got == nil {
+			t.Fatalf("%q: handler not called, sent %q", c.in, bot.SentTexts())
+		}
+		if !reflect.DeepEqual(*got, c.want) {
+			t.Errorf("%q: flags %+v, want %+v", c.in, *got, c.want)
+		}
+		if fmt.Sprintf("%q", args) != fmt.Sprintf("%q", c.args) { // nil 与空切片均可
+			t.Errorf("%q: args %q, want %q", c.in, args, c.args)
+		}
+	}
+	bot.AssertSent(t)
+
+	// 解析失败时不调用 Handler, 发送用法说明
+	for _, in := range []string{"/ping -n x", "/ping -unknown", `/ping "a`, "/ping -p -1", "/ping -h"} {
+		got = nil
+		bot.Reset()
+		bot.GroupMessage(1, 2, in)
+		if got != nil {
+			t.Errorf("%q: handler called with %+v", in, *got)
+		}
+		sent := bot.SentTexts()
+		if len(sent) != 1 || !strings.Contains(sent[0], "用法: /ping [选项] [参数...]") {
+			t.Fatalf("%q: sent %q, want usage", in, sent)
+		}
+		if help := in == "/ping -h"; help == strings.HasPrefix(sent[0], "参数错误") {
+			t.Errorf("%q: usage %q", in, sent[0])
+		}
+	}
+	want := strings.Join([]string{
+		"用法: /ping [选项] [参数...]",
+		"选项:",
+		"  -t  持续 ping",
+		"  -n int (默认: 4)",
+		"  -host string  目标主机",
+		"  -w duration",
+		"  -p uint,...",
+		"  -tag string,... (默认: default)",
+	}, "\n")
+	if sent := bot.SentTexts(); sent[0] != want {
+		t.Errorf("usage\n%s\nwant\n%s", sent[0], want)
+	}
+}
+
+func TestShellRuleUnsupportedType(t *testing.T) {
+	for _, model := range []interface{}{
+		struct {
+			B []bool `flag:"b"`
+		}{},
+		struct {
+			M map[string]string `flag:"m"`
+		}{},
+		1,
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ShellRule(%T) did not panic", model)
+				}
+			}()
+			zero.ShellRule("x", model)
+		}()
+	}
+}
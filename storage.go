@@ -0,0 +1,111 @@
+package zero
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/cubevlmu/CZeroBot/storage"
+)
+
+var (
+	defaultStore     storage.Store = storage.NewMemory()
+	defaultStoreLock sync.RWMutex
+)
+
+// SetStorage 设置插件使用的存储, 默认为内存存储
+//
+// 应在注册插件后, Run 之前调用, 旧的存储不会被关闭
+func SetStorage(s storage.Store) {
+	defaultStoreLock.Lock()
+	defer defaultStoreLock.Unlock()
+	defaultStore = s
+}
+
+// Storage 插件使用的存储
+func Storage() storage.Store {
+	defaultStoreLock.RLock()
+	defer defaultStoreLock.RUnlock()
+	return defaultStore
+}
+
+// Storage 该 Engine 的 bucket
+//
+// Register 生成的插件为 plugin/插件名, 其余 Engine 为 engine/名称, 名称见 SetName
+func (e *Engine) Storage() storage.Bucket {
+	if e.service != nil {
+		return storage.NewBucket(Storage(), "plugin/"+e.service.info.Name)
+	}
+	return storage.NewBucket(Storage(), "engine/"+e.name)
+}
+
+// Storage 当前 Matcher 所属 Engine 的 bucket 中, 以 ServiceID 划分的本群 (私聊) bucket
+func (ctx *Ctx) Storage() storage.Bucket {
+	e := defaultEngine
+	if ctx.ma != nil && ctx.ma.Engine != nil {
+		e = ctx.ma.Engine
+	}
+	return e.Storage().Bucket(strconv.FormatInt(ServiceID(ctx), 10))
+}
+
+// storeServiceStorage 将插件开关状态保存在 storage.Store 的 service/插件名 中
+type storeServiceStorage struct {
+	store storage.Store
+}
+
+// NewServiceStorage 使用 s 保存插件开关状态, 可传入 SetServiceStorage
+func NewServiceStorage(s storage.Store) ServiceStorage {
+	return storeServiceStorage{store: s}
+}
+
+func (s storeServiceStorage) bucket(name string) storage.Bucket {
+	return storage.NewBucket(s.store, "service/"+name)
+}
+
+// LoadService 读取插件 name 的所有开关状态
+func (s storeServiceStorage) LoadService(name string) (map[int64]bool, error) {
+	state := make(map[int64]bool)
+	err := storage.Iterate(s.bucket(name), func(key string, enable bool) bool {
+		if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+			state[id] = enable
+		}
+		return true
+	})
+	return state, err
+}
+
+// SaveService 保存插件 name 在 id 处的开关状态
+func (s storeServiceStorage) SaveService(name string, id int64, enable bool) error {
+	return storage.Set(s.bucket(name), strconv.FormatInt(id, 10), enable, 0)
+}
+
+// DeleteService 删除插件 name 在 id 处的开关状态
+func (s storeServiceStorage) DeleteService(name string, id int64) error {
+	return s.bucket(name).Delete(strconv.FormatInt(id, 10))
+}
+
+// userBucket 用户的开关状态, 保存在 service/插件名/user 中
+func (s storeServiceStorage) userBucket(name string) storage.Bucket {
+	return s.bucket(name).Bucket("user")
+}
+
+// LoadServiceUsers 读取插件 name 对所有用户的开关状态
+func (s storeServiceStorage) LoadServiceUsers(name string) (map[int64]bool, error) {
+	users := make(map[int64]bool)
+	err := storage.Iterate(s.userBucket(name), func(key string, enable bool) bool {
+		if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+			users[id] = enable
+		}
+		return true
+	})
+	return users, err
+}
+
+// SaveServiceUser 保存插件 name 对用户 userID 的开关状态
+func (s storeServiceStorage) SaveServiceUser(name string, userID int64, enable bool) error {
+	return storage.Set(s.userBucket(name), strconv.FormatInt(userID, 10), enable, 0)
+}
+
+// DeleteServiceUser 删除插件 name 对用户 userID 的开关状态
+func (s storeServiceStorage) DeleteServiceUser(name string, userID int64) error {
+	return s.userBucket(name).Delete(strconv.FormatInt(userID, 10))
+}
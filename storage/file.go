@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/cubevlmu/CZeroBot/log"
+)
+
+// compactMinRecords 文件中的记录数少于此值时不压缩
+const compactMinRecords = 1024
+
+// record 文件中的一行, 即一次写入或删除
+type record struct {
+	Op     string `json:"o"` // s: set, d: delete
+	Bucket string `json:"b"`
+	Key    string `json:"k"`
+	Value  []byte `json:"v,omitempty"`
+	Expire int64  `json:"e,omitempty"`
+}
+
+// File 以 JSON Lines 追加写入的文件存储
+//
+// 数据全部保存在内存中, 每次修改追加一行记录, 打开时回放所有记录.
+// 记录数超过存活键数的两倍时自动压缩文件
+type File struct {
+	mem     *Memory
+	mu      sync.Mutex // 写锁
+	path    string
+	f       *os.File
+	w       *bufio.Writer
+	records int // 文件中的记录数
+	next    int // 记录数达到此值时检查是否需要压缩
+}
+
+// OpenFile 打开 path 处的文件存储, 不存在时创建
+func OpenFile(path string) (*File, error) {
+	s := &File{mem: NewMemory(), path: path, next: compactMinRecords}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err = repair(f); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	s.f = f
+	s.w = bufio.NewWriter(f)
+	return s, nil
+}
+
+// load 回放文件中的记录
+func (s *File) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		var r record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil { // 写入中断时的残缺行
+			log.Warningf("[storage] skip broken record at %s:%d: %v", s.path, line, err)
+			continue
+		}
+		s.records++
+		switch r.Op {
+		case "s":
+			s.mem.set(r.Bucket, r.Key, &entry{value: r.Value, expire: r.Expire})
+		case "d":
+			s.mem.delete(r.Bucket, r.Key)
+		}
+	}
+	return sc.Err()
+}
+
+// repair 使 f 以换行结尾, 以免之后追加的记录接在写入中断的残缺行后
+//
+// 最后一行完整但缺少换行时补上换行, 否则截断至上一个换行
+func repair(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	end := info.Size()
+	buf := make([]byte, 4096)
+	if _, err = f.ReadAt(buf[:1], end-1); err != nil {
+		return err
+	}
+	if buf[0] == '\n' {
+		return nil
+	}
+	start := int64(0) // 最后一行的起始位置
+	for off := end; off > 0; {
+		n := int64(len(buf))
+		if n > off {
+			n = off
+		}
+		off -= n
+		if _, err = f.ReadAt(buf[:n], off); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			start = off + int64(i) + 1
+			break
+		}
+	}
+	last := make([]byte, end-start)
+	if _, err = f.ReadAt(last, start); err != nil {
+		return err
+	}
+	if json.Valid(last) {
+		_, err = f.Write([]byte{'\n'})
+		return err
+	}
+	log.Warningf("[storage] truncate broken record at the end of %s", f.Name())
+	return f.Truncate(start)
+}
+
+// Get 读取 bucket 中 key 的值
+func (s *File) Get(bucket, key string) ([]byte, bool, error) {
+	return s.mem.Get(bucket, key)
+}
+
+// Iterate 按键的字典序遍历 bucket
+func (s *File) Iterate(bucket string, f func(key string, value []byte) bool) error {
+	return s.mem.Iterate(bucket, f)
+}
+
+// Set 写入 bucket 中 key 的值
+func (s *File) Set(bucket, key string, value []byte, ttl time.Duration) error {
+	return s.write(record{Op: "s", Bucket: bucket, Key: key, Value: value, Expire: expireAt(ttl)})
+}
+
+// Delete 删除 bucket 中的 key
+func (s *File) Delete(bucket, key string) error {
+	return s.write(record{Op: "d", Bucket: bucket, Key: key})
+}
+
+// write 追加记录并更新内存
+func (s *File) write(r record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return ErrClosed
+	}
+	if err := s.append(r); err != nil {
+		return err
+	}
+	buffered := s.w.Buffered()
+	if err := s.w.Flush(); err != nil {
+		s.discard(buffered - s.w.Buffered())
+		return err
+	}
+	s.mem.mu.Lock()
+	if r.Op == "s" {
+		s.mem.set(r.Bucket, r.Key, &entry{value: append([]byte(nil), r.Value...), expire: r.Expire})
+	} else {
+		s.mem.delete(r.Bucket, r.Key)
+	}
+	live := -1
+	if s.records >= s.next {
+		live = s.mem.purge()
+	}
+	s.mem.mu.Unlock()
+	if live >= 0 {
+		if s.records > 2*live {
+			if err := s.compact(); err != nil {
+				log.Warningf("[storage] failed to compact %s: %v", s.path, err)
+			}
+		}
+		s.next = 2 * s.records
+		if s.next < compactMinRecords {
+			s.next = compactMinRecords
+		}
+	}
+	return nil
+}
+
+// discard 丢弃写入失败的记录, 需持有写锁
+//
+// 清空缓冲并截去已写入文件的 written 字节, 使文件与未更新的内存一致
+func (s *File) discard(written int) {
+	s.records--
+	s.w.Reset(s.f)
+	if written == 0 {
+		return
+	}
+	fi, err := s.f.Stat()
+	if err == nil {
+		err = s.f.Truncate(fi.Size() - int64(written))
+	}
+	if err != nil {
+		log.Warningf("[storage] failed to discard partial record in %s: %v", s.path, err)
+	}
+}
+
+func (s *File) append(r record) error {
+	data, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+	if _, err = s.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.records++
+	return nil
+}
+
+// Compact 移除过期的键并重写文件, 使每个键只保留一条记录
+func (s *File) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return ErrClosed
+	}
+	s.mem.Purge()
+	return s.compact()
+}
+
+// compact 将存活的键写入临时文件后替换原文件, 需持有写锁
+func (s *File) compact() error {
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	old, oldw, oldrecords := s.f, s.w, s.records
+	s.f, s.w, s.records = f, bufio.NewWriter(f), 0
+	restore := func(err error) error {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		s.f, s.w, s.records = old, oldw, oldrecords
+		return err
+	}
+	s.mem.mu.RLock()
+	names := make([]string, 0, len(s.mem.buckets))
+	for name := range s.mem.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for k, e := range s.mem.buckets[name] {
+			if err = s.append(record{Op: "s", Bucket: name, Key: k, Value: e.value, Expire: e.expire}); err != nil {
+				s.mem.mu.RUnlock()
+				return restore(err)
+			}
+		}
+	}
+	s.mem.mu.RUnlock()
+	if err = s.w.Flush(); err != nil {
+		return restore(err)
+	}
+	if err = f.Sync(); err != nil {
+		return restore(err)
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return restore(err)
+	}
+	_ = old.Close()
+	return nil
+}
+
+// Close 写入缓冲并关闭文件
+func (s *File) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.w.Flush()
+	if serr := s.f.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	s.f = nil
+	_ = s.mem.Close()
+	return err
+}
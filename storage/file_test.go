@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFileRepairsBrokenTail(t *testing.T) {
+	for name, tail := range map[string]string{
+		"partial":    `{"o":"s","b":"b","k":"lost","v":"d`,
+		"no newline": `{"o":"s","b":"b","k":"kept","v":"dg=="}`,
+	} {
+		path := filepath.Join(t.TempDir(), "store.jsonl")
+		if err := os.WriteFile(path, []byte(`{"o":"s","b":"b","k":"a","v":"YQ=="}`+"\n"+tail), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		s, err := OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = s.Set("b", "c", []byte("c"), 0); err != nil {
+			t.Fatal(err)
+		}
+		if err = s.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err = OpenFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, key := range []string{"a", "c"} {
+			if _, ok, _ := s.Get("b", key); !ok {
+				t.Errorf("%s: key %q lost after reopening", name, key)
+			}
+		}
+		_, ok, _ := s.Get("b", "kept")
+		if want := name == "no newline"; ok != want {
+			t.Errorf("%s: complete last record present %v, want %v", name, ok, want)
+		}
+		_ = s.Close()
+	}
+}
+
+// lines 文件的行数
+func lines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Count(data, []byte{'\n'})
+}
+
+func TestFileCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err = s.Set("b", "k", []byte{byte('0' + i)}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = s.Set("b", "deleted", []byte("x"), 0)
+	_ = s.Delete("b", "deleted")
+	_ = s.Set("b", "expired", []byte("x"), time.Nanosecond)
+	_ = s.Set("c", "other", []byte("y"), time.Hour)
+	if n := lines(t, path); n != 14 {
+		t.Fatalf("%d records before Compact, want 14", n)
+	}
+
+	if err = s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if n := lines(t, path); n != 2 {
+		t.Fatalf("%d records after Compact, want 2", n)
+	}
+	if _, err = os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file left: %v", err)
+	}
+	// 压缩后继续追加到新文件
+	if err = s.Set("b", "after", []byte("z"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, c := range []struct{ bucket, key, value string }{{"b", "k", "9"}, {"c", "other", "y"}, {"b", "after", "z"}} {
+		if v, ok, _ := s.Get(c.bucket, c.key); !ok || string(v) != c.value {
+			t.Errorf("%s/%s = %q, %v after reopening, want %q", c.bucket, c.key, v, ok, c.value)
+		}
+	}
+	for _, key := range []string{"deleted", "expired"} {
+		if _, ok, _ := s.Get("b", key); ok {
+			t.Errorf("%s key restored after Compact", key)
+		}
+	}
+}
+
+func TestFileAutoCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i := 0; i < compactMinRecords+10; i++ {
+		if err = s.Set("b", "k", []byte(strconv.Itoa(i)), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := lines(t, path); n > 11 {
+		t.Fatalf("%d records after %d writes to one key, want compacted", n, compactMinRecords+10)
+	}
+	if v, _, _ := s.Get("b", "k"); string(v) != strconv.Itoa(compactMinRecords+9) {
+		t.Fatalf("value after compaction %q", v)
+	}
+}
+
+// failingWriter 写入前 n 字节后返回错误
+type failingWriter struct {
+	f *os.File
+	n int
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		p = p[:w.n]
+	}
+	n, _ := w.f.Write(p)
+	return n, errors.New("disk full")
+}
+
+func TestFileFlushFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.jsonl")
+	s, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Set("b", "a", []byte("a"), 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{0, 5} {
+		s.w = bufio.NewWriter(failingWriter{f: s.f, n: n})
+		if err = s.Set("b", "lost", []byte("x"), 0); err == nil {
+			t.Fatalf("Set returned nil after writing %d bytes", n)
+		}
+		if _, ok, _ := s.Get("b", "lost"); ok {
+			t.Fatal("failed record applied to memory")
+		}
+	}
+	// 失败的记录不会随之后的写入一同写入文件
+	if err = s.Set("b", "c", []byte("c"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if s.records != 2 || lines(t, path) != 2 {
+		t.Fatalf("%d records, %d lines after failed writes, want 2", s.records, lines(t, path))
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for key, want := range map[string]bool{"a": true, "c": true, "lost": false} {
+		if _, ok, _ := s.Get("b", key); ok != want {
+			t.Errorf("key %q present %v after reopening, want %v", key, ok, want)
+		}
+	}
+}
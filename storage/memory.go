@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// entry 带过期时间的值
+type entry struct {
+	value  []byte
+	expire int64 // unix nano, 0 为永不过期
+}
+
+func (e *entry) expired(now int64) bool {
+	return e.expire != 0 && e.expire <= now
+}
+
+func expireAt(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(ttl).UnixNano()
+}
+
+// sweepInterval Memory 在写入时清理过期键的最短间隔
+const sweepInterval = time.Minute
+
+// Memory 内存存储, 进程退出后数据丢失
+//
+// 过期的键在 Set 时每 sweepInterval 至多清理一次, 也可调用 Purge 立即清理
+type Memory struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string]*entry
+	swept   int64 // 上次清理的 unix nano
+	closed  bool
+}
+
+// NewMemory 生成空的内存存储
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]map[string]*entry)}
+}
+
+// Get 读取 bucket 中 key 的值
+func (m *Memory) Get(bucket, key string) ([]byte, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return nil, false, ErrClosed
+	}
+	e, ok := m.buckets[bucket][key]
+	if !ok || e.expired(time.Now().UnixNano()) {
+		return nil, false, nil
+	}
+	return append([]byte(nil), e.value...), true, nil
+}
+
+// Set 写入 bucket 中 key 的值
+func (m *Memory) Set(bucket, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	m.set(bucket, key, &entry{value: append([]byte(nil), value...), expire: expireAt(ttl)})
+	if now := time.Now().UnixNano(); now-m.swept >= int64(sweepInterval) {
+		m.purge()
+		m.swept = now
+	}
+	return nil
+}
+
+func (m *Memory) set(bucket, key string, e *entry) {
+	b, ok := m.buckets[bucket]
+	if !ok {
+		b = make(map[string]*entry)
+		m.buckets[bucket] = b
+	}
+	b[key] = e
+}
+
+// Delete 删除 bucket 中的 key
+func (m *Memory) Delete(bucket, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	m.delete(bucket, key)
+	return nil
+}
+
+func (m *Memory) delete(bucket, key string) {
+	b, ok := m.buckets[bucket]
+	if !ok {
+		return
+	}
+	delete(b, key)
+	if len(b) == 0 {
+		delete(m.buckets, bucket)
+	}
+}
+
+// Iterate 按键的字典序遍历 bucket, 遍历期间可以修改存储
+func (m *Memory) Iterate(bucket string, f func(key string, value []byte) bool) error {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return ErrClosed
+	}
+	now := time.Now().UnixNano()
+	b := m.buckets[bucket]
+	keys := make([]string, 0, len(b))
+	values := make(map[string][]byte, len(b))
+	for k, e := range b {
+		if !e.expired(now) {
+			keys = append(keys, k)
+			values[k] = e.value
+		}
+	}
+	m.mu.RUnlock()
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !f(k, append([]byte(nil), values[k]...)) {
+			break
+		}
+	}
+	return nil
+}
+
+// Purge 移除所有已过期的键, 返回剩余的键数
+func (m *Memory) Purge() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.purge()
+}
+
+func (m *Memory) purge() (n int) {
+	now := time.Now().UnixNano()
+	for name, b := range m.buckets {
+		for k, e := range b {
+			if e.expired(now) {
+				delete(b, k)
+			}
+		}
+		if len(b) == 0 {
+			delete(m.buckets, name)
+		}
+		n += len(b)
+	}
+	return
+}
+
+// Close 关闭存储并释放数据
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed = true
+	m.buckets = nil
+	return nil
+}
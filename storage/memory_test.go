@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// stored 键是否仍保存在 m 中, 包括已过期但未清理的键
+func stored(m *Memory, bucket, key string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.buckets[bucket][key]
+	return ok
+}
+
+func TestMemoryTTL(t *testing.T) {
+	m := NewMemory()
+	for key, ttl := range map[string]time.Duration{"forever": 0, "negative": -1, "hour": time.Hour, "expired": time.Nanosecond} {
+		if err := m.Set("b", key, []byte(key), ttl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, key := range []string{"forever", "negative", "hour"} {
+		if v, ok, err := m.Get("b", key); err != nil || !ok || string(v) != key {
+			t.Errorf("Get(%q) = %q, %v, %v", key, v, ok, err)
+		}
+	}
+	if _, ok, _ := m.Get("b", "expired"); ok {
+		t.Error("expired key visible to Get")
+	}
+	var keys []string
+	_ = m.Iterate("b", func(key string, _ []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if len(keys) != 3 || keys[0] != "forever" || keys[1] != "hour" || keys[2] != "negative" {
+		t.Errorf("Iterate keys %q, want the 3 live keys in order", keys)
+	}
+
+	// 覆盖写入时重新计算过期时间
+	if err := m.Set("b", "expired", []byte("again"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := m.Get("b", "expired"); !ok {
+		t.Error("rewritten key still expired")
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	m := NewMemory()
+	if err := m.Set("a", "live", nil, 0); err != nil { // 首次写入时清理
+		t.Fatal(err)
+	}
+	if err := m.Set("b", "expired", nil, time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	if !stored(m, "b", "expired") {
+		t.Fatal("expired key swept within sweepInterval")
+	}
+
+	m.swept -= int64(sweepInterval)
+	if err := m.Set("a", "other", nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	if stored(m, "b", "expired") {
+		t.Fatal("expired key not swept by Set after sweepInterval")
+	}
+	if _, ok := m.buckets["b"]; ok {
+		t.Fatal("empty bucket kept after sweep")
+	}
+
+	if err := m.Set("a", "expired", nil, time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	if n := m.Purge(); n != 2 {
+		t.Fatalf("Purge left %d keys, want 2", n)
+	}
+	if stored(m, "a", "expired") {
+		t.Fatal("expired key kept by Purge")
+	}
+}
+
+func TestMemoryClosed(t *testing.T) {
+	m := NewMemory()
+	_ = m.Close()
+	if _, _, err := m.Get("b", "k"); err != ErrClosed {
+		t.Errorf("Get after Close: %v", err)
+	}
+	if err := m.Set("b", "k", nil, 0); err != ErrClosed {
+		t.Errorf("Set after Close: %v", err)
+	}
+	if err := m.Iterate("b", func(string, []byte) bool { return true }); err != ErrClosed {
+		t.Errorf("Iterate after Close: %v", err)
+	}
+}
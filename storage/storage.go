@@ -0,0 +1,108 @@
+// Package storage 插件状态的键值存储
+//
+// 数据按 bucket 分隔, 每个 Engine 拥有独立的 bucket, 并可按群/用户继续划分
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrClosed 存储已被关闭
+var ErrClosed = errors.New("storage: closed")
+
+// Store 键值存储后端
+//
+// ttl <= 0 表示永不过期, 已过期的键对 Get 与 Iterate 不可见
+type Store interface {
+	// Get 读取 bucket 中 key 的值, 不存在时 ok 为 false
+	Get(bucket, key string) (value []byte, ok bool, err error)
+	// Set 写入 bucket 中 key 的值
+	Set(bucket, key string, value []byte, ttl time.Duration) error
+	// Delete 删除 bucket 中的 key, 不存在时不返回错误
+	Delete(bucket, key string) error
+	// Iterate 遍历 bucket 中的所有键, f 返回 false 时停止
+	Iterate(bucket string, f func(key string, value []byte) bool) error
+	// Close 关闭存储
+	Close() error
+}
+
+// Bucket Store 中的命名空间
+type Bucket struct {
+	store Store
+	name  string
+}
+
+// NewBucket 生成 store 中名为 name 的 Bucket
+func NewBucket(store Store, name string) Bucket {
+	return Bucket{store: store, name: name}
+}
+
+// Name bucket 的完整名称
+func (b Bucket) Name() string {
+	return b.name
+}
+
+// Bucket 生成子命名空间, 名称以 / 分隔
+func (b Bucket) Bucket(name string) Bucket {
+	if b.name == "" {
+		return Bucket{store: b.store, name: name}
+	}
+	return Bucket{store: b.store, name: b.name + "/" + strings.TrimPrefix(name, "/")}
+}
+
+// GetBytes 读取原始值
+func (b Bucket) GetBytes(key string) ([]byte, bool, error) {
+	return b.store.Get(b.name, key)
+}
+
+// SetBytes 写入原始值
+func (b Bucket) SetBytes(key string, value []byte, ttl time.Duration) error {
+	return b.store.Set(b.name, key, value, ttl)
+}
+
+// Delete 删除 key
+func (b Bucket) Delete(key string) error {
+	return b.store.Delete(b.name, key)
+}
+
+// IterateBytes 遍历原始值
+func (b Bucket) IterateBytes(f func(key string, value []byte) bool) error {
+	return b.store.Iterate(b.name, f)
+}
+
+// Get 读取 key 并以 JSON 解码为 T, 不存在时 ok 为 false
+func Get[T any](b Bucket, key string) (v T, ok bool, err error) {
+	data, ok, err := b.GetBytes(key)
+	if err != nil || !ok {
+		return
+	}
+	err = json.Unmarshal(data, &v)
+	return v, err == nil, err
+}
+
+// Set 以 JSON 编码 v 并写入 key
+func Set[T any](b Bucket, key string, v T, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.SetBytes(key, data, ttl)
+}
+
+// Iterate 遍历 bucket, 值以 JSON 解码为 T, 无法解码的值将返回错误
+func Iterate[T any](b Bucket, f func(key string, v T) bool) (err error) {
+	ierr := b.IterateBytes(func(key string, value []byte) bool {
+		var v T
+		if err = json.Unmarshal(value, &v); err != nil {
+			return false
+		}
+		return f(key, v)
+	})
+	if ierr != nil {
+		return ierr
+	}
+	return err
+}
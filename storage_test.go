@@ -0,0 +1,52 @@
+package zero_test
+
+import (
+	"testing"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/storage"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestEngineStorage(t *testing.T) {
+	a, b := zerotest.Engine(t), zerotest.Engine(t)
+	if a.Storage().Name() == b.Storage().Name() {
+		t.Fatalf("two engines share the bucket %q", a.Storage().Name())
+	}
+	if err := storage.Set(a.Storage(), "k", "a", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := storage.Get[string](b.Storage(), "k"); ok {
+		t.Fatal("key written by one engine is visible to another")
+	}
+
+	if name := zerotest.Engine(t).SetName("named").Storage().Name(); name != "engine/named" {
+		t.Errorf("named engine uses bucket %q", name)
+	}
+	if name := zerotest.Plugin(t, zero.PluginInfo{Name: "storage-plugin"}).Storage().Name(); name != "plugin/storage-plugin" {
+		t.Errorf("plugin uses bucket %q", name)
+	}
+}
+
+func TestCtxStorage(t *testing.T) {
+	e := zerotest.Engine(t)
+	e.OnCommand("count").Handle(func(ctx *zero.Ctx) {
+		n, _, _ := storage.Get[int](ctx.Storage(), "n")
+		n++
+		_ = storage.Set(ctx.Storage(), "n", n, 0)
+		ctx.Send(n)
+	})
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	bot.GroupMessage(1, 10, "/count")
+	bot.GroupMessage(1, 11, "/count")
+	bot.GroupMessage(2, 10, "/count")
+	bot.PrivateMessage(10, "/count")
+	bot.AssertSent(t, "1", "2", "1", "1")
+	if n, _, _ := storage.Get[int](e.Storage().Bucket("1"), "n"); n != 2 {
+		t.Fatalf("group 1 count %d, want 2", n)
+	}
+	if n, _, _ := storage.Get[int](e.Storage().Bucket("-10"), "n"); n != 1 {
+		t.Fatalf("private count %d, want 1", n)
+	}
+}
@@ -0,0 +1,385 @@
+package zero
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SendLimit 每个账号发送消息的频率限制
+//
+// 超出限制的消息按会话排队, 同一会话内先进先出
+type SendLimit struct {
+	PerSecond      float64 `json:"per_second"`       // 每个账号每秒最多发送的消息数, <= 0 不限制
+	Burst          int     `json:"burst"`            // 每个账号最多累积的突发消息数, 默认 1
+	GroupPerSecond float64 `json:"group_per_second"` // 每个群每秒最多发送的消息数, <= 0 不限制
+	GroupBurst     int     `json:"group_burst"`      // 每个群最多累积的突发消息数, 默认 1
+}
+
+// bucket 生成速率为 perSecond 的令牌桶, 不限制时返回 nil
+func (l *SendLimit) bucket(perSecond float64, burst int) *tokenBucket {
+	if perSecond <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{interval: time.Duration(float64(time.Second) / perSecond), burst: float64(burst)}
+}
+
+// sendPriorityKey context 中发送优先级的键
+type sendPriorityKey struct{}
+
+// WithSendPriority 设置通过 c 发送的消息在队列中的优先级, 越小越优先, 默认为 0
+//
+//	ctx.API().WithContext(zero.WithSendPriority(ctx.Context(), -1)).SendGroupMessage(gid, msg)
+func WithSendPriority(c context.Context, priority int) context.Context {
+	return context.WithValue(c, sendPriorityKey{}, priority)
+}
+
+// sendJob 排队中的发送请求
+type sendJob struct {
+	ctx      context.Context
+	req      APIRequest
+	conv     string // 会话
+	group    int64  // 群号, 非群消息为 0
+	priority int
+	seq      uint64
+	done     chan sendResult
+	canceled bool // 需持有 SendScheduler.mu
+}
+
+type sendResult struct {
+	rsp APIResponse
+	err error
+}
+
+// SendScheduler 对发送消息限速的 APICaller
+//
+// 发送类 API 按 SendLimit 排队, 在发送完成后返回, 其余 API 直接调用.
+// 不同会话的消息并发发送, 同一会话在上一条发送完成前不会发送下一条,
+// 因此某一会话的发送阻塞时不影响其他会话
+type SendScheduler struct {
+	mu      sync.Mutex
+	caller  APICaller
+	limit   SendLimit
+	account *tokenBucket
+	group   *tokenBucket
+	state   *tokenBucketState
+	groups  map[int64]*tokenBucketState
+	queues  map[string][]*sendJob // 会话到其队列
+	sending map[string]bool       // 正在发送的会话
+	pending int
+	seq     uint64
+	running bool
+	wakeup  chan struct{}
+}
+
+// NewSendScheduler 使用 limit 限制通过 caller 发送消息的频率
+func NewSendScheduler(caller APICaller, limit SendLimit) *SendScheduler {
+	return &SendScheduler{
+		caller:  caller,
+		limit:   limit,
+		account: limit.bucket(limit.PerSecond, limit.Burst),
+		group:   limit.bucket(limit.GroupPerSecond, limit.GroupBurst),
+		groups:  make(map[int64]*tokenBucketState),
+		queues:  make(map[string][]*sendJob),
+		sending: make(map[string]bool),
+		wakeup:  make(chan struct{}, 1),
+	}
+}
+
+// Len 排队中的消息数
+func (s *SendScheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pending
+}
+
+// LenOf 会话中排队的消息数, groupID 非 0 时为群, 否则为私聊 userID
+func (s *SendScheduler) LenOf(groupID, userID int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if groupID != 0 {
+		return len(s.queues["g"+strconv.FormatInt(groupID, 10)])
+	}
+	return len(s.queues["p"+strconv.FormatInt(userID, 10)])
+}
+
+// setCaller 更换底层 caller, 用于重连后
+func (s *SendScheduler) setCaller(caller APICaller) {
+	s.mu.Lock()
+	s.caller = caller
+	s.mu.Unlock()
+}
+
+// CallAPI 调用 API, 发送消息时排队
+func (s *SendScheduler) CallAPI(request APIRequest) (APIResponse, error) {
+	return s.CallAPIContext(context.Background(), request)
+}
+
+// CallAPIContext 调用 API, 发送消息时排队, ctx 取消时放弃排队
+func (s *SendScheduler) CallAPIContext(ctx context.Context, request APIRequest) (APIResponse, error) {
+	conv, group, ok := sendConversation(request)
+	s.mu.Lock()
+	caller := s.caller
+	if !ok || (s.account == nil && s.group == nil) {
+		s.mu.Unlock()
+		return CallAPIContext(ctx, caller, request)
+	}
+	priority, _ := ctx.Value(sendPriorityKey{}).(int)
+	s.seq++
+	job := &sendJob{
+		ctx:      ctx,
+		req:      request,
+		conv:     conv,
+		group:    group,
+		priority: priority,
+		seq:      s.seq,
+		done:     make(chan sendResult, 1),
+	}
+	s.queues[conv] = append(s.queues[conv], job)
+	s.pending++
+	if !s.running {
+		s.running = true
+		go s.dispatch()
+	} else {
+		s.wake()
+	}
+	s.mu.Unlock()
+	select {
+	case r := <-job.done:
+		return r.rsp, r.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		job.canceled = true
+		s.mu.Unlock()
+		select {
+		case r := <-job.done: // 已开始发送
+			return r.rsp, r.err
+		default:
+		}
+		return APIResponse{}, ctx.Err()
+	}
+}
+
+// wake 唤醒等待中的 dispatch
+func (s *SendScheduler) wake() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch 按限速取出排队的消息交给 send, 队列为空时退出
+func (s *SendScheduler) dispatch() {
+	for {
+		s.mu.Lock()
+		job, wait := s.next(time.Now())
+		if job == nil && len(s.queues) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		if job != nil {
+			s.sending[job.conv] = true
+			go s.send(job, s.caller)
+			s.mu.Unlock()
+			continue
+		}
+		s.mu.Unlock()
+		if wait == 0 { // 均在等待发送完成
+			<-s.wakeup
+			continue
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-s.wakeup:
+		}
+		t.Stop()
+	}
+}
+
+// send 发送 job, 完成后允许发送其会话的下一条消息
+func (s *SendScheduler) send(job *sendJob, caller APICaller) {
+	rsp, err := CallAPIContext(job.ctx, caller, job.req)
+	job.done <- sendResult{rsp: rsp, err: err}
+	s.mu.Lock()
+	delete(s.sending, job.conv)
+	s.wake()
+	s.mu.Unlock()
+}
+
+// next 取出可以在 now 发送的优先级最高的消息, 需持有锁
+//
+// 没有可发送的消息时返回需要等待的时间, 为 0 时需等待正在发送的会话完成
+func (s *SendScheduler) next(now time.Time) (*sendJob, time.Duration) {
+	if s.state == nil && s.account != nil {
+		s.state = s.account.newState().(*tokenBucketState)
+	}
+	var best *sendJob
+	var wait time.Duration
+	for conv, q := range s.queues {
+		for len(q) > 0 && q[0].canceled { // 丢弃已取消的请求
+			q = q[1:]
+			s.pending--
+		}
+		if len(q) == 0 {
+			delete(s.queues, conv)
+			continue
+		}
+		s.queues[conv] = q
+		if s.sending[conv] {
+			continue
+		}
+		head := q[0]
+		if w := s.groupWait(head.group, now); w > 0 {
+			if wait == 0 || w < wait {
+				wait = w
+			}
+			continue
+		}
+		if best == nil || head.priority < best.priority || (head.priority == best.priority && head.seq < best.seq) {
+			best = head
+		}
+	}
+	if best == nil {
+		return nil, wait
+	}
+	if s.account != nil {
+		if ok, w := s.account.take(s.state, now, false); !ok {
+			if w <= 0 { // 舍入误差
+				w = time.Millisecond
+			}
+			return nil, w
+		}
+	}
+	if s.group != nil && best.group != 0 {
+		st, ok := s.groups[best.group]
+		if !ok {
+			st = s.group.newState().(*tokenBucketState)
+			s.groups[best.group] = st
+		}
+		s.group.take(st, now, false)
+	}
+	q := s.queues[best.conv]
+	if len(q) == 1 {
+		delete(s.queues, best.conv)
+	} else {
+		s.queues[best.conv] = q[1:]
+	}
+	s.pending--
+	return best, 0
+}
+
+// groupWait 群 groupID 在 now 还需等待的时间, 需持有锁
+func (s *SendScheduler) groupWait(groupID int64, now time.Time) time.Duration {
+	if s.group == nil || groupID == 0 {
+		return 0
+	}
+	st, ok := s.groups[groupID]
+	if !ok {
+		st = s.group.newState().(*tokenBucketState)
+		s.groups[groupID] = st
+	}
+	peek := *st
+	if ok, w := s.group.take(&peek, now, false); !ok {
+		if w <= 0 { // 舍入误差
+			w = time.Millisecond
+		}
+		return w
+	}
+	if peek.tokens+1 >= s.group.burst { // 令牌已满, 与新的状态相同, 不再记录
+		delete(s.groups, groupID)
+	}
+	return 0
+}
+
+// sendConversation 发送类 API 的会话与群号
+func sendConversation(req APIRequest) (conv string, group int64, ok bool) {
+	switch req.Action {
+	case "send_msg", "send_private_msg", "send_group_msg", "send_guild_channel_msg",
+		"send_group_forward_msg", "send_private_forward_msg", "send_forward_msg":
+	default:
+		return "", 0, false
+	}
+	if req.Action == "send_guild_channel_msg" {
+		return "c" + paramString(req.Params["guild_id"]) + "/" + paramString(req.Params["channel_id"]), 0, true
+	}
+	if g := paramString(req.Params["group_id"]); g != "" && g != "0" {
+		group, _ = strconv.ParseInt(g, 10, 64)
+		return "g" + g, group, true
+	}
+	return "p" + paramString(req.Params["user_id"]), 0, true
+}
+
+// paramString 将 ID 参数转为字符串
+func paramString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case int:
+		return strconv.Itoa(x)
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// schedulers 每个账号的 SendScheduler
+var (
+	schedulers   sync.Map // map[int64]*SendScheduler
+	schedulersMu sync.Mutex
+)
+
+// stopSchedulers 移除所有账号的 SendScheduler, 已排队的消息仍会发送
+func stopSchedulers() {
+	schedulersMu.Lock()
+	defer schedulersMu.Unlock()
+	schedulers.Range(func(id, _ interface{}) bool {
+		schedulers.Delete(id)
+		return true
+	})
+}
+
+// GetSendScheduler 获取账号 selfID 的 SendScheduler, 未设置 Config.SendLimit 时不存在
+func GetSendScheduler(selfID int64) (*SendScheduler, bool) {
+	s, ok := schedulers.Load(selfID)
+	if !ok {
+		return nil, false
+	}
+	return s.(*SendScheduler), true
+}
+
+// outbound 按 BotConfig.SendLimit 包装账号 selfID 的 caller, 已包装的原样返回
+//
+// 由 StoreCaller 在连接时调用, 事件与 GetBot 直接使用 APICallers 中包装后的 caller
+func outbound(selfID int64, caller APICaller) APICaller {
+	switch caller.(type) {
+	case *SendScheduler, *recordCaller: // 由 StoreCaller 存入
+		return caller
+	}
+	caller = recording(selfID, caller)
+	limit := BotConfig.SendLimit
+	if limit == nil || caller == nil {
+		return caller
+	}
+	schedulersMu.Lock()
+	defer schedulersMu.Unlock()
+	if v, ok := schedulers.Load(selfID); ok {
+		s := v.(*SendScheduler)
+		if s.limit == *limit { // 限制变化时重新创建
+			s.setCaller(caller)
+			return s
+		}
+	}
+	s := NewSendScheduler(caller, *limit)
+	schedulers.Store(selfID, s)
+	return s
+}
@@ -0,0 +1,133 @@
+package zero_test
+
+import (
+	"testing"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestSendLimit(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	e := zerotest.Engine(t)
+	e.OnCommand("flood").Handle(func(ctx *zero.Ctx) {
+		for _, s := range []string{"1", "2", "3"} {
+			ctx.Send(s)
+		}
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/", SendLimit: &zero.SendLimit{PerSecond: float64(time.Second / interval)}})
+
+	start := time.Now()
+	bot.GroupMessage(1, 2, "/flood")
+	bot.AssertSent(t, "1", "2", "3")
+	if d := time.Since(start); d < 2*interval {
+		t.Fatalf("3 messages sent in %v, want at least %v", d, 2*interval)
+	}
+
+	// 直接从 APICallers 或 GetBot 取得的 caller 同样受限制
+	caller, ok := zero.APICallers.Load(zerotest.SelfID)
+	if !ok {
+		t.Fatal("caller not stored")
+	}
+	if _, ok := caller.(*zero.SendScheduler); !ok {
+		t.Fatalf("stored caller is %T, want *zero.SendScheduler", caller)
+	}
+	bot.Reset()
+	start = time.Now()
+	if _, err := caller.CallAPI(zero.APIRequest{Action: "send_group_msg", Params: zero.Params{"group_id": 1, "message": "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	zero.GetBot(zerotest.SelfID).SendGroupMessage(1, "b")
+	zero.GetBot(zerotest.SelfID).SendGroupMessage(1, "c")
+	bot.AssertSent(t, "a", "b", "c")
+	if d := time.Since(start); d < 2*interval {
+		t.Fatalf("3 messages sent in %v, want at least %v", d, 2*interval)
+	}
+}
+
+// hangCaller 记录发送的消息, 消息为 hang 时阻塞至 release 关闭
+type hangCaller struct {
+	sent    chan string
+	release chan struct{}
+}
+
+func (c *hangCaller) CallAPI(req zero.APIRequest) (zero.APIResponse, error) {
+	msg := req.Params["message"].(string)
+	c.sent <- msg
+	if msg == "hang" {
+		<-c.release
+	}
+	return zero.APIResponse{Status: "ok"}, nil
+}
+
+func TestSendSchedulerHungTarget(t *testing.T) {
+	c := &hangCaller{sent: make(chan string, 8), release: make(chan struct{})}
+	s := zero.NewSendScheduler(c, zero.SendLimit{PerSecond: 1000, Burst: 10})
+	send := func(group int64, msg string) <-chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = s.CallAPI(zero.APIRequest{Action: "send_group_msg", Params: zero.Params{"group_id": group, "message": msg}})
+		}()
+		return done
+	}
+	next := func() string {
+		select {
+		case msg := <-c.sent:
+			return msg
+		case <-time.After(5 * time.Second):
+			t.Fatal("no message sent")
+			return ""
+		}
+	}
+
+	hung := send(1, "hang")
+	if msg := next(); msg != "hang" {
+		t.Fatalf("sent %q, want hang", msg)
+	}
+	after := send(1, "after") // 同一会话, 需等待 hang 完成
+	for s.LenOf(1, 0) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 其他会话不受影响
+	select {
+	case <-send(2, "other"):
+	case <-time.After(5 * time.Second):
+		t.Fatal("send to another group blocked by the hung send")
+	}
+	if msg := next(); msg != "other" {
+		t.Fatalf("sent %q, want other", msg)
+	}
+	select {
+	case msg := <-c.sent:
+		t.Fatalf("%q sent before the previous message of its group finished", msg)
+	default:
+	}
+
+	close(c.release)
+	<-hung
+	<-after
+	if msg := next(); msg != "after" {
+		t.Fatalf("sent %q, want after", msg)
+	}
+	if n := s.Len(); n != 0 {
+		t.Fatalf("%d messages left in queue", n)
+	}
+}
+
+func TestStopRemovesSendScheduler(t *testing.T) {
+	bot := zerotest.New(zerotest.SelfID)
+	bot.Run(zero.Config{SendLimit: &zero.SendLimit{PerSecond: 10}})
+	if _, ok := zero.GetSendScheduler(zerotest.SelfID); !ok {
+		t.Fatal("no SendScheduler while running")
+	}
+	if err := bot.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := zero.GetSendScheduler(zerotest.SelfID); ok {
+		t.Fatal("SendScheduler kept after Stop")
+	}
+}
@@ -0,0 +1,86 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// jsonExporter 将 Span 以 JSON Lines 写入 w
+type jsonExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONExporter 将每个 Span 以一行 JSON 写入 w, 字段名与 OTLP/JSON 相同
+func NewJSONExporter(w io.Writer) Exporter {
+	return &jsonExporter{enc: json.NewEncoder(w)}
+}
+
+// NewStdoutExporter 输出至标准输出的 JSON Exporter, 用于本地调试
+func NewStdoutExporter() Exporter {
+	return NewJSONExporter(os.Stdout)
+}
+
+type jsonAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+// anyValue OTLP 的 AnyValue
+func anyValue(v interface{}) map[string]interface{} {
+	switch x := v.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": x}
+	case bool:
+		return map[string]interface{}{"boolValue": x}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(x, 10)}
+	case int:
+		return map[string]interface{}{"intValue": strconv.Itoa(x)}
+	case float64:
+		return map[string]interface{}{"doubleValue": x}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(x)}
+	}
+}
+
+type jsonStatus struct {
+	Code    StatusCode `json:"code"`
+	Message string     `json:"message,omitempty"`
+}
+
+type jsonSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []jsonAttribute `json:"attributes,omitempty"`
+	Status            jsonStatus      `json:"status"`
+}
+
+func (e *jsonExporter) ExportSpan(s *SpanData) {
+	js := jsonSpan{
+		TraceID:           s.TraceID.String(),
+		SpanID:            s.SpanID.String(),
+		ParentSpanID:      s.ParentSpanID.String(),
+		Name:              s.Name,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		Status:            jsonStatus{Code: s.StatusCode, Message: s.StatusMessage},
+	}
+	if len(s.Attributes) > 0 {
+		js.Attributes = make([]jsonAttribute, len(s.Attributes))
+		for i, a := range s.Attributes {
+			js.Attributes[i] = jsonAttribute{Key: a.Key, Value: anyValue(a.Value)}
+		}
+	}
+	e.mu.Lock()
+	_ = e.enc.Encode(&js)
+	e.mu.Unlock()
+}
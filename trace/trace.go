@@ -0,0 +1,216 @@
+// Package trace 与 OpenTelemetry 数据模型兼容的简易追踪
+//
+// 未设置 Exporter 时 Start 返回 nil Span, 其所有方法均为空操作
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID W3C Trace Context 的 trace-id
+type TraceID [16]byte
+
+// String 小写十六进制
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID W3C Trace Context 的 parent-id
+type SpanID [8]byte
+
+// String 小写十六进制, 为零值时为 ""
+func (s SpanID) String() string {
+	if s == (SpanID{}) {
+		return ""
+	}
+	return hex.EncodeToString(s[:])
+}
+
+// Attribute Span 的属性
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// String 字符串属性
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Int64 整数属性
+func Int64(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Bool 布尔属性
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// StatusCode Span 的状态, 与 OpenTelemetry 相同
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+// SpanData 结束的 Span, 交给 Exporter
+type SpanData struct {
+	TraceID       TraceID
+	SpanID        SpanID
+	ParentSpanID  SpanID // 根 Span 为零值
+	Name          string
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// Exporter 导出结束的 Span
+//
+// ExportSpan 在 Span.End 的协程中同步调用, 耗时的导出应自行排队
+type Exporter interface {
+	ExportSpan(s *SpanData)
+}
+
+// exporterHolder 用于在 atomic.Value 中保存不同类型的 Exporter
+type exporterHolder struct {
+	Exporter
+}
+
+var exporter atomic.Value // exporterHolder
+
+// SetExporter 设置 Exporter, 为 nil 时关闭追踪
+func SetExporter(e Exporter) {
+	exporter.Store(exporterHolder{e})
+}
+
+// Enabled 是否设置了 Exporter
+func Enabled() bool {
+	h, _ := exporter.Load().(exporterHolder)
+	return h.Exporter != nil
+}
+
+// Span 进行中的操作
+type Span struct {
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+type spanKey struct{}
+
+// SpanFromContext c 中的 Span, 不存在时为 nil
+func SpanFromContext(c context.Context) *Span {
+	if c == nil {
+		return nil
+	}
+	s, _ := c.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithSpan 返回带有 s 的 context, 之后在其上 Start 的 Span 将以 s 为父
+func ContextWithSpan(c context.Context, s *Span) context.Context {
+	return context.WithValue(c, spanKey{}, s)
+}
+
+// Start 以 c 中的 Span 为父开始新的 Span, 未启用追踪时返回 c 与 nil
+func Start(c context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if !Enabled() {
+		return c, nil
+	}
+	if c == nil {
+		c = context.Background()
+	}
+	s := &Span{data: SpanData{
+		Name:       name,
+		Start:      time.Now(),
+		Attributes: attrs,
+	}}
+	if parent := SpanFromContext(c); parent != nil {
+		s.data.TraceID = parent.data.TraceID
+		s.data.ParentSpanID = parent.data.SpanID
+	} else {
+		putUint64(s.data.TraceID[:8], rand.Uint64())
+		putUint64(s.data.TraceID[8:], rand.Uint64())
+	}
+	putUint64(s.data.SpanID[:], rand.Uint64())
+	return ContextWithSpan(c, s), s
+}
+
+func putUint64(b []byte, v uint64) {
+	for i := range b {
+		b[i] = byte(v >> (8 * i))
+	}
+}
+
+// TraceID Span 所属的 trace
+func (s *Span) TraceID() TraceID {
+	if s == nil {
+		return TraceID{}
+	}
+	return s.data.TraceID
+}
+
+// SpanID Span 的 ID
+func (s *Span) SpanID() SpanID {
+	if s == nil {
+		return SpanID{}
+	}
+	return s.data.SpanID
+}
+
+// SetAttributes 添加属性
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+	s.mu.Unlock()
+}
+
+// SetStatus 设置状态
+func (s *Span) SetStatus(code StatusCode, msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.StatusCode = code
+	s.data.StatusMessage = msg
+	s.mu.Unlock()
+}
+
+// RecordError err 不为 nil 时将状态设为 StatusError
+func (s *Span) RecordError(err error) {
+	if err != nil {
+		s.SetStatus(StatusError, err.Error())
+	}
+}
+
+// End 结束并导出, 重复调用无效
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+	if h, _ := exporter.Load().(exporterHolder); h.Exporter != nil {
+		h.ExportSpan(&data)
+	}
+}
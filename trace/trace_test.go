@@ -0,0 +1,123 @@
+package trace_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/cubevlmu/CZeroBot/trace"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestDisabled(t *testing.T) {
+	trace.SetExporter(nil)
+	c := context.Background()
+	c2, span := trace.Start(c, "noop")
+	if span != nil || c2 != c {
+		t.Fatal("Start created a span without an exporter")
+	}
+	// nil Span 的方法均为空操作
+	span.SetAttributes(trace.Bool("a", true))
+	span.RecordError(errors.New("x"))
+	span.End()
+	if trace.SpanFromContext(c) != nil || trace.SpanFromContext(nil) != nil {
+		t.Fatal("span found in a context without one")
+	}
+}
+
+func TestParenting(t *testing.T) {
+	spans := zerotest.RecordSpans(t)
+	c, root := trace.Start(context.Background(), "root")
+	c, child := trace.Start(c, "child")
+	_, grandchild := trace.Start(c, "grandchild")
+	_, sibling := trace.Start(trace.ContextWithSpan(context.Background(), root), "sibling")
+	_, other := trace.Start(context.Background(), "other")
+	for _, s := range []*trace.Span{grandchild, child, sibling, root, other} {
+		s.End()
+	}
+
+	all := spans.All()
+	if len(all) != 5 {
+		t.Fatalf("%d spans exported, want 5", len(all))
+	}
+	byName := make(map[string]trace.SpanData)
+	for _, d := range all {
+		byName[d.Name] = d
+	}
+	for name, parent := range map[string]string{"child": "root", "grandchild": "child", "sibling": "root"} {
+		d, p := byName[name], byName[parent]
+		if d.TraceID != p.TraceID || d.ParentSpanID != p.SpanID {
+			t.Errorf("%s is not a child of %s", name, parent)
+		}
+	}
+	for _, name := range []string{"root", "other"} {
+		if byName[name].ParentSpanID != (trace.SpanID{}) {
+			t.Errorf("%s has a parent", name)
+		}
+	}
+	if byName["other"].TraceID == byName["root"].TraceID {
+		t.Error("unrelated root spans share a trace")
+	}
+	if byName["child"].SpanID == byName["root"].SpanID {
+		t.Error("child reuses the span ID of its parent")
+	}
+}
+
+func TestSpanData(t *testing.T) {
+	spans := zerotest.RecordSpans(t)
+	_, span := trace.Start(context.Background(), "call", trace.String("action", "send"))
+	span.SetAttributes(trace.Int64("retcode", 100))
+	span.RecordError(nil)
+	span.RecordError(errors.New("failed"))
+	span.End()
+	span.End() // 重复调用无效
+
+	all := spans.All()
+	if len(all) != 1 {
+		t.Fatalf("%d spans exported, want 1", len(all))
+	}
+	d := all[0]
+	if d.StatusCode != trace.StatusError || d.StatusMessage != "failed" {
+		t.Errorf("status %v %q", d.StatusCode, d.StatusMessage)
+	}
+	if len(d.Attributes) != 2 || d.Attributes[1].Value != int64(100) {
+		t.Errorf("attributes %v", d.Attributes)
+	}
+	if d.End.Before(d.Start) {
+		t.Error("span ended before it started")
+	}
+}
+
+func TestJSONExporter(t *testing.T) {
+	var buf bytes.Buffer
+	trace.SetExporter(trace.NewJSONExporter(&buf))
+	defer trace.SetExporter(nil)
+	c, root := trace.Start(context.Background(), "root", trace.Int64("n", 1), trace.Bool("b", true))
+	_, child := trace.Start(c, "child")
+	child.End()
+	root.End()
+
+	dec := json.NewDecoder(&buf)
+	var spans [2]map[string]interface{}
+	for i := range spans {
+		if err := dec.Decode(&spans[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c0, r0 := spans[0], spans[1]
+	if c0["parentSpanId"] != r0["spanId"] || c0["traceId"] != r0["traceId"] {
+		t.Errorf("child %v is not linked to root %v", c0, r0)
+	}
+	if _, ok := r0["parentSpanId"]; ok {
+		t.Error("root span has parentSpanId")
+	}
+	if id, _ := r0["traceId"].(string); len(id) != 32 {
+		t.Errorf("traceId %q is not 16 bytes of hex", id)
+	}
+	attrs, _ := json.Marshal(r0["attributes"])
+	if want := `[{"key":"n","value":{"intValue":"1"}},{"key":"b","value":{"boolValue":true}}]`; string(attrs) != want {
+		t.Errorf("attributes %s, want %s", attrs, want)
+	}
+}
@@ -0,0 +1,66 @@
+package zero
+
+import (
+	"fmt"
+
+	"github.com/cubevlmu/CZeroBot/trace"
+)
+
+// startEventSpan 为事件开始根 Span, 置于 ctx.Context() 中
+func startEventSpan(ctx *Ctx) {
+	if !trace.Enabled() {
+		return
+	}
+	e := ctx.Event
+	attrs := []trace.Attribute{
+		trace.String("event.post_type", e.PostType),
+		trace.String("event.detail_type", e.DetailType),
+		trace.Int64("event.self_id", e.SelfID),
+	}
+	if e.SubType != "" {
+		attrs = append(attrs, trace.String("event.sub_type", e.SubType))
+	}
+	if id := e.RawEvent.Get("id"); id.Exists() { // OneBot 12 事件 ID
+		attrs = append(attrs, trace.String("event.id", id.String()))
+	}
+	if e.MessageID != nil {
+		attrs = append(attrs, trace.String("event.message_id", fmt.Sprint(e.MessageID)))
+	}
+	if e.GroupID != 0 {
+		attrs = append(attrs, trace.Int64("event.group_id", e.GroupID))
+	}
+	if e.UserID != 0 {
+		attrs = append(attrs, trace.Int64("event.user_id", e.UserID))
+	}
+	ctx.stdctx, _ = trace.Start(ctx.stdctx, "event "+e.PostType, attrs...)
+}
+
+// endEventSpan 结束事件的根 Span
+func endEventSpan(ctx *Ctx) {
+	trace.SpanFromContext(ctx.stdctx).End()
+}
+
+// stageSpan 开始 Matcher m 的 stage 阶段的 Span
+func stageSpan(ctx *Ctx, m *Matcher, stage string) *trace.Span {
+	if !trace.Enabled() {
+		return nil
+	}
+	_, span := trace.Start(ctx.stdctx, "match "+stage,
+		trace.String("matcher", matcherName(m)),
+		trace.String("stage", stage),
+		trace.Int64("matcher.priority", int64(m.Priority)),
+	)
+	return span
+}
+
+// endStageSpan 结束阶段的 Span, ok 为该阶段是否通过
+func endStageSpan(span *trace.Span, ok, timeout bool) {
+	if span == nil {
+		return
+	}
+	span.SetAttributes(trace.Bool("ok", ok))
+	if timeout {
+		span.SetStatus(trace.StatusError, "timeout")
+	}
+	span.End()
+}
@@ -0,0 +1,80 @@
+package zero_test
+
+import (
+	"testing"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/trace"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+// attr Span 中 key 的属性值
+func attr(d trace.SpanData, key string) interface{} {
+	for _, a := range d.Attributes {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return nil
+}
+
+func TestEventSpans(t *testing.T) {
+	spans := zerotest.RecordSpans(t)
+	e := zerotest.Engine(t)
+	e.OnCommand("trace").Handle(func(ctx *zero.Ctx) {
+		_, span := trace.Start(ctx.Context(), "work")
+		span.End()
+	})
+	e.OnMessage(func(*zero.Ctx) bool { return false }).Handle(func(*zero.Ctx) {})
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+	bot.GroupMessage(1, 2, "/trace")
+
+	events := spans.Named("event message")
+	if len(events) != 1 {
+		t.Fatalf("%d event spans, want 1", len(events))
+	}
+	root := events[0]
+	if root.ParentSpanID != (trace.SpanID{}) {
+		t.Fatalf("event span has parent %s", root.ParentSpanID)
+	}
+	for k, want := range map[string]interface{}{"event.post_type": "message", "event.detail_type": "group",
+		"event.group_id": int64(1), "event.user_id": int64(2), "event.self_id": zerotest.SelfID} {
+		if got := attr(root, k); got != want {
+			t.Errorf("event span %s = %v, want %v", k, got, want)
+		}
+	}
+
+	// 各阶段与 Handler 中开始的 Span 均以事件的 Span 为父
+	for _, name := range []string{"match rule", "match Handler", "work"} {
+		named := spans.Named(name)
+		if len(named) == 0 {
+			t.Errorf("no %q span", name)
+			continue
+		}
+		for _, d := range named {
+			if d.TraceID != root.TraceID || d.ParentSpanID != root.SpanID {
+				t.Errorf("%q span is not a child of the event span", name)
+			}
+		}
+	}
+	var passed, failed int
+	for _, d := range spans.Named("match rule") {
+		if attr(d, "ok") == true {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	if passed != 1 || failed != 1 {
+		t.Errorf("rule spans: %d passed, %d failed, want 1 and 1", passed, failed)
+	}
+	if all := spans.All(); all[len(all)-1].SpanID != root.SpanID {
+		t.Error("event span ended before its children")
+	}
+
+	// 不同事件属于不同的 trace
+	bot.GroupMessage(1, 2, "/trace")
+	if events = spans.Named("event message"); len(events) != 2 || events[1].TraceID == root.TraceID {
+		t.Fatal("second event shares the trace of the first")
+	}
+}
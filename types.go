@@ -25,6 +25,48 @@ type APIResponse struct {
 	Echo    uint64       `json:"echo"`
 }
 
+// apiResponseJSON APIResponse 的 JSON 形式, Data 为原始 JSON
+type apiResponseJSON struct {
+	Status  string          `json:"status"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Message string          `json:"message,omitempty"`
+	Wording string          `json:"wording,omitempty"`
+	RetCode int64           `json:"retcode"`
+	Echo    uint64          `json:"echo,omitempty"`
+}
+
+// MarshalJSON 按 OneBot 响应的格式编码
+func (r APIResponse) MarshalJSON() ([]byte, error) {
+	v := apiResponseJSON{
+		Status:  r.Status,
+		Message: r.Message,
+		Wording: r.Wording,
+		RetCode: r.RetCode,
+		Echo:    r.Echo,
+	}
+	if r.Data.Raw != "" {
+		v.Data = json.RawMessage(r.Data.Raw)
+	}
+	return json.Marshal(&v)
+}
+
+// UnmarshalJSON 解码 OneBot 响应
+func (r *APIResponse) UnmarshalJSON(data []byte) error {
+	var v apiResponseJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*r = APIResponse{
+		Status:  v.Status,
+		Data:    gjson.ParseBytes(v.Data),
+		Message: v.Message,
+		Wording: v.Wording,
+		RetCode: v.RetCode,
+		Echo:    v.Echo,
+	}
+	return nil
+}
+
 // APIRequest is the request sending to the cqhttp
 // https://github.com/botuniverse/onebot-11/blob/master/communication/ws.md
 type APIRequest struct {
@@ -80,6 +122,7 @@ type Event struct {
 	OperatorID    int64           `json:"operator_id"` // This field is used for Notice Event
 	File          *File           `json:"file"`
 	RequestType   string          `json:"request_type"`
+	MetaEventType string          `json:"meta_event_type"`
 	Flag          string          `json:"flag"`
 	Comment       string          `json:"comment"` // This field is used for Request Event
 	Message       message.Message `json:"-"`       // Message parsed
@@ -105,6 +148,16 @@ type File struct {
 	BusID int64  `json:"busid"`
 }
 
+// RawData API 返回的原始 JSON, 用于读取模型尚未覆盖的字段
+//
+// 以字符串保存, 使包含它的模型仍可用 == 比较
+type RawData string
+
+// Get 从原始 JSON 中获取 path 对应的值
+func (r RawData) Get(path string) gjson.Result {
+	return gjson.Get(string(r), path)
+}
+
 // Group 群
 type Group struct {
 	ID             int64  `json:"group_id"`
@@ -113,6 +166,185 @@ type Group struct {
 	MaxMemberCount int64  `json:"max_member_count"`
 }
 
+// LoginInfo 登录号信息
+// https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_login_info-%E8%8E%B7%E5%8F%96%E7%99%BB%E5%BD%95%E5%8F%B7%E4%BF%A1%E6%81%AF
+type LoginInfo struct {
+	UserID   int64   `json:"user_id"`
+	NickName string  `json:"nickname"`
+	Raw      RawData `json:"-"` // 原始 JSON
+}
+
+// Stranger 陌生人信息
+// https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_stranger_info-%E8%8E%B7%E5%8F%96%E9%99%8C%E7%94%9F%E4%BA%BA%E4%BF%A1%E6%81%AF
+type Stranger struct {
+	UserID   int64   `json:"user_id"`
+	NickName string  `json:"nickname"`
+	Sex      string  `json:"sex"` // "male"、"female"、"unknown"
+	Age      int     `json:"age"`
+	Raw      RawData `json:"-"` // 原始 JSON
+}
+
+// Friend 好友
+// https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_friend_list-%E8%8E%B7%E5%8F%96%E5%A5%BD%E5%8F%8B%E5%88%97%E8%A1%A8
+type Friend struct {
+	UserID   int64   `json:"user_id"`
+	NickName string  `json:"nickname"`
+	Remark   string  `json:"remark"`
+	Raw      RawData `json:"-"` // 原始 JSON
+}
+
+// GroupMember 群成员
+// https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_member_info-%E8%8E%B7%E5%8F%96%E7%BE%A4%E6%88%90%E5%91%98%E4%BF%A1%E6%81%AF
+type GroupMember struct {
+	GroupID         int64   `json:"group_id"`
+	UserID          int64   `json:"user_id"`
+	NickName        string  `json:"nickname"`
+	Card            string  `json:"card"`
+	Sex             string  `json:"sex"` // "male"、"female"、"unknown"
+	Age             int     `json:"age"`
+	Area            string  `json:"area"`
+	JoinTime        int64   `json:"join_time"`
+	LastSentTime    int64   `json:"last_sent_time"`
+	Level           string  `json:"level"`
+	Role            string  `json:"role"` // "owner"、"admin"、"member"
+	Unfriendly      bool    `json:"unfriendly"`
+	Title           string  `json:"title"`
+	TitleExpireTime int64   `json:"title_expire_time"`
+	CardChangeable  bool    `json:"card_changeable"`
+	Raw             RawData `json:"-"` // 原始 JSON
+}
+
+// Name 群名片, 没有则为昵称
+func (m *GroupMember) Name() string {
+	if m.Card != "" {
+		return m.Card
+	}
+	return m.NickName
+}
+
+// Honor 群荣誉中的成员
+type Honor struct {
+	UserID      int64   `json:"user_id"`
+	NickName    string  `json:"nickname"`
+	Avatar      string  `json:"avatar"`
+	Description string  `json:"description"`
+	DayCount    int     `json:"day_count"` // 仅 current_talkative 有效
+	Raw         RawData `json:"-"`         // 原始 JSON
+}
+
+// GroupHonorInfo 群荣誉信息
+// https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_group_honor_info-%E8%8E%B7%E5%8F%96%E7%BE%A4%E8%8D%A3%E8%AA%89%E4%BF%A1%E6%81%AF
+type GroupHonorInfo struct {
+	GroupID          int64   `json:"group_id"`
+	CurrentTalkative *Honor  `json:"current_talkative"`
+	TalkativeList    []Honor `json:"talkative_list"`
+	PerformerList    []Honor `json:"performer_list"`
+	LegendList       []Honor `json:"legend_list"`
+	StrongNewbieList []Honor `json:"strong_newbie_list"`
+	EmotionList      []Honor `json:"emotion_list"`
+	Raw              RawData `json:"-"` // 原始 JSON
+}
+
+// ImageInfo 图片信息
+// https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_image-%E8%8E%B7%E5%8F%96%E5%9B%BE%E7%89%87
+type ImageInfo struct {
+	File     string  `json:"file"`
+	FileName string  `json:"filename"`
+	Size     int64   `json:"size"`
+	URL      string  `json:"url"`
+	Raw      RawData `json:"-"` // 原始 JSON
+}
+
+// RecordInfo 语音信息
+// https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_record-%E8%8E%B7%E5%8F%96%E8%AF%AD%E9%9F%B3
+type RecordInfo struct {
+	File string  `json:"file"`
+	Raw  RawData `json:"-"` // 原始 JSON
+}
+
+// VersionInfo 版本信息
+// https://github.com/botuniverse/onebot-11/blob/master/api/public.md#get_version_info-%E8%8E%B7%E5%8F%96%E7%89%88%E6%9C%AC%E4%BF%A1%E6%81%AF
+type VersionInfo struct {
+	AppName         string  `json:"app_name"`
+	AppVersion      string  `json:"app_version"`
+	ProtocolVersion string  `json:"protocol_version"`
+	Raw             RawData `json:"-"` // 原始 JSON
+}
+
+// AtAllRemain 群@全体成员剩余次数
+type AtAllRemain struct {
+	CanAtAll                 bool    `json:"can_at_all"`
+	RemainAtAllCountForGroup int     `json:"remain_at_all_count_for_group"`
+	RemainAtAllCountForUin   int     `json:"remain_at_all_count_for_uin"`
+	Raw                      RawData `json:"-"` // 原始 JSON
+}
+
+// EssenceMessage 群精华消息
+type EssenceMessage struct {
+	SenderID     int64   `json:"sender_id"`
+	SenderNick   string  `json:"sender_nick"`
+	SenderTime   int64   `json:"sender_time"`
+	OperatorID   int64   `json:"operator_id"`
+	OperatorNick string  `json:"operator_nick"`
+	OperatorTime int64   `json:"operator_time"`
+	MessageID    int64   `json:"message_id"`
+	Raw          RawData `json:"-"` // 原始 JSON
+}
+
+// GroupFilesystemInfo 群文件系统信息
+type GroupFilesystemInfo struct {
+	FileCount  int64   `json:"file_count"`
+	LimitCount int64   `json:"limit_count"`
+	UsedSpace  int64   `json:"used_space"`
+	TotalSpace int64   `json:"total_space"`
+	Raw        RawData `json:"-"` // 原始 JSON
+}
+
+// GroupFile 群文件
+type GroupFile struct {
+	GroupID       int64   `json:"group_id"`
+	FileID        string  `json:"file_id"`
+	FileName      string  `json:"file_name"`
+	BusID         int64   `json:"busid"`
+	FileSize      int64   `json:"file_size"`
+	UploadTime    int64   `json:"upload_time"`
+	DeadTime      int64   `json:"dead_time"`
+	ModifyTime    int64   `json:"modify_time"`
+	DownloadTimes int64   `json:"download_times"`
+	Uploader      int64   `json:"uploader"`
+	UploaderName  string  `json:"uploader_name"`
+	Raw           RawData `json:"-"` // 原始 JSON
+}
+
+// GroupFolder 群文件夹
+type GroupFolder struct {
+	GroupID        int64   `json:"group_id"`
+	FolderID       string  `json:"folder_id"`
+	FolderName     string  `json:"folder_name"`
+	CreateTime     int64   `json:"create_time"`
+	Creator        int64   `json:"creator"`
+	CreatorName    string  `json:"creator_name"`
+	TotalFileCount int64   `json:"total_file_count"`
+	Raw            RawData `json:"-"` // 原始 JSON
+}
+
+// GroupFiles 群文件目录内容
+type GroupFiles struct {
+	Files   []GroupFile   `json:"files"`
+	Folders []GroupFolder `json:"folders"`
+	Raw     RawData       `json:"-"` // 原始 JSON
+}
+
+// FileInfo 下载的文件信息
+type FileInfo struct {
+	File     string  `json:"file"`
+	FileName string  `json:"file_name"`
+	FileSize int64   `json:"file_size"`
+	Base64   string  `json:"base64"`
+	URL      string  `json:"url"`
+	Raw      RawData `json:"-"` // 原始 JSON
+}
+
 // Name displays a simple text version of a user.
 func (u *User) Name() string {
 	if u.AnonymousName != "" {
@@ -0,0 +1,77 @@
+// Package shell 提供类 shell 的命令行参数切分
+package shell
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrUnclosedQuote 引号未闭合
+	ErrUnclosedQuote = errors.New("shell: unclosed quote")
+	// ErrTrailingEscape 末尾存在未转义的反斜杠
+	ErrTrailingEscape = errors.New("shell: trailing backslash")
+)
+
+// Parse 将命令行切分为参数列表
+//
+// 支持单引号、双引号与反斜杠转义:
+//   - 单引号内的内容原样保留
+//   - 双引号内仅 \" 与 \\ 会被转义
+//   - 引号外的 \ 会转义下一个字符
+func Parse(s string) ([]string, error) {
+	var (
+		args   []string
+		sb     strings.Builder
+		quote  rune // 当前所在的引号, 0 表示不在引号内
+		escape bool
+		inArg  bool // 处理 "" 这类空参数
+	)
+	for _, r := range s {
+		switch {
+		case escape:
+			if quote == '"' && r != '"' && r != '\\' {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+				continue
+			}
+			sb.WriteRune(r)
+		case r == '\\':
+			escape = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+				continue
+			}
+			sb.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, sb.String())
+				sb.Reset()
+				inArg = false
+			}
+		default:
+			sb.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escape {
+		return nil, ErrTrailingEscape
+	}
+	if quote != 0 {
+		return nil, ErrUnclosedQuote
+	}
+	if inArg {
+		args = append(args, sb.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,48 @@
+package shell
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		in   string
+		want []string
+		err  error
+	}{
+		{"", nil, nil},
+		{"  \t ", nil, nil},
+		{"a b  c", []string{"a", "b", "c"}, nil},
+		{" a\tb\nc ", []string{"a", "b", "c"}, nil},
+		{`"a b" c`, []string{"a b", "c"}, nil},
+		{`'a b' c`, []string{"a b", "c"}, nil},
+		{`a"b c"d`, []string{"ab cd"}, nil},
+		{`"" ''`, []string{"", ""}, nil},
+		{`'a\"b'`, []string{`a\"b`}, nil},
+		{`"a\"b"`, []string{`a"b`}, nil},
+		{`"a\\b"`, []string{`a\b`}, nil},
+		{`"a\nb"`, []string{`a\nb`}, nil},
+		{`"it's"`, []string{"it's"}, nil},
+		{`'say "hi"'`, []string{`say "hi"`}, nil},
+		{`a\ b`, []string{"a b"}, nil},
+		{`\"a\"`, []string{`"a"`}, nil},
+		{`\\`, []string{`\`}, nil},
+		{`-n=3 --name="a b" -v`, []string{"-n=3", "--name=a b", "-v"}, nil},
+		{"你好 世界", []string{"你好", "世界"}, nil},
+		{`"a b`, nil, ErrUnclosedQuote},
+		{`a 'b`, nil, ErrUnclosedQuote},
+		{`a\`, nil, ErrTrailingEscape},
+		{`"a\`, nil, ErrTrailingEscape},
+	} {
+		got, err := Parse(c.in)
+		if !errors.Is(err, c.err) {
+			t.Errorf("Parse(%q) error = %v, want %v", c.in, err, c.err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Parse(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package zerotest
+
+import (
+	"encoding/json"
+	"time"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/message"
+)
+
+// Event 待注入的 OneBot 11 事件
+type Event map[string]interface{}
+
+// EventOption 修改注入的事件
+type EventOption func(Event)
+
+// With 设置事件字段
+func With(key string, value interface{}) EventOption {
+	return func(e Event) {
+		e[key] = value
+	}
+}
+
+// Sender 设置发送者的昵称与群角色 (owner / admin / member)
+func Sender(nickname, role string) EventOption {
+	return func(e Event) {
+		sender, _ := e["sender"].(map[string]interface{})
+		if sender == nil {
+			sender = map[string]interface{}{"user_id": e["user_id"]}
+			e["sender"] = sender
+		}
+		sender["nickname"] = nickname
+		sender["role"] = role
+	}
+}
+
+// Inject 同步注入事件, 在所有 matcher 处理完成后返回
+//
+// 如果 Handler 会等待后续事件 (如 FutureEvent), 请使用 Go
+func (b *Bot) Inject(e Event) {
+	zero.Dispatch(b.marshal(e), b)
+}
+
+// Go 异步注入事件, 返回的 chan 在所有 matcher 处理完成后关闭
+func (b *Bot) Go(e Event) <-chan struct{} {
+	payload := b.marshal(e)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		zero.Dispatch(payload, b)
+	}()
+	return done
+}
+
+func (b *Bot) marshal(e Event) []byte {
+	if _, ok := e["time"]; !ok {
+		e["time"] = time.Now().Unix()
+	}
+	if _, ok := e["self_id"]; !ok {
+		e["self_id"] = b.SelfID
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		panic(err)
+	}
+	return payload
+}
+
+// toMessage 将 string (CQ 码) / message.Message / message.Segment 转为 Message
+func toMessage(msg interface{}) message.Message {
+	switch m := msg.(type) {
+	case string:
+		return message.ParseMessageFromString(m)
+	case message.Message:
+		return m
+	case []message.Segment:
+		return m
+	case message.Segment:
+		return message.Message{m}
+	default:
+		panic("zerotest: unsupported message type")
+	}
+}
+
+// messageEvent 生成消息事件
+func (b *Bot) messageEvent(messageType, subType string, userID int64, msg interface{}, opts []EventOption) Event {
+	m := toMessage(msg)
+	e := Event{
+		"post_type":    "message",
+		"message_type": messageType,
+		"sub_type":     subType,
+		"message_id":   b.nextMessageID(),
+		"user_id":      userID,
+		"message":      m,
+		"raw_message":  m.String(),
+		"font":         0,
+		"sender": map[string]interface{}{
+			"user_id":  userID,
+			"nickname": "user" + itoa(userID),
+		},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// PrivateEvent 生成私聊消息事件, 用于 Go 异步注入
+func (b *Bot) PrivateEvent(userID int64, msg interface{}, opts ...EventOption) Event {
+	return b.messageEvent("private", "friend", userID, msg, opts)
+}
+
+// GroupEvent 生成群消息事件, 用于 Go 异步注入
+//
+//	done := bot.Go(bot.GroupEvent(1, 2, "/register"))
+func (b *Bot) GroupEvent(groupID, userID int64, msg interface{}, opts ...EventOption) Event {
+	opts = append([]EventOption{With("group_id", groupID), Sender("user"+itoa(userID), "member")}, opts...)
+	return b.messageEvent("group", "normal", userID, msg, opts)
+}
+
+// PrivateMessage 注入私聊消息, msg 可为 CQ 码字符串或 message.Message
+func (b *Bot) PrivateMessage(userID int64, msg interface{}, opts ...EventOption) {
+	b.Inject(b.PrivateEvent(userID, msg, opts...))
+}
+
+// GroupMessage 注入群消息, msg 可为 CQ 码字符串或 message.Message
+func (b *Bot) GroupMessage(groupID, userID int64, msg interface{}, opts ...EventOption) {
+	b.Inject(b.GroupEvent(groupID, userID, msg, opts...))
+}
+
+// Notice 注入通知事件
+//
+//	bot.Notice("group_increase", "approve", zerotest.With("group_id", 1), zerotest.With("user_id", 2))
+func (b *Bot) Notice(noticeType, subType string, opts ...EventOption) {
+	e := Event{
+		"post_type":   "notice",
+		"notice_type": noticeType,
+		"sub_type":    subType,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	b.Inject(e)
+}
+
+// Request 注入请求事件
+//
+//	bot.Request("friend", "", zerotest.With("user_id", 2), zerotest.With("flag", "f"))
+func (b *Bot) Request(requestType, subType string, opts ...EventOption) {
+	e := Event{
+		"post_type":    "request",
+		"request_type": requestType,
+		"sub_type":     subType,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	b.Inject(e)
+}
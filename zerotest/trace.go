@@ -0,0 +1,47 @@
+package zerotest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/cubevlmu/CZeroBot/trace"
+)
+
+// Spans 记录导出的 Span
+type Spans struct {
+	mu    sync.Mutex
+	spans []trace.SpanData
+}
+
+// RecordSpans 在测试期间启用追踪, 并记录所有结束的 Span
+func RecordSpans(t testing.TB) *Spans {
+	s := &Spans{}
+	trace.SetExporter(s)
+	t.Cleanup(func() { trace.SetExporter(nil) })
+	return s
+}
+
+// ExportSpan 实现 trace.Exporter
+func (s *Spans) ExportSpan(d *trace.SpanData) {
+	s.mu.Lock()
+	s.spans = append(s.spans, *d)
+	s.mu.Unlock()
+}
+
+// All 按结束顺序返回所有 Span
+func (s *Spans) All() []trace.SpanData {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]trace.SpanData(nil), s.spans...)
+}
+
+// Named 按结束顺序返回名为 name 的 Span
+func (s *Spans) Named(name string) []trace.SpanData {
+	var named []trace.SpanData
+	for _, d := range s.All() {
+		if d.Name == name {
+			named = append(named, d)
+		}
+	}
+	return named
+}
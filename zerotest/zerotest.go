@@ -0,0 +1,306 @@
+// Package zerotest 提供内存中的 OneBot 实现, 用于在测试中运行插件
+//
+//	e := zerotest.Engine(t)
+//	e.OnCommand("ping").Handle(func(ctx *zero.Ctx) { ctx.Send("pong") })
+//	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+//	bot.GroupMessage(1, 2, "/ping")
+//	bot.AssertSent(t, "pong")
+package zerotest
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/message"
+)
+
+// Bot 内存中的 OneBot 实现, 同时是 Driver 与 APICaller
+//
+// 注入的事件经过与真实连接相同的 processEvent → match → Handler 流程,
+// 并在所有 matcher 处理完成后返回
+type Bot struct {
+	SelfID   int64
+	NickName string
+
+	mu       sync.Mutex
+	requests []zero.APIRequest
+	sent     []Sent
+	handlers map[string]func(zero.APIRequest) zero.APIResponse
+	seq      int64 // 消息 ID
+	driver   *driver
+}
+
+// Sent 插件发送的消息
+type Sent struct {
+	Action    string
+	GroupID   int64 // 私聊为 0
+	UserID    int64 // 群聊为 0
+	GuildID   string
+	ChannelID string
+	MessageID int64
+	Message   message.Message
+}
+
+// Text 消息中的纯文本
+func (s *Sent) Text() string {
+	return s.Message.ExtractPlainText()
+}
+
+// New 创建账号为 selfID 的 Bot
+func New(selfID int64) *Bot {
+	return &Bot{
+		SelfID:   selfID,
+		NickName: "zerotest",
+		handlers: map[string]func(zero.APIRequest) zero.APIResponse{},
+	}
+}
+
+// SelfID Start 启动的 Bot 的账号
+const SelfID int64 = 123
+
+// Start 以 cfg 启动账号为 SelfID 的 Bot, 测试结束时自动 Close
+func Start(t testing.TB, cfg zero.Config) *Bot {
+	t.Helper()
+	b := New(SelfID)
+	b.Run(cfg)
+	t.Cleanup(func() {
+		if err := b.Close(); err != nil {
+			t.Errorf("zerotest: close: %v", err)
+		}
+	})
+	return b
+}
+
+// Plugin 注册测试结束时自动注销的插件
+func Plugin(t testing.TB, info zero.PluginInfo) *zero.Engine {
+	e := zero.Register(info)
+	t.Cleanup(e.Delete)
+	return e
+}
+
+// Engine 创建测试结束时自动 Delete 的 Engine
+func Engine(t testing.TB) *zero.Engine {
+	e := zero.New()
+	t.Cleanup(e.Delete)
+	return e
+}
+
+// Run 以 cfg 启动 zero, cfg.Driver 将被替换为本 Bot
+//
+// 同一时间只能运行一个 Bot, 结束时需调用 Close
+func (b *Bot) Run(cfg zero.Config) {
+	b.driver = &driver{bot: b, done: make(chan struct{})}
+	cfg.Driver = []zero.Driver{b.driver}
+	zero.Run(&cfg)
+}
+
+// Close 停止 zero, 最多等待 10s
+func (b *Bot) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return zero.Stop(ctx)
+}
+
+// Handle 使用 f 处理 action 的调用, 覆盖默认响应
+func (b *Bot) Handle(action string, f func(zero.APIRequest) zero.APIResponse) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[action] = f
+}
+
+// Respond 调用 action 时返回 data
+func (b *Bot) Respond(action string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		panic(err)
+	}
+	b.Handle(action, func(zero.APIRequest) zero.APIResponse {
+		return zero.APIResponse{Status: "ok", Data: gjson.ParseBytes(raw)}
+	})
+}
+
+// Fail 调用 action 时返回 retcode 与 msg
+func (b *Bot) Fail(action string, retcode int64, msg string) {
+	b.Handle(action, func(zero.APIRequest) zero.APIResponse {
+		return zero.APIResponse{Status: "failed", RetCode: retcode, Message: msg, Wording: msg}
+	})
+}
+
+// Requests 所有 API 调用, action 非空时仅返回该 action 的调用
+func (b *Bot) Requests(action ...string) []zero.APIRequest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	reqs := make([]zero.APIRequest, 0, len(b.requests))
+	for _, req := range b.requests {
+		if len(action) == 0 || req.Action == action[0] {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
+// Sent 所有发送的消息
+func (b *Bot) Sent() []Sent {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	sent := make([]Sent, len(b.sent))
+	copy(sent, b.sent)
+	return sent
+}
+
+// SentTexts 所有发送的消息的纯文本
+func (b *Bot) SentTexts() []string {
+	sent := b.Sent()
+	texts := make([]string, len(sent))
+	for i := range sent {
+		texts[i] = sent[i].Text()
+	}
+	return texts
+}
+
+// Reset 清空调用与发送记录
+func (b *Bot) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requests = nil
+	b.sent = nil
+}
+
+// AssertSent 断言自上次 Reset 以来发送的消息纯文本依次为 texts
+func (b *Bot) AssertSent(t testing.TB, texts ...string) {
+	t.Helper()
+	got := b.SentTexts()
+	if len(got) != len(texts) {
+		t.Fatalf("zerotest: sent %d messages %q, want %d messages %q", len(got), got, len(texts), texts)
+	}
+	for i := range texts {
+		if got[i] != texts[i] {
+			t.Fatalf("zerotest: message %d is %q, want %q", i, got[i], texts[i])
+		}
+	}
+}
+
+// WaitSent 等待发送的消息达到 n 条, 超过 5s 时失败, 返回发送的消息纯文本
+//
+// 用于等待以 Go 注入的事件的 Handler 发出提示后再注入后续事件
+func (b *Bot) WaitSent(t testing.TB, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got := b.SentTexts()
+		if len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("zerotest: sent %d messages %q, want at least %d", len(got), got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// AssertCalled 断言 action 被调用了 n 次
+func (b *Bot) AssertCalled(t testing.TB, action string, n int) {
+	t.Helper()
+	if got := len(b.Requests(action)); got != n {
+		t.Fatalf("zerotest: %s called %d times, want %d", action, got, n)
+	}
+}
+
+// CallAPI 记录调用并返回响应
+func (b *Bot) CallAPI(req zero.APIRequest) (zero.APIResponse, error) {
+	b.mu.Lock()
+	b.requests = append(b.requests, req)
+	f := b.handlers[req.Action]
+	b.mu.Unlock()
+	var rsp zero.APIResponse
+	if f != nil {
+		rsp = f(req)
+	} else {
+		rsp = b.defaultResponse(req)
+	}
+	rsp.Echo = req.Echo
+	if isSend(req.Action) && rsp.RetCode == 0 {
+		b.recordSent(req, rsp.Data.Get("message_id").Int())
+	}
+	return rsp, nil
+}
+
+// defaultResponse 未设置 Handle 时的响应
+func (b *Bot) defaultResponse(req zero.APIRequest) zero.APIResponse {
+	data := "null"
+	switch {
+	case isSend(req.Action):
+		data = `{"message_id":` + itoa(b.nextMessageID()) + `}`
+	case req.Action == "get_login_info":
+		nickname, _ := json.Marshal(b.NickName)
+		data = `{"user_id":` + itoa(b.SelfID) + `,"nickname":` + string(nickname) + `}`
+	}
+	return zero.APIResponse{Status: "ok", Data: gjson.Parse(data)}
+}
+
+func (b *Bot) nextMessageID() int64 {
+	return atomic.AddInt64(&b.seq, 1)
+}
+
+func isSend(action string) bool {
+	switch action {
+	case "send_msg", "send_private_msg", "send_group_msg", "send_guild_channel_msg":
+		return true
+	}
+	return false
+}
+
+func (b *Bot) recordSent(req zero.APIRequest, id int64) {
+	raw, _ := json.Marshal(req.Params)
+	p := gjson.ParseBytes(raw)
+	s := Sent{
+		Action:    req.Action,
+		GroupID:   p.Get("group_id").Int(),
+		UserID:    p.Get("user_id").Int(),
+		GuildID:   p.Get("guild_id").String(),
+		ChannelID: p.Get("channel_id").String(),
+		MessageID: id,
+		Message:   message.ParseMessage([]byte(p.Get("message").Raw)),
+	}
+	if s.GroupID != 0 {
+		s.UserID = 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, s)
+}
+
+// driver 作为 zero.Driver 注册 Bot
+type driver struct {
+	bot  *Bot
+	once sync.Once
+	done chan struct{}
+}
+
+// Connect 注册 APICaller
+func (d *driver) Connect() {
+	zero.StoreCaller(d.bot.SelfID, d.bot)
+}
+
+// Listen 事件通过 Bot 直接注入, 此处仅等待 Close
+func (d *driver) Listen(func([]byte, zero.APICaller)) {
+	<-d.done
+}
+
+// Close 使 Listen 返回
+func (d *driver) Close() error {
+	d.once.Do(func() { close(d.done) })
+	return nil
+}
+
+func itoa(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
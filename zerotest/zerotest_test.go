@@ -0,0 +1,93 @@
+package zerotest_test
+
+import (
+	"testing"
+
+	zero "github.com/cubevlmu/CZeroBot"
+	"github.com/cubevlmu/CZeroBot/zerotest"
+)
+
+func TestInjectAndCapture(t *testing.T) {
+	e := zerotest.Engine(t)
+	e.OnCommand("ping").Handle(func(ctx *zero.Ctx) {
+		ctx.Send("pong " + ctx.State["args"].(string))
+	})
+	e.OnNotice(func(ctx *zero.Ctx) bool { return ctx.Event.NoticeType == "group_increase" }).
+		Handle(func(ctx *zero.Ctx) {
+			ctx.SendGroupMessage(ctx.Event.GroupID, "welcome")
+		})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	bot.GroupMessage(1, 2, "/ping a")
+	bot.PrivateMessage(2, "/ping b")
+	bot.GroupMessage(1, 2, "ping") // 缺少命令前缀
+	bot.Notice("group_increase", "approve", zerotest.With("group_id", 1), zerotest.With("user_id", 3))
+	bot.AssertSent(t, "pong a", "pong b", "welcome")
+	bot.AssertCalled(t, "send_group_msg", 2)
+	bot.AssertCalled(t, "send_private_msg", 1)
+
+	sent := bot.Sent()
+	if sent[0].GroupID != 1 || sent[0].UserID != 0 {
+		t.Fatalf("group reply sent to group %d user %d", sent[0].GroupID, sent[0].UserID)
+	}
+	if sent[1].GroupID != 0 || sent[1].UserID != 2 {
+		t.Fatalf("private reply sent to group %d user %d", sent[1].GroupID, sent[1].UserID)
+	}
+	if sent[0].MessageID == sent[1].MessageID {
+		t.Fatalf("message ids are not unique: %d", sent[0].MessageID)
+	}
+
+	bot.Reset()
+	bot.AssertSent(t)
+	if n := len(bot.Requests()); n != 0 {
+		t.Fatalf("%d requests after Reset", n)
+	}
+}
+
+func TestRespondAndFail(t *testing.T) {
+	e := zerotest.Engine(t)
+	var name string
+	var rsp zero.APIResponse
+	var err error
+	e.OnCommand("info").Handle(func(ctx *zero.Ctx) {
+		name = ctx.GetGroupInfo(ctx.Event.GroupID, true).Name
+		rsp, err = ctx.API().Call("set_group_ban", zero.Params{"group_id": ctx.Event.GroupID})
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	bot.Respond("get_group_info", map[string]interface{}{"group_id": 1, "group_name": "test"})
+	bot.Fail("set_group_ban", 102, "no permission")
+	bot.GroupMessage(1, 2, "/info")
+
+	if name != "test" {
+		t.Fatalf("group name is %q, want %q", name, "test")
+	}
+	if err == nil || rsp.RetCode != 102 {
+		t.Fatalf("set_group_ban returned retcode %d err %v, want retcode 102 and an error", rsp.RetCode, err)
+	}
+	reqs := bot.Requests("get_group_info")
+	if len(reqs) != 1 || reqs[0].Params["group_id"] != int64(1) {
+		t.Fatalf("get_group_info requests %+v", reqs)
+	}
+}
+
+func TestFutureEventRoundTrip(t *testing.T) {
+	e := zerotest.Engine(t)
+	e.OnCommand("name").Handle(func(ctx *zero.Ctx) {
+		next := ctx.FutureEvent("message", ctx.CheckSession()).Next()
+		ctx.Send("your name?")
+		reply := <-next
+		reply.Send("hello " + reply.Event.RawMessage)
+	})
+
+	bot := zerotest.Start(t, zero.Config{CommandPrefix: "/"})
+
+	done := bot.Go(bot.GroupEvent(1, 2, "/name"))
+	bot.WaitSent(t, 1)
+	bot.GroupMessage(1, 3, "other user") // 其他用户的消息不应被接收
+	bot.GroupMessage(1, 2, "alice")
+	<-done
+	bot.AssertSent(t, "your name?", "hello alice")
+}